@@ -61,7 +61,8 @@ echo "DB_USER=yudo"
 echo "DB_PASSWORD=yudo123"
 echo "DB_NAME=hackathon_getcontact"
 echo "SERVER_PORT=9001"
-echo "JWT_SECRET=HackthonII-2025"
+echo "# At least 32 characters; generate one with: openssl rand -hex 32"
+echo "JWT_SECRET=replace-with-output-of-openssl-rand-hex-32"
 echo "JWT_EXPIRATION=168h"
 echo "EOF"
 echo ""
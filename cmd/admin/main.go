@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"user-service/configs"
+	"user-service/internal/app/repository"
+	"user-service/internal/app/service"
+	"user-service/pkg/db"
+
+	"github.com/joho/godotenv"
+)
+
+// Operator commands that are deliberately not exposed through the public API
+func main() {
+	// Load .env file
+	_ = godotenv.Load("configs/.env")
+
+	// Parse command flags
+	command := flag.String("command", "", "Admin command: restore-user")
+	userID := flag.Uint("user-id", 0, "ID of the user the command applies to")
+	flag.Parse()
+
+	// Load configuration
+	cfg := configs.LoadConfig()
+
+	// Build DSN
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBName,
+	)
+
+	// Initialize database connection
+	database, err := db.NewSQLConnection(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	svc := service.NewService(
+		repository.NewUserRepository(database),
+		repository.NewContactRepository(database),
+		cfg.JWTSecret,
+	)
+
+	// Execute command
+	switch *command {
+	case "restore-user":
+		if *userID == 0 {
+			log.Fatalf("restore-user requires -user-id")
+		}
+		if err := svc.RestoreAccount(context.Background(), uint(*userID)); err != nil {
+			log.Fatalf("❌ Restore failed: %v", err)
+		}
+		fmt.Printf("✅ User %d restored successfully!\n", *userID)
+
+	default:
+		log.Fatalf("Unknown command: %s. Use 'restore-user'", *command)
+	}
+
+	os.Exit(0)
+}
@@ -18,9 +18,25 @@ func main() {
 	_ = godotenv.Load("configs/.env")
 
 	// Parse command flags
-	command := flag.String("command", "up", "Migration command: up, down, or status")
+	command := flag.String("command", "up", "Migration command: up, down, goto, status, plan, or create")
+	version := flag.String("version", "", "Target migration for goto, e.g. 002")
+	name := flag.String("name", "", "Name of the migration to create, e.g. add_foo")
+	dir := flag.String("dir", "internal/app/migrations/sql", "Directory for created migrations")
 	flag.Parse()
 
+	// Scaffolding needs no database
+	if *command == "create" {
+		if *name == "" {
+			log.Fatal("-name is required for create")
+		}
+		id, err := migrations.CreateMigration(*dir, *name, migrations.GetMigrations())
+		if err != nil {
+			log.Fatalf("❌ Failed to create migration: %v", err)
+		}
+		fmt.Printf("✅ Created %s/%s.up.sql and %s.down.sql\n", *dir, id, id)
+		os.Exit(0)
+	}
+
 	// Load configuration
 	cfg := configs.LoadConfig()
 
@@ -64,14 +80,38 @@ func main() {
 		}
 		fmt.Println("✅ Rollback completed successfully!")
 
+	case "goto":
+		if *version == "" {
+			log.Fatal("-version is required for goto")
+		}
+		fmt.Printf("🎯 Migrating to version %s...\n", *version)
+		if err := runner.MigrateTo(*version); err != nil {
+			log.Fatalf("❌ Migration failed: %v", err)
+		}
+		fmt.Println("✅ Database is at the requested version!")
+
 	case "status":
 		fmt.Println("📊 Checking migration status...")
 		if err := runner.Status(); err != nil {
 			log.Fatalf("❌ Status check failed: %v", err)
 		}
 
+	case "plan":
+		plan, err := runner.Plan()
+		if err != nil {
+			log.Fatalf("❌ Planning failed: %v", err)
+		}
+		if len(plan) == 0 {
+			fmt.Println("✅ Database is up to date, nothing to apply")
+			break
+		}
+		fmt.Printf("📋 %d migration(s) would be applied, in order:\n", len(plan))
+		for _, id := range plan {
+			fmt.Printf("  %s\n", id)
+		}
+
 	default:
-		log.Fatalf("Unknown command: %s. Use 'up', 'down', or 'status'", *command)
+		log.Fatalf("Unknown command: %s. Use 'up', 'down', 'goto', 'status', 'plan', or 'create'", *command)
 	}
 
 	os.Exit(0)
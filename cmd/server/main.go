@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 	"user-service/configs"
 	"user-service/internal/app/handlers"
+	"user-service/internal/app/migrations"
 	"user-service/internal/app/routes"
 	"user-service/internal/logger"
 	"user-service/pkg/db"
+	"user-service/pkg/redis"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 // @title Contact Management API
@@ -26,13 +35,19 @@ func main() {
 	// Load .env file
 	_ = godotenv.Load("configs/.env")
 
-	// Load configuration
+	// Load configuration and fail fast when required settings are missing
 	cfg := configs.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize logger
 	logConfig := logger.Config{
-		Level:      "info",
-		OutputPath: "logs/app.log",
+		Level:         cfg.LogLevel,
+		OutputPath:    "logs/app.log",
+		MaxSize:       int64(cfg.LogMaxSizeMB),
+		MaxBackups:    cfg.LogMaxBackups,
+		SensitiveKeys: cfg.LogSensitiveKeys,
 	}
 	if err := logger.Init(logConfig); err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
@@ -54,37 +69,149 @@ func main() {
 	)
 
 	// Initialize database with MySQL
-	database, err := db.NewSQLConnection(dsn)
+	database, err := db.NewSQLConnection(dsn,
+		db.WithPool(db.PoolConfig{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		}),
+		db.WithPrepareStmt(cfg.DBPrepareStmt),
+		db.WithSlowQueryLog(cfg.SlowQueryThreshold, logger.LogSlowQuery),
+	)
 	if err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		log.Fatalf("failed to initialize database: %v", err)
 	}
 
+	// The schema is managed by the SQL migrations in cmd/migrate
 	logger.Info("Database connected successfully")
 
-	// Run auto migrations
-	db.AutoMigrate(database)
-
-	logger.Info("Database migrations completed")
+	// Initialize Redis (optional)
+	var redisClient *goredis.Client
+	if cfg.RedisAddr != "" {
+		redisClient = redis.NewRedisClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+		if err := redis.PingRedis(redisClient); err != nil {
+			logger.Error("Failed to connect to Redis", "error", err)
+			log.Fatalf("failed to connect to redis: %v", err)
+		}
+		defer redisClient.Close()
+		logger.Info("Redis connected successfully")
+	}
 
 	// Initialize Gin router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New() // Use gin.New() instead of gin.Default()
 
+	// Only trusted proxies may set the client IP through X-Forwarded-For; gin trusts all by default
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies", "error", err)
+		log.Fatalf("invalid trusted proxies: %v", err)
+	}
+
 	// Add logger middleware FIRST
-	router.Use(logger.LoggingMiddleware())
+	router.Use(logger.LoggingMiddleware(cfg.MaxBodyBytes))
 
 	// Initialize handler
-	handler := handlers.NewHandler(cfg, database)
+	handler, err := handlers.NewHandler(cfg, database, redisClient)
+	if err != nil {
+		logger.Error("Failed to initialize handler", "error", err)
+		log.Fatalf("failed to initialize handler: %v", err)
+	}
 
 	// Setup routes (pass handler's service)
 	routes.SetupRoutes(router, handler, handler.GetService())
 
-	// Start server on port 9001
-	logger.Info("Server starting", "port", "9001")
-	log.Printf("Starting server on port 9001...")
-	if err := router.Run(":9001"); err != nil {
-		logger.Error("Failed to start server", "error", err)
-		log.Fatalf("failed to start server: %v", err)
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// /ready reports 503 until cmd/migrate has brought the schema up to date
+	if sqlDB, err := database.DB(); err == nil {
+		go waitForMigrations(ctx, migrations.NewRunner(sqlDB), migrationPollInterval)
+	}
+
+	// Start server on the configured port (9001 by default)
+	logger.Info("Server starting", "port", cfg.Port)
+	log.Printf("Starting server on port %s...", cfg.Port)
+	shutdownTimeout := time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	if err := runServer(ctx, ":"+cfg.Port, router, shutdownTimeout); err != nil {
+		logger.Error("Server stopped with error", "error", err)
+	}
+
+	// Stop background work before closing the connections it uses
+	handler.Close()
+
+	// Close the database connection
+	if sqlDB, err := database.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			logger.Error("Failed to close database", "error", err)
+		}
+	}
+
+	logger.Info("Server stopped")
+}
+
+// migrationPollInterval is how often the server checks for pending migrations until it is ready
+const migrationPollInterval = 5 * time.Second
+
+// waitForMigrations marks the service ready once runner has no pending migrations,
+// checking again every interval until then or until ctx is cancelled
+func waitForMigrations(ctx context.Context, runner *migrations.Runner, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := runner.Pending()
+		switch {
+		case err != nil:
+			logger.Warn("Failed to check pending migrations", "error", err)
+		case len(pending) == 0:
+			handlers.SetReady(true)
+			logger.Info("Database migrations applied, service is ready")
+			return
+		default:
+			logger.Warn("Waiting for pending migrations", "pending", pending)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runServer serves handler on addr until ctx is cancelled, then gracefully shuts
+// the server down, waiting up to shutdownTimeout for in-flight requests to finish.
+func runServer(ctx context.Context, addr string, handler http.Handler, shutdownTimeout time.Duration) error {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		// The server failed to start or stopped unexpectedly
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down server", "timeout", shutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
+	return nil
 }
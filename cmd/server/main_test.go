@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// freeAddr returns a local address with a free port
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestRunServer(t *testing.T) {
+	t.Run("returns when context is cancelled", func(t *testing.T) {
+		addr := freeAddr(t)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- runServer(ctx, addr, handler, time.Second)
+		}()
+
+		// Wait until the server accepts requests
+		assert.Eventually(t, func() bool {
+			resp, err := http.Get("http://" + addr)
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		}, 2*time.Second, 10*time.Millisecond)
+
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("runServer did not return after context cancellation")
+		}
+	})
+
+	t.Run("waits for in-flight requests", func(t *testing.T) {
+		addr := freeAddr(t)
+		started := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- runServer(ctx, addr, handler, time.Second)
+		}()
+
+		respErr := make(chan error, 1)
+		go func() {
+			var resp *http.Response
+			var err error
+			for i := 0; i < 100; i++ {
+				resp, err = http.Get("http://" + addr)
+				if err == nil {
+					resp.Body.Close()
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			respErr <- err
+		}()
+
+		<-started
+		cancel()
+
+		assert.NoError(t, <-respErr)
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("returns listen error", func(t *testing.T) {
+		err := runServer(context.Background(), "invalid-address", http.NotFoundHandler(), time.Second)
+		assert.Error(t, err)
+	})
+}
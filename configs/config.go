@@ -1,17 +1,124 @@
 package configs
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// minJWTSecretLength is the shortest JWT_SECRET accepted; HS256 keys need at least 256 bits
+const minJWTSecretLength = 32
+
 type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
 	DBHost     string
 	DBPort     string
-	JWTSecret  string
-	Port       string
+	// DBQueryTimeout bounds each database query (DB_QUERY_TIMEOUT_SECONDS)
+	DBQueryTimeout time.Duration
+	// DBMaxOpenConns, DBMaxIdleConns and DBConnMaxLifetime size the MySQL connection pool; 0 keeps the driver default
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	// SlowQueryThreshold logs queries taking at least this long (SLOW_QUERY_THRESHOLD_MS); 0 disables it
+	SlowQueryThreshold time.Duration
+	// DBPrepareStmt caches prepared statements for the queries GORM runs
+	DBPrepareStmt    bool
+	JWTSecret        string
+	JWTExpiryMinutes int
+	// JWTKeys are kid:secret pairs (comma-separated JWT_KEYS) for rotating the HS256 secret.
+	// New tokens are signed with the JWTActiveKID key (JWT_ACTIVE_KID) and carry its kid;
+	// older tokens verify while their kid is listed. Tokens without a kid verify with
+	// JWT_SECRET, which is optional with JWT_KEYS; without it they are rejected.
+	JWTKeys      []string
+	JWTActiveKID string
+	// JWTAudience is the audience access tokens are issued for and validated against
+	JWTAudience string
+	// JWTPrivateKeyPath and JWTPublicKeyPath are PEM RSA keys; when set tokens use RS256 instead of HS256
+	JWTPrivateKeyPath string
+	JWTPublicKeyPath  string
+	Port              string
+	// LogLevel is the minimum level logged: debug, info, warn or error
+	LogLevel string
+	// LogMaxSizeMB rotates the log file once it exceeds this size; 0 disables rotation.
+	// LogMaxBackups is how many rotated files are kept; 0 keeps all.
+	LogMaxSizeMB  int
+	LogMaxBackups int
+	// LogSensitiveKeys are the JSON keys redacted from logged request bodies
+	// (comma-separated LOG_SENSITIVE_KEYS); when set they replace the logger's default list
+	LogSensitiveKeys []string
+	// MaxBodyBytes is the largest request body accepted; larger requests get 413
+	MaxBodyBytes int64
+	// ReadTimeout and WriteTimeout bound reading and writing requests; ImportTimeout
+	// bounds contact import and export, which may take longer. 0 disables a timeout.
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	ImportTimeout time.Duration
+	// RequireEmailVerification blocks login until the user confirms their email
+	RequireEmailVerification bool
+	// SMTPHost, SMTPPort, SMTPUsername and SMTPPassword configure the server verification
+	// and password reset emails are sent through, from EmailFrom
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+	// EmailLogOnly writes emails, tokens included, to the log instead of sending them;
+	// meant for local development
+	EmailLogOnly bool
+	// RejectDeactivatedTokens rejects still-valid tokens of deactivated accounts
+	RejectDeactivatedTokens bool
+	// BcryptCost is the bcrypt cost new password hashes are made with; older hashes are upgraded on login
+	BcryptCost int
+	// StrictPasswordPolicy requires new passwords to mix upper/lower case letters and digits
+	StrictPasswordPolicy bool
+	RedisAddr            string
+	RedisPassword        string
+	RedisDB              int
+	// LoginMaxAttempts failed logins per email and IP within LoginAttemptWindowMinutes trigger a lockout
+	LoginMaxAttempts          int
+	LoginAttemptWindowMinutes int
+	// NormalizePhoneNumbers stores contact phones in +62 format for duplicate detection
+	NormalizePhoneNumbers bool
+	// AllowDuplicateContactPhones lets a user's contacts share a phone number; duplicates are
+	// returned as warnings instead of rejected
+	AllowDuplicateContactPhones bool
+	// DefaultPageSize and MaxPageSize control contact list pagination
+	DefaultPageSize int
+	MaxPageSize     int
+	// MaxContactsPerUser caps how many contacts an account may have; zero means unlimited
+	MaxContactsPerUser int
+	// ShutdownTimeoutSeconds bounds how long in-flight requests may take to finish on shutdown
+	ShutdownTimeoutSeconds int
+	// AvatarDir is the directory uploaded avatars are stored in
+	AvatarDir string
+	// WriteRateLimit requests per client IP within WriteRateLimitWindowSeconds are allowed on contact writes
+	WriteRateLimit              int
+	WriteRateLimitWindowSeconds int
+	// CORSAllowedOrigins are the browser origins allowed to call the API (comma-separated CORS_ALLOWED_ORIGINS)
+	CORSAllowedOrigins []string
+	// CORSAllowAllOrigins allows every origin, without credentials; meant for local development
+	CORSAllowAllOrigins bool
+	// TrustedProxies are the IPs and CIDRs of proxies whose X-Forwarded-For header gives the
+	// client IP (comma-separated TRUSTED_PROXIES); when empty the connection's address is used
+	TrustedProxies []string
+	// ProfileCacheTTLSeconds is how long profiles are served from Redis; 0 disables the cache
+	ProfileCacheTTLSeconds int
+	// WebhooksEnabled posts contact events to the webhooks users configure
+	WebhooksEnabled bool
+	// WebhookMaxAttempts is how often a failing webhook delivery is tried
+	WebhookMaxAttempts int
+	// WebhookWorkers is how many webhook deliveries run concurrently
+	WebhookWorkers int
+	// IntrospectionUsername and IntrospectionPassword protect token introspection with basic
+	// auth; the endpoint is not registered unless both are set
+	IntrospectionUsername string
+	IntrospectionPassword string
 }
 
 func LoadConfig() Config {
@@ -21,12 +128,203 @@ func LoadConfig() Config {
 	// }
 
 	return Config{
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		JWTSecret:  os.Getenv("JWT_SECRET"),
-		Port:       os.Getenv("PORT"),
+		DBUser:                      os.Getenv("DB_USER"),
+		DBPassword:                  os.Getenv("DB_PASSWORD"),
+		DBName:                      os.Getenv("DB_NAME"),
+		DBHost:                      os.Getenv("DB_HOST"),
+		DBPort:                      os.Getenv("DB_PORT"),
+		DBQueryTimeout:              time.Duration(getEnvInt("DB_QUERY_TIMEOUT_SECONDS", 10)) * time.Second,
+		DBMaxOpenConns:              getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:              getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime:           time.Duration(getEnvInt("DB_CONN_MAX_LIFETIME_SECONDS", 300)) * time.Second,
+		DBPrepareStmt:               getEnvBool("DB_PREPARE_STMT", false),
+		SlowQueryThreshold:          time.Duration(getEnvInt("SLOW_QUERY_THRESHOLD_MS", 0)) * time.Millisecond,
+		JWTSecret:                   os.Getenv("JWT_SECRET"),
+		JWTExpiryMinutes:            getEnvInt("JWT_EXPIRY_MINUTES", 1440),
+		JWTKeys:                     getEnvList("JWT_KEYS"),
+		JWTActiveKID:                os.Getenv("JWT_ACTIVE_KID"),
+		JWTAudience:                 getEnv("JWT_AUDIENCE", "user-service"),
+		JWTPrivateKeyPath:           os.Getenv("JWT_PRIVATE_KEY_PATH"),
+		JWTPublicKeyPath:            os.Getenv("JWT_PUBLIC_KEY_PATH"),
+		Port:                        getEnv("PORT", "9001"),
+		LogLevel:                    getEnv("LOG_LEVEL", "info"),
+		LogMaxSizeMB:                getEnvInt("LOG_MAX_SIZE_MB", 100),
+		LogMaxBackups:               getEnvInt("LOG_MAX_BACKUPS", 5),
+		LogSensitiveKeys:            getEnvList("LOG_SENSITIVE_KEYS"),
+		RequireEmailVerification:    getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+		SMTPHost:                    os.Getenv("SMTP_HOST"),
+		SMTPPort:                    getEnv("SMTP_PORT", "587"),
+		SMTPUsername:                os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:                os.Getenv("SMTP_PASSWORD"),
+		EmailFrom:                   os.Getenv("EMAIL_FROM"),
+		EmailLogOnly:                getEnvBool("EMAIL_LOG_ONLY", false),
+		RejectDeactivatedTokens:     getEnvBool("REJECT_DEACTIVATED_TOKENS", false),
+		StrictPasswordPolicy:        getEnvBool("STRICT_PASSWORD_POLICY", true),
+		BcryptCost:                  getEnvInt("BCRYPT_COST", 10),
+		RedisAddr:                   os.Getenv("REDIS_ADDR"),
+		RedisPassword:               os.Getenv("REDIS_PASSWORD"),
+		RedisDB:                     getEnvInt("REDIS_DB", 0),
+		LoginMaxAttempts:            getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginAttemptWindowMinutes:   getEnvInt("LOGIN_ATTEMPT_WINDOW_MINUTES", 15),
+		NormalizePhoneNumbers:       getEnvBool("NORMALIZE_PHONE_NUMBERS", false),
+		AllowDuplicateContactPhones: getEnvBool("ALLOW_DUPLICATE_CONTACT_PHONES", false),
+		DefaultPageSize:             getEnvInt("DEFAULT_PAGE_SIZE", 10),
+		MaxPageSize:                 getEnvInt("MAX_PAGE_SIZE", 100),
+		MaxContactsPerUser:          getEnvInt("MAX_CONTACTS_PER_USER", 0),
+		ShutdownTimeoutSeconds:      getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 10),
+		AvatarDir:                   getEnv("AVATAR_DIR", "uploads/avatars"),
+		MaxBodyBytes:                int64(getEnvInt("MAX_BODY_BYTES", 4<<20)),
+		ReadTimeout:                 time.Duration(getEnvInt("READ_TIMEOUT_SECONDS", 10)) * time.Second,
+		WriteTimeout:                time.Duration(getEnvInt("WRITE_TIMEOUT_SECONDS", 30)) * time.Second,
+		ImportTimeout:               time.Duration(getEnvInt("IMPORT_TIMEOUT_SECONDS", 120)) * time.Second,
+		WriteRateLimit:              getEnvInt("WRITE_RATE_LIMIT", 60),
+		WriteRateLimitWindowSeconds: getEnvInt("WRITE_RATE_LIMIT_WINDOW_SECONDS", 60),
+		CORSAllowedOrigins:          getEnvList("CORS_ALLOWED_ORIGINS"),
+		CORSAllowAllOrigins:         getEnvBool("CORS_ALLOW_ALL_ORIGINS", false),
+		TrustedProxies:              getEnvList("TRUSTED_PROXIES"),
+		ProfileCacheTTLSeconds:      getEnvInt("PROFILE_CACHE_TTL_SECONDS", 60),
+		WebhooksEnabled:             getEnvBool("WEBHOOKS_ENABLED", false),
+		WebhookMaxAttempts:          getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookWorkers:              getEnvInt("WEBHOOK_WORKERS", 4),
+		IntrospectionUsername:       os.Getenv("INTROSPECTION_USERNAME"),
+		IntrospectionPassword:       os.Getenv("INTROSPECTION_PASSWORD"),
+	}
+}
+
+// Validate checks the settings the server cannot run without, so it fails at startup
+// instead of e.g. signing tokens with an empty secret. It reports every problem at once.
+func (c Config) Validate() error {
+	var problems []string
+
+	// The secret signs HS256 tokens; it is unused when an RSA private key is configured,
+	// and only verifies tokens issued before rotation keys when JWT_KEYS is set
+	if c.JWTPrivateKeyPath == "" {
+		if c.JWTSecret == "" {
+			if len(c.JWTKeys) == 0 {
+				problems = append(problems, "JWT_SECRET is required")
+			}
+		} else if len(c.JWTSecret) < minJWTSecretLength {
+			problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d characters", minJWTSecretLength))
+		}
+
+		if keys, err := c.JWTKeyMap(); err != nil {
+			problems = append(problems, err.Error())
+		} else if len(keys) > 0 || c.JWTActiveKID != "" {
+			for kid, secret := range keys {
+				if len(secret) < minJWTSecretLength {
+					problems = append(problems, fmt.Sprintf("JWT_KEYS secret of %q must be at least %d characters", kid, minJWTSecretLength))
+				}
+			}
+			if _, ok := keys[c.JWTActiveKID]; !ok {
+				problems = append(problems, "JWT_ACTIVE_KID must name a key in JWT_KEYS")
+			}
+		}
+	}
+
+	for _, setting := range []struct{ name, value string }{
+		{"DB_USER", c.DBUser},
+		{"DB_HOST", c.DBHost},
+		{"DB_PORT", c.DBPort},
+		{"DB_NAME", c.DBName},
+		{"PORT", c.Port},
+	} {
+		if setting.value == "" {
+			problems = append(problems, setting.name+" is required")
+		} else if strings.HasSuffix(setting.name, "PORT") && !validPort(setting.value) {
+			problems = append(problems, setting.name+" must be a port number between 1 and 65535")
+		}
+	}
+
+	// Without a sender nobody receives a verification token, so nobody could log in
+	if c.RequireEmailVerification && c.SMTPHost == "" && !c.EmailLogOnly {
+		problems = append(problems, "REQUIRE_EMAIL_VERIFICATION needs SMTP_HOST or EMAIL_LOG_ONLY to send verification emails")
+	}
+	if c.SMTPHost != "" {
+		if c.EmailFrom == "" {
+			problems = append(problems, "EMAIL_FROM is required when SMTP_HOST is set")
+		}
+		if !validPort(c.SMTPPort) {
+			problems = append(problems, "SMTP_PORT must be a port number between 1 and 65535")
+		}
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if !validProxy(proxy) {
+			problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES entry %q must be an IP address or CIDR", proxy))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// JWTKeyMap returns the JWTKeys secrets by key ID
+func (c Config) JWTKeyMap() (map[string]string, error) {
+	keys := make(map[string]string, len(c.JWTKeys))
+	for _, entry := range c.JWTKeys {
+		kid, secret, ok := strings.Cut(entry, ":")
+		kid = strings.TrimSpace(kid)
+		if !ok || kid == "" || secret == "" {
+			return nil, errors.New("JWT_KEYS entries must be kid:secret pairs")
+		}
+		if _, ok := keys[kid]; ok {
+			return nil, fmt.Errorf("JWT_KEYS lists kid %q more than once", kid)
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
+
+// validPort reports whether value is a TCP port number
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+// validProxy reports whether value is an IP address or CIDR range
+func validProxy(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
+
+// getEnvInt reads an integer env var, returning fallback when unset or invalid
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool reads a boolean env var, returning fallback when unset or invalid
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList reads a comma-separated env var, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnv reads a string env var, returning fallback when unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
 }
@@ -0,0 +1,114 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig() Config {
+	return Config{
+		DBUser:    "app",
+		DBHost:    "localhost",
+		DBPort:    "3306",
+		DBName:    "users",
+		JWTSecret: "0123456789abcdef0123456789abcdef",
+		Port:      "9001",
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, validConfig().Validate())
+	})
+
+	t.Run("missing JWT secret", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.JWTSecret = ""
+
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "JWT_SECRET is required")
+	})
+
+	t.Run("short JWT secret", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.JWTSecret = "too-short"
+
+		assert.ErrorContains(t, cfg.Validate(), "JWT_SECRET must be at least 32 characters")
+	})
+
+	t.Run("RSA keys replace the secret", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.JWTSecret = ""
+		cfg.JWTPrivateKeyPath = "keys/private.pem"
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("JWT rotation keys", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.JWTKeys = []string{"2025-01:0123456789abcdef0123456789abcdef", "2025-06:fedcba9876543210fedcba9876543210"}
+		cfg.JWTActiveKID = "2025-06"
+		assert.NoError(t, cfg.Validate())
+
+		keys, err := cfg.JWTKeyMap()
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456789abcdef0123456789abcdef", keys["2025-01"])
+
+		cfg.JWTActiveKID = "2026-01"
+		assert.ErrorContains(t, cfg.Validate(), "JWT_ACTIVE_KID must name a key in JWT_KEYS")
+
+		cfg.JWTActiveKID = "2025-06"
+		cfg.JWTKeys = append(cfg.JWTKeys, "2026-01:short")
+		assert.ErrorContains(t, cfg.Validate(), `JWT_KEYS secret of "2026-01" must be at least 32 characters`)
+
+		cfg.JWTKeys = []string{"no-separator"}
+		assert.ErrorContains(t, cfg.Validate(), "JWT_KEYS entries must be kid:secret pairs")
+	})
+
+	t.Run("JWT rotation keys replace the secret", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.JWTSecret = ""
+		cfg.JWTKeys = []string{"2025-06:fedcba9876543210fedcba9876543210"}
+		cfg.JWTActiveKID = "2025-06"
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("email verification needs a sender", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.RequireEmailVerification = true
+		assert.ErrorContains(t, cfg.Validate(), "REQUIRE_EMAIL_VERIFICATION needs SMTP_HOST or EMAIL_LOG_ONLY")
+
+		cfg.EmailLogOnly = true
+		assert.NoError(t, cfg.Validate())
+
+		cfg.EmailLogOnly = false
+		cfg.SMTPHost = "smtp.example.com"
+		cfg.SMTPPort = "587"
+		assert.ErrorContains(t, cfg.Validate(), "EMAIL_FROM is required when SMTP_HOST is set")
+
+		cfg.EmailFrom = "no-reply@example.com"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("trusted proxies", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.TrustedProxies = []string{"10.0.0.1", "172.16.0.0/12", "2001:db8::/32"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.TrustedProxies = []string{"10.0.0.1", "load-balancer"}
+		assert.ErrorContains(t, cfg.Validate(), `TRUSTED_PROXIES entry "load-balancer" must be an IP address or CIDR`)
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.DBHost = ""
+		cfg.Port = "http"
+
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "DB_HOST is required")
+		assert.ErrorContains(t, err, "PORT must be a port number between 1 and 65535")
+	})
+}
@@ -161,7 +161,8 @@ DB_NAME=hackathon_getcontact
 SERVER_PORT=9001
 
 # JWT Configuration
-JWT_SECRET=HackthonII-2025
+# At least 32 characters; generate one with: openssl rand -hex 32
+JWT_SECRET=replace-with-output-of-openssl-rand-hex-32
 JWT_EXPIRATION=168h
 
 # Redis Configuration (optional)
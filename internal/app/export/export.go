@@ -0,0 +1,78 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"user-service/internal/app/models"
+)
+
+// csvHeader matches the columns accepted by the contacts import endpoint
+var csvHeader = []string{"full_name", "phone", "email", "favorite"}
+
+// WriteCSV writes contacts as CSV with a header row
+func WriteCSV(w io.Writer, contacts []models.Contact) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, contact := range contacts {
+		email := ""
+		if contact.Email != nil {
+			email = *contact.Email
+		}
+		record := []string{contact.FullName, contact.Phone, email, strconv.FormatBool(contact.Favorite)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteVCard writes contacts as vCard 3.0 entries
+func WriteVCard(w io.Writer, contacts []models.Contact) error {
+	writer := bufio.NewWriter(w)
+
+	for _, contact := range contacts {
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:3.0",
+			"N:" + escapeVCard(contact.FullName) + ";;;;",
+			"FN:" + escapeVCard(contact.FullName),
+			"TEL;TYPE=CELL:" + escapeVCard(contact.Phone),
+		}
+		if contact.Email != nil && *contact.Email != "" {
+			lines = append(lines, "EMAIL;TYPE=INTERNET:"+escapeVCard(*contact.Email))
+		}
+		lines = append(lines, "END:VCARD")
+
+		for _, line := range lines {
+			// vCard lines are terminated by CRLF
+			if _, err := writer.WriteString(line + "\r\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Flush()
+}
+
+// vCardEscaper escapes characters that have special meaning in vCard text values
+var vCardEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	",", `\,`,
+	";", `\;`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeVCard(value string) string {
+	return vCardEscaper.Replace(value)
+}
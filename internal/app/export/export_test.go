@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"user-service/internal/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestWriteCSV(t *testing.T) {
+	contacts := []models.Contact{
+		{FullName: "Doe, Jane", Phone: "+62 812, ext 3", Email: strPtr(`"jane",doe@example.com`), Favorite: true},
+		{FullName: "John Smith", Phone: "081234567890"},
+	}
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, contacts)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"full_name,phone,email,favorite\n"+
+			"\"Doe, Jane\",\"+62 812, ext 3\",\"\"\"jane\"\",doe@example.com\",true\n"+
+			"John Smith,081234567890,,false\n",
+		buf.String())
+
+	// The output must round-trip through a CSV reader
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Doe, Jane", "+62 812, ext 3", `"jane",doe@example.com`, "true"}, records[1])
+}
+
+func TestWriteVCard(t *testing.T) {
+	contacts := []models.Contact{
+		{FullName: "Doe, Jane", Phone: "081234567890", Email: strPtr("jane@example.com")},
+		{FullName: "John Smith", Phone: "081234567891"},
+	}
+
+	var buf bytes.Buffer
+	err := WriteVCard(&buf, contacts)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nN:Doe\\, Jane;;;;\r\nFN:Doe\\, Jane\r\nTEL;TYPE=CELL:081234567890\r\nEMAIL;TYPE=INTERNET:jane@example.com\r\nEND:VCARD\r\n"+
+			"BEGIN:VCARD\r\nVERSION:3.0\r\nN:John Smith;;;;\r\nFN:John Smith\r\nTEL;TYPE=CELL:081234567891\r\nEND:VCARD\r\n",
+		buf.String())
+}
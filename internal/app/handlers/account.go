@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportAccount streams everything stored about the user as a JSON file: the profile,
+// audit log entries (when audit logging is enabled) and all contacts
+func (h *Handler) ExportAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	export, err := h.service.ExportAccount(c.Request.Context(), userID.(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="account.json"`)
+	c.Status(http.StatusOK)
+	if err := writeAccountExport(c.Request.Context(), c.Writer, export); err != nil {
+		// Headers are already sent; record the error for logging
+		c.Error(fmt.Errorf("account export failed: %w", err))
+	}
+}
+
+// writeAccountExport writes export as one JSON object, flushing after each batch of
+// contacts so they are sent as they are read
+func writeAccountExport(ctx context.Context, w io.Writer, export *service.AccountExport) error {
+	head, err := json.Marshal(struct {
+		ExportedAt time.Time            `json:"exported_at"`
+		Profile    *models.UserResponse `json:"profile"`
+		AuditLogs  []models.AuditLog    `json:"audit_logs,omitempty"`
+	}{export.ExportedAt, export.Profile, export.AuditLogs})
+	if err != nil {
+		return err
+	}
+
+	// Reopen the object to append the contacts array
+	if _, err := w.Write(head[:len(head)-1]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `,"contacts":[`); err != nil {
+		return err
+	}
+
+	first := true
+	err = export.EachContactBatch(ctx, func(contacts []*models.ContactResponse) error {
+		for _, contact := range contacts {
+			data, err := json.Marshal(contact)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]}")
+	return err
+}
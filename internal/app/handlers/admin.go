@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/service"
+	"user-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevelData represents the log level response data
+type LogLevelData struct {
+	Level string `json:"level"`
+}
+
+// UsersListData represents the admin user listing response data
+type UsersListData struct {
+	Count int                    `json:"count"`
+	Page  int                    `json:"page"`
+	Limit int                    `json:"limit"`
+	Users []*models.UserResponse `json:"users"`
+}
+
+// ListUsers lists all users with pagination, searchable by name or email
+func (h *Handler) ListUsers(c *gin.Context) {
+	var req models.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid query parameters", gin.H{})
+		return
+	}
+
+	resp, err := h.service.ListUsers(c.Request.Context(), &req)
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.setLinkHeaders(c, resp.Pagination, c.Request.URL.Path)
+	data := UsersListData{
+		Count: int(resp.Pagination.Total),
+		Page:  resp.Pagination.Page,
+		Limit: resp.Pagination.Limit,
+		Users: resp.Data.([]*models.UserResponse),
+	}
+
+	h.successResponse(c, http.StatusOK, "Users loaded successfully", data)
+}
+
+// SetUserRole changes the role of a user, e.g. to promote them to admin
+func (h *Handler) SetUserRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid user ID", gin.H{})
+		return
+	}
+
+	var req models.SetRoleRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	if err := h.service.SetRole(c.Request.Context(), uint(userID), req.Role); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidRole) {
+			h.validationErrorResponse(c, "role", []string{"must be one of user, admin"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "User role updated", gin.H{})
+}
+
+// SetLogLevel changes the log level of the running process, e.g. to debug a production
+// issue without a redeploy. The change is not persisted and only affects this instance.
+func (h *Handler) SetLogLevel(c *gin.Context) {
+	var req models.SetLogLevelRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	previous := logger.Level()
+	if err := logger.SetLevel(req.Level); err != nil {
+		h.validationErrorResponse(c, "level", []string{"must be one of debug, info, warn, error"})
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Warn("Log level changed", "from", previous, "to", logger.Level())
+	h.successResponse(c, http.StatusOK, "Log level updated", LogLevelData{Level: logger.Level()})
+}
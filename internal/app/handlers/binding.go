@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"user-service/internal/app/models"
+	"user-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report validation errors by JSON/form field name rather than Go struct field name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// parseBindingError converts validator errors from request binding into a map of
+// field -> failed rules. It returns nil for errors that are not validation errors
+// (e.g. malformed JSON).
+func parseBindingError(err error) map[string][]string {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fields := make(map[string][]string, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields[fe.Field()] = append(fields[fe.Field()], fe.Tag())
+	}
+	return fields
+}
+
+// bindAndNormalize decodes a JSON request body like ShouldBindJSON, but normalizes known
+// request DTOs (see normalizeRequest) before validating them, so that e.g.
+// " Jane@Example.com " passes the email rule and reaches the service trimmed.
+func bindAndNormalize(c *gin.Context, obj interface{}) error {
+	if c.Request == nil || c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	decoder := json.NewDecoder(c.Request.Body)
+	if binding.EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	if binding.EnableDecoderDisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+
+	normalizeRequest(obj)
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// normalizeRequest trims names, tokens and labels, lowercases emails and strips phone
+// separators of the request DTOs that carry them. Passwords and free text are kept as sent.
+func normalizeRequest(obj interface{}) {
+	switch req := obj.(type) {
+	case *models.RegisterRequest:
+		trimField(&req.FullName)
+		normalizeEmailField(&req.Email)
+		normalizePhoneField(req.Phone)
+	case *models.LoginRequest:
+		normalizeEmailField(&req.Email)
+	case *models.ForgotPasswordRequest:
+		normalizeEmailField(&req.Email)
+	case *models.ResetPasswordRequest:
+		trimField(&req.Token)
+	case *models.RefreshTokenRequest:
+		trimField(&req.RefreshToken)
+	case *models.IntrospectTokenRequest:
+		trimField(&req.Token)
+	case *models.UpdateProfileRequest:
+		trimField(req.FullName)
+		normalizeEmailField(req.Email)
+		normalizePhoneField(req.Phone)
+		trimField(req.AvatarURL)
+	case *models.SetWebhookRequest:
+		trimField(&req.URL)
+	case *models.CreateContactRequest:
+		trimField(&req.FullName)
+		normalizePhoneField(&req.Phone)
+		normalizeEmailField(req.Email)
+		normalizeContactPhones(req.Phones)
+		normalizeContactEmails(req.Emails)
+	case *models.UpdateContactRequest:
+		trimField(req.FullName)
+		normalizePhoneField(req.Phone)
+		normalizeEmailField(req.Email)
+		if req.Phones != nil {
+			normalizeContactPhones(*req.Phones)
+		}
+		if req.Emails != nil {
+			normalizeContactEmails(*req.Emails)
+		}
+	}
+}
+
+func normalizeContactPhones(phones []models.ContactPhoneRequest) {
+	for i := range phones {
+		normalizePhoneField(&phones[i].Phone)
+		trimField(&phones[i].Label)
+	}
+}
+
+func normalizeContactEmails(emails []models.ContactEmailRequest) {
+	for i := range emails {
+		normalizeEmailField(&emails[i].Email)
+		trimField(&emails[i].Label)
+	}
+}
+
+// trimField trims a string field; nil (omitted) optional fields are left alone
+func trimField(field *string) {
+	if field != nil {
+		*field = strings.TrimSpace(*field)
+	}
+}
+
+func normalizeEmailField(field *string) {
+	if field != nil {
+		*field = strings.ToLower(strings.TrimSpace(*field))
+	}
+}
+
+func normalizePhoneField(field *string) {
+	if field != nil {
+		*field = utils.CleanPhone(*field)
+	}
+}
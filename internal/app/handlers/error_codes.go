@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+
+	"user-service/internal/app/service"
+	"user-service/internal/middleware"
+)
+
+// Error codes returned in StandardResponse.ErrorCode. Clients should switch on these
+// rather than on the human readable message, whose wording may change. Invalid input
+// is reported as ErrCodeValidation with the failing fields in data.
+const (
+	ErrCodeValidation          = "VALIDATION_ERROR"
+	ErrCodeInternal            = middleware.ErrCodeInternal
+	ErrCodeQueryTimeout        = "QUERY_TIMEOUT"
+	ErrCodeEmailExists         = "EMAIL_EXISTS"
+	ErrCodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	ErrCodeTooManyAttempts     = "TOO_MANY_ATTEMPTS"
+	ErrCodeEmailNotVerified    = "EMAIL_NOT_VERIFIED"
+	ErrCodeAccountDeactivated  = "ACCOUNT_DEACTIVATED"
+	ErrCodeInvalidToken        = middleware.ErrCodeInvalidToken
+	ErrCodeUserNotFound        = "USER_NOT_FOUND"
+	ErrCodeSessionNotFound     = "SESSION_NOT_FOUND"
+	ErrCodeContactNotFound     = "CONTACT_NOT_FOUND"
+	ErrCodePhoneExists         = "PHONE_EXISTS"
+	ErrCodeContactLimit        = "CONTACT_LIMIT_REACHED"
+	ErrCodeRequestBodyTooLarge = middleware.ErrCodeRequestBodyTooLarge
+)
+
+// errorCodes maps service errors to the code reported for them
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{service.ErrEmailAlreadyExists, ErrCodeEmailExists},
+	{service.ErrInvalidCredentials, ErrCodeInvalidCredentials},
+	{service.ErrTooManyAttempts, ErrCodeTooManyAttempts},
+	{service.ErrEmailNotVerified, ErrCodeEmailNotVerified},
+	{service.ErrAccountDeactivated, ErrCodeAccountDeactivated},
+	{service.ErrInvalidToken, ErrCodeInvalidToken},
+	{service.ErrUserNotFound, ErrCodeUserNotFound},
+	{service.ErrSessionNotFound, ErrCodeSessionNotFound},
+	{service.ErrContactNotFound, ErrCodeContactNotFound},
+	{service.ErrPhoneAlreadyExists, ErrCodePhoneExists},
+	{service.ErrContactLimitReached, ErrCodeContactLimit},
+}
+
+// errorCode returns the code for a service error, or ErrCodeInternal for unknown errors
+func errorCode(err error) string {
+	for _, mapping := range errorCodes {
+		if errors.Is(err, mapping.err) {
+			return mapping.code
+		}
+	}
+	return ErrCodeInternal
+}
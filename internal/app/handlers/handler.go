@@ -1,31 +1,192 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"user-service/configs"
+	"user-service/internal/app/export"
 	"user-service/internal/app/models"
 	"user-service/internal/app/repository"
 	"user-service/internal/app/service"
+	"user-service/internal/app/webhook"
+	"user-service/internal/logger"
+	"user-service/internal/middleware"
+
+	"user-service/pkg/coreclient"
+	"user-service/pkg/email"
+	"user-service/pkg/redis"
+	"user-service/pkg/storage"
 
 	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	db      *gorm.DB
-	service *service.Service
+	db        *gorm.DB
+	redis     *goredis.Client
+	service   *service.Service
+	avatarDir string
+	webhooks  *webhook.Dispatcher
+
+	writeRateLimit       int
+	writeRateLimitWindow time.Duration
+	corsAllowedOrigins   []string
+	maxBodyBytes         int64
+	readTimeout          time.Duration
+	writeTimeout         time.Duration
+	importTimeout        time.Duration
+
+	// introspectionUsername and introspectionPassword may call token introspection;
+	// empty disables the endpoint
+	introspectionUsername string
+	introspectionPassword string
 }
 
-func NewHandler(cfg configs.Config, db *gorm.DB) *Handler {
+// AvatarURLPrefix is the path uploaded avatars are served under
+const AvatarURLPrefix = "/uploads/avatars"
+
+// maxAvatarUploadSize is the largest avatar image accepted
+const maxAvatarUploadSize = 2 << 20 // 2MB
+
+// NewHandler wires repositories and the service. redisClient is optional;
+// when nil, features backed by Redis (such as token revocation) are disabled.
+// It fails when configured JWT signing keys cannot be loaded or email verification is
+// required without an email sender.
+func NewHandler(cfg configs.Config, db *gorm.DB, redisClient *goredis.Client) (*Handler, error) {
+	if err := repository.RegisterQueryTimeout(db, cfg.DBQueryTimeout); err != nil {
+		return nil, err
+	}
+
 	userRepo := repository.NewUserRepository(db)
 	contactRepo := repository.NewContactRepository(db)
-	svc := service.NewService(userRepo, contactRepo, cfg.JWTSecret)
-	return &Handler{db: db, service: svc}
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+
+	opts := []service.Option{
+		service.WithRefreshTokenRepository(refreshTokenRepo),
+		service.WithAuditLogRepository(auditLogRepo),
+		service.WithSessionRepository(repository.NewSessionRepository(db)),
+		service.WithAccessTokenTTL(time.Duration(cfg.JWTExpiryMinutes) * time.Minute),
+		service.WithJWTAudience(cfg.JWTAudience),
+		service.WithRequireEmailVerification(cfg.RequireEmailVerification),
+		service.WithStrictPasswordPolicy(cfg.StrictPasswordPolicy),
+		service.WithRejectDeactivatedTokens(cfg.RejectDeactivatedTokens),
+		service.WithPhoneNormalization(cfg.NormalizePhoneNumbers),
+		service.WithDuplicateContactPhones(cfg.AllowDuplicateContactPhones),
+		service.WithPageSizes(cfg.DefaultPageSize, cfg.MaxPageSize),
+		service.WithMaxContactsPerUser(cfg.MaxContactsPerUser),
+		service.WithBcryptCost(cfg.BcryptCost),
+		service.WithAvatarStorage(storage.NewLocalStorage(cfg.AvatarDir, AvatarURLPrefix)),
+	}
+	if redisClient != nil {
+		opts = append(opts,
+			service.WithTokenRevocationStore(redis.NewRevocationStore(redisClient)),
+			service.WithLoginAttemptLimiter(
+				redis.NewLoginAttemptCounter(redisClient),
+				cfg.LoginMaxAttempts,
+				time.Duration(cfg.LoginAttemptWindowMinutes)*time.Minute,
+			),
+			service.WithLastSeenThrottle(redis.NewLastSeenThrottle(redisClient)),
+		)
+		if cfg.ProfileCacheTTLSeconds > 0 {
+			opts = append(opts, service.WithProfileCache(
+				redis.NewProfileCache(redisClient),
+				time.Duration(cfg.ProfileCacheTTLSeconds)*time.Second,
+			))
+		}
+	}
+
+	corsAllowedOrigins := cfg.CORSAllowedOrigins
+	if cfg.CORSAllowAllOrigins {
+		corsAllowedOrigins = []string{"*"}
+	}
+
+	switch {
+	case cfg.SMTPHost != "":
+		opts = append(opts, service.WithEmailSender(email.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom)))
+	case cfg.EmailLogOnly:
+		opts = append(opts, service.WithEmailSender(email.NewLogSender(logger.Info)))
+	case cfg.RequireEmailVerification:
+		return nil, errors.New("email verification is required but no email sender is configured")
+	}
+
+	if len(cfg.JWTKeys) > 0 {
+		keys, err := cfg.JWTKeyMap()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithJWTKeys(keys, cfg.JWTActiveKID))
+	}
+
+	if cfg.JWTPrivateKeyPath != "" || cfg.JWTPublicKeyPath != "" {
+		privateKey, publicKey, err := service.LoadRSAKeys(cfg.JWTPrivateKeyPath, cfg.JWTPublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithRSAKeys(privateKey, publicKey))
+	}
+
+	var svc *service.Service
+	var webhooks *webhook.Dispatcher
+	if cfg.WebhooksEnabled {
+		// Endpoints are looked up through the service, which needs the dispatcher first
+		endpoint := func(ctx context.Context, userID uint) (string, string, error) {
+			return svc.WebhookEndpoint(ctx, userID)
+		}
+		client := coreclient.New("",
+			coreclient.WithTransport(webhook.NewTransport()),
+			coreclient.WithRetry(webhook.RetryPolicy(cfg.WebhookMaxAttempts)),
+		)
+		webhooks = webhook.NewDispatcher(client, endpoint, webhook.WithWorkers(cfg.WebhookWorkers))
+		opts = append(opts, service.WithEventPublisher(webhooks))
+	}
+
+	// Introspection is only enabled with both credentials
+	var introspectionUsername, introspectionPassword string
+	if cfg.IntrospectionUsername != "" && cfg.IntrospectionPassword != "" {
+		introspectionUsername, introspectionPassword = cfg.IntrospectionUsername, cfg.IntrospectionPassword
+	}
+
+	svc = service.NewService(userRepo, contactRepo, cfg.JWTSecret, opts...)
+	if webhooks != nil {
+		webhooks.Start()
+	}
+	return &Handler{
+		db:                   db,
+		redis:                redisClient,
+		service:              svc,
+		avatarDir:            cfg.AvatarDir,
+		webhooks:             webhooks,
+		writeRateLimit:       cfg.WriteRateLimit,
+		writeRateLimitWindow: time.Duration(cfg.WriteRateLimitWindowSeconds) * time.Second,
+		corsAllowedOrigins:   corsAllowedOrigins,
+		maxBodyBytes:         cfg.MaxBodyBytes,
+		readTimeout:          cfg.ReadTimeout,
+		writeTimeout:         cfg.WriteTimeout,
+		importTimeout:        cfg.ImportTimeout,
+
+		introspectionUsername: introspectionUsername,
+		introspectionPassword: introspectionPassword,
+	}, nil
+}
+
+// Close stops background work such as webhook delivery
+func (h *Handler) Close() {
+	if h.webhooks != nil {
+		h.webhooks.Stop()
+	}
 }
 
 // GetService returns the service instance (for middleware)
@@ -33,27 +194,130 @@ func (h *Handler) GetService() *service.Service {
 	return h.service
 }
 
+// GetDB returns the database connection (for metrics)
+func (h *Handler) GetDB() *gorm.DB {
+	return h.db
+}
+
+// GetRedis returns the Redis client, or nil when Redis is not configured
+func (h *Handler) GetRedis() *goredis.Client {
+	return h.redis
+}
+
+// GetWriteRateLimit returns how many write requests a client may make per window
+func (h *Handler) GetWriteRateLimit() (int, time.Duration) {
+	return h.writeRateLimit, h.writeRateLimitWindow
+}
+
+// GetCORSAllowedOrigins returns the origins allowed to make cross-origin requests
+func (h *Handler) GetCORSAllowedOrigins() []string {
+	return h.corsAllowedOrigins
+}
+
+// GetMaxBodyBytes returns the largest request body accepted
+func (h *Handler) GetMaxBodyBytes() int64 {
+	return h.maxBodyBytes
+}
+
+// GetRequestTimeouts returns the timeouts of reading requests, writing requests and
+// contact imports and exports
+func (h *Handler) GetRequestTimeouts() (read, write, imports time.Duration) {
+	return h.readTimeout, h.writeTimeout, h.importTimeout
+}
+
+// GetIntrospectionCredentials returns the basic auth credentials allowed to introspect
+// tokens; both empty when introspection is disabled
+func (h *Handler) GetIntrospectionCredentials() (username, password string) {
+	return h.introspectionUsername, h.introspectionPassword
+}
+
+// GetAvatarDir returns the directory uploaded avatars are stored in (for static serving)
+func (h *Handler) GetAvatarDir() string {
+	return h.avatarDir
+}
+
 // StandardResponse represents the standard API response format
 type StandardResponse struct {
 	Status     int         `json:"status"`
 	StatusCode int         `json:"status_code"`
 	Message    string      `json:"message"`
 	Data       interface{} `json:"data"`
+	// ErrorCode identifies the error for clients; set on error responses caused by a known condition
+	ErrorCode string `json:"error_code,omitempty"`
+	// CorrelationID identifies the request in the logs; set on error responses
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // TokenData represents the token structure in response
 type TokenData struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// IntrospectionData represents the token introspection response data. Claims are only
+// included for tokens that could be verified.
+type IntrospectionData struct {
+	Active    bool   `json:"active"`
+	UserID    uint   `json:"user_id,omitempty"`
+	Email     string `json:"email,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
 }
 
 // AuthResponseData represents the auth response data structure
 type AuthResponseData struct {
-	ID        uint       `json:"id"`
-	FullName  string     `json:"full_name"`
-	Email     string     `json:"email"`
-	Phone     *string    `json:"phone,omitempty"` // Optional field
-	AvatarURL *string    `json:"avatar_url,omitempty"`
-	Token     *TokenData `json:"token,omitempty"`
+	ID            uint       `json:"id"`
+	FullName      string     `json:"full_name"`
+	Email         string     `json:"email"`
+	Phone         *string    `json:"phone,omitempty"` // Optional field
+	AvatarURL     *string    `json:"avatar_url,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	Token         *TokenData `json:"token,omitempty"`
+}
+
+// ProfileData represents the profile response data structure
+type ProfileData struct {
+	AuthResponseData
+	ContactsCount int64      `json:"contacts_count"`
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// EmailAvailabilityData represents the email availability check response data
+type EmailAvailabilityData struct {
+	Available bool `json:"available"`
+}
+
+// SessionsListData represents active session list response data
+type SessionsListData struct {
+	Count    int                       `json:"count"`
+	Sessions []*models.SessionResponse `json:"sessions"`
+}
+
+// AuditLogsListData represents audit log list response data
+type AuditLogsListData struct {
+	Count   int               `json:"count"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+	Entries []models.AuditLog `json:"entries"`
+}
+
+// ContactHistoryData represents contact revision history response data
+type ContactHistoryData struct {
+	Count     int                      `json:"count"`
+	Revisions []models.ContactRevision `json:"revisions"`
+}
+
+// BirthdaysData represents upcoming birthdays response data
+type BirthdaysData struct {
+	Days     int                       `json:"days"`
+	Count    int                       `json:"count"`
+	Contacts []*models.ContactResponse `json:"contacts"`
+}
+
+// RecentContactsData represents recently added contacts response data
+type RecentContactsData struct {
+	Count    int                       `json:"count"`
+	Contacts []*models.ContactResponse `json:"contacts"`
 }
 
 // ContactsListData represents contacts list response data
@@ -62,10 +326,33 @@ type ContactsListData struct {
 	Page     int                       `json:"page"`
 	Limit    int                       `json:"limit"`
 	Contacts []*models.ContactResponse `json:"contacts"`
+	// NextCursor is set when another newest-first page may follow
+	NextCursor string `json:"next_cursor,omitempty"`
+	// HasNext reports whether another page follows; Count is -1 when it was not counted
+	HasNext bool `json:"has_next"`
+	// TotalPages is -1 along with Count; HasNextPage repeats HasNext
+	TotalPages  int  `json:"total_pages"`
+	HasNextPage bool `json:"has_next_page"`
+	HasPrevPage bool `json:"has_prev_page"`
 }
 
+// ResponseFormatHeader selects the response format of a request. With the value
+// ResponseFormatPlain responses carry no StandardResponse envelope: successful ones are
+// the bare data and errors are a PlainErrorResponse. The envelope is the default.
+const (
+	ResponseFormatHeader = middleware.ResponseFormatHeader
+	ResponseFormatPlain  = middleware.ResponseFormatPlain
+)
+
+// PlainErrorResponse is the error body of envelope-less responses
+type PlainErrorResponse = middleware.PlainErrorResponse
+
 // successResponse helper function
 func (h *Handler) successResponse(c *gin.Context, statusCode int, message string, data interface{}) {
+	if middleware.PlainResponse(c) {
+		c.JSON(statusCode, data)
+		return
+	}
 	c.JSON(statusCode, StandardResponse{
 		Status:     1,
 		StatusCode: statusCode,
@@ -76,25 +363,89 @@ func (h *Handler) successResponse(c *gin.Context, statusCode int, message string
 
 // errorResponse helper function
 func (h *Handler) errorResponse(c *gin.Context, statusCode int, message string, data interface{}) {
-	if data == nil {
-		data = gin.H{}
+	h.codedErrorResponse(c, statusCode, "", message, data)
+}
+
+// serviceErrorResponse reports a service error together with its error code
+func (h *Handler) serviceErrorResponse(c *gin.Context, statusCode int, message string, err error, data interface{}) {
+	h.codedErrorResponse(c, statusCode, errorCode(err), message, data)
+}
+
+// codedErrorResponse writes an error response carrying code, in the same shape as the
+// errors of the middleware
+func (h *Handler) codedErrorResponse(c *gin.Context, statusCode int, code, message string, data interface{}) {
+	middleware.ErrorResponse(c, statusCode, code, message, data)
+}
+
+// internalErrorResponse reports an unexpected error, as 504 when a database query timed out
+func (h *Handler) internalErrorResponse(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		h.codedErrorResponse(c, http.StatusGatewayTimeout, ErrCodeQueryTimeout, "Database query timed out", gin.H{})
+		return
 	}
-	c.JSON(statusCode, StandardResponse{
-		Status:     0,
-		StatusCode: statusCode,
-		Message:    message,
-		Data:       data,
-	})
+	h.codedErrorResponse(c, http.StatusInternalServerError, ErrCodeInternal, "Internal server error", gin.H{})
 }
 
 // validationErrorResponse helper function
 func (h *Handler) validationErrorResponse(c *gin.Context, field string, messages []string) {
-	c.JSON(http.StatusBadRequest, StandardResponse{
-		Status:     0,
-		StatusCode: http.StatusBadRequest,
-		Message:    "Validation error",
-		Data:       gin.H{field: messages},
-	})
+	h.validationErrorsResponse(c, map[string][]string{field: messages})
+}
+
+// validationErrorsResponse helper function for multiple invalid fields
+func (h *Handler) validationErrorsResponse(c *gin.Context, fields map[string][]string) {
+	h.codedErrorResponse(c, http.StatusBadRequest, ErrCodeValidation, "Validation error", fields)
+}
+
+// setLinkHeaders adds RFC 5988 Link headers for the first, previous, next and last pages
+// of a paginated list. Links point at baseURL and keep the request's other query params.
+// The last page is left out when the total is unknown.
+func (h *Handler) setLinkHeaders(c *gin.Context, meta models.PaginationMeta, baseURL string) {
+	page, totalPages := meta.Page, meta.TotalPages
+	unknownTotal := totalPages < 0
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	query := c.Request.URL.Query()
+	link := func(target int, rel string) string {
+		query.Set("page", strconv.Itoa(target))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, baseURL, query.Encode(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+	if (unknownTotal && meta.HasNextPage) || (!unknownTotal && page < totalPages) {
+		links = append(links, link(page+1, "next"))
+	}
+	if !unknownTotal {
+		links = append(links, link(totalPages, "last"))
+	}
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// bindingErrorResponse reports which fields failed validation, falling back to a generic
+// message for malformed request bodies
+func (h *Handler) bindingErrorResponse(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		h.codedErrorResponse(c, http.StatusRequestEntityTooLarge, ErrCodeRequestBodyTooLarge, "Request body too large", gin.H{})
+		return
+	}
+	if fields := parseBindingError(err); len(fields) > 0 {
+		h.validationErrorsResponse(c, fields)
+		return
+	}
+	h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+}
+
+// auditContext returns the request context carrying the client IP and user agent
+// recorded in audit logs and sessions
+func (h *Handler) auditContext(c *gin.Context) context.Context {
+	ctx := service.WithClientIP(c.Request.Context(), c.ClientIP())
+	return service.WithUserAgent(ctx, c.Request.UserAgent())
 }
 
 // Ping health check endpoint
@@ -109,46 +460,41 @@ func (h *Handler) Ping(c *gin.Context) {
 // Register handles user registration
 func (h *Handler) Register(c *gin.Context) {
 	var req models.RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
 		return
 	}
 
 	// Call service
-	authResp, err := h.service.Register(c.Request.Context(), &req)
+	authResp, err := h.service.Register(h.auditContext(c), &req)
 	if err != nil {
 		// Handle specific errors
-		if errors.Is(err, service.ErrEmailAlreadyExists) {
-			h.errorResponse(c, http.StatusConflict, "Email already registered", gin.H{})
-			return
-		}
-		if errors.Is(err, service.ErrInvalidEmail) {
-			h.validationErrorResponse(c, "email", []string{"invalid format"})
-			return
-		}
-		if errors.Is(err, service.ErrInvalidPhone) {
-			h.validationErrorResponse(c, "phone", []string{"invalid format"})
+		var verr *service.ValidationError
+		if errors.As(err, &verr) {
+			h.validationErrorsResponse(c, verr.Fields)
 			return
 		}
-		if errors.Is(err, service.ErrWeakPassword) {
-			h.validationErrorResponse(c, "password", []string{"must be at least 8 characters"})
+		if errors.Is(err, service.ErrEmailAlreadyExists) {
+			h.serviceErrorResponse(c, http.StatusConflict, "Email already registered", err, gin.H{})
 			return
 		}
 		// Log the actual error for debugging
 		c.Error(fmt.Errorf("registration failed: %w", err))
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
 	// Format response
 	data := AuthResponseData{
-		ID:        authResp.User.ID,
-		FullName:  authResp.User.FullName,
-		Email:     authResp.User.Email,
-		Phone:     authResp.User.Phone,
-		AvatarURL: authResp.User.AvatarURL,
+		ID:            authResp.User.ID,
+		FullName:      authResp.User.FullName,
+		Email:         authResp.User.Email,
+		Phone:         authResp.User.Phone,
+		AvatarURL:     authResp.User.AvatarURL,
+		EmailVerified: authResp.User.EmailVerified,
 		Token: &TokenData{
-			AccessToken: authResp.Token,
+			AccessToken:  authResp.Token,
+			RefreshToken: authResp.RefreshToken,
 		},
 	}
 
@@ -158,267 +504,1034 @@ func (h *Handler) Register(c *gin.Context) {
 // Login handles user authentication
 func (h *Handler) Login(c *gin.Context) {
 	var req models.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
 		return
 	}
 
+	req.ClientIP = c.ClientIP()
+
 	// Call service
-	authResp, err := h.service.Login(c.Request.Context(), &req)
+	authResp, err := h.service.Login(h.auditContext(c), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			h.serviceErrorResponse(c, http.StatusTooManyRequests, "Too many failed login attempts, please try again later", err, gin.H{})
+			return
+		}
 		if errors.Is(err, service.ErrInvalidCredentials) {
-			h.errorResponse(c, http.StatusUnauthorized, "Invalid email or password", gin.H{})
+			h.serviceErrorResponse(c, http.StatusUnauthorized, "Invalid email or password", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			h.serviceErrorResponse(c, http.StatusForbidden, "Email not verified", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrAccountDeactivated) {
+			h.serviceErrorResponse(c, http.StatusForbidden, "Account is deactivated", err, gin.H{})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
 	// Format response
 	data := AuthResponseData{
-		ID:        authResp.User.ID,
-		FullName:  authResp.User.FullName,
-		Email:     authResp.User.Email,
-		Phone:     authResp.User.Phone,
-		AvatarURL: authResp.User.AvatarURL,
+		ID:            authResp.User.ID,
+		FullName:      authResp.User.FullName,
+		Email:         authResp.User.Email,
+		Phone:         authResp.User.Phone,
+		AvatarURL:     authResp.User.AvatarURL,
+		EmailVerified: authResp.User.EmailVerified,
 		Token: &TokenData{
-			AccessToken: authResp.Token,
+			AccessToken:  authResp.Token,
+			RefreshToken: authResp.RefreshToken,
 		},
 	}
 
 	h.successResponse(c, http.StatusOK, "Login success", data)
 }
 
-// ============================================================================
-// USER PROFILE HANDLERS
-// ============================================================================
-
-// GetProfile retrieves the logged-in user's profile
-func (h *Handler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized - invalid or expired token", gin.H{})
+// RefreshToken exchanges a refresh token for a new token pair
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
 		return
 	}
 
-	profile, err := h.service.GetProfile(c.Request.Context(), userID.(uint))
+	authResp, err := h.service.RefreshToken(h.auditContext(c), req.RefreshToken)
 	if err != nil {
-		if errors.Is(err, service.ErrUserNotFound) {
-			h.errorResponse(c, http.StatusNotFound, "User not found", gin.H{})
+		if errors.Is(err, service.ErrInvalidToken) {
+			h.serviceErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token", err, gin.H{})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	// Format response (without token)
+	// Format response
 	data := AuthResponseData{
-		ID:        profile.ID,
-		FullName:  profile.FullName,
-		Email:     profile.Email,
-		Phone:     profile.Phone,
-		AvatarURL: profile.AvatarURL,
+		ID:            authResp.User.ID,
+		FullName:      authResp.User.FullName,
+		Email:         authResp.User.Email,
+		Phone:         authResp.User.Phone,
+		AvatarURL:     authResp.User.AvatarURL,
+		EmailVerified: authResp.User.EmailVerified,
+		Token: &TokenData{
+			AccessToken:  authResp.Token,
+			RefreshToken: authResp.RefreshToken,
+		},
 	}
 
-	h.successResponse(c, http.StatusOK, "Profile loaded successfully", data)
+	h.successResponse(c, http.StatusOK, "Token refreshed successfully", data)
 }
 
-// UpdateProfile updates the logged-in user's profile
-func (h *Handler) UpdateProfile(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+// IntrospectToken reports whether an access token is active along with its claims.
+// Tokens that cannot be verified are reported as inactive without any claims.
+func (h *Handler) IntrospectToken(c *gin.Context) {
+	var req models.IntrospectTokenRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
 		return
 	}
 
-	var req models.UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+	claims, active, err := h.service.IntrospectToken(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			h.successResponse(c, http.StatusOK, "Token introspected", IntrospectionData{Active: false})
+			return
+		}
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	// Validate full_name if provided
-	if req.FullName != "" && strings.TrimSpace(req.FullName) == "" {
-		h.validationErrorResponse(c, "full_name", []string{"must not be empty"})
+	data := IntrospectionData{
+		Active: active,
+		UserID: claims.UserID,
+		Email:  claims.Email,
+	}
+	if claims.ExpiresAt != nil {
+		data.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		data.IssuedAt = claims.IssuedAt.Unix()
+	}
+	h.successResponse(c, http.StatusOK, "Token introspected", data)
+}
+
+// VerifyEmail confirms a user's email address using the emailed token
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		h.validationErrorResponse(c, "token", []string{"is required"})
 		return
 	}
 
-	profile, err := h.service.UpdateProfile(c.Request.Context(), userID.(uint), &req)
-	if err != nil {
-		if errors.Is(err, service.ErrUserNotFound) {
-			h.errorResponse(c, http.StatusNotFound, "User not found", gin.H{})
-			return
-		}
-		if errors.Is(err, service.ErrInvalidPhone) {
-			h.validationErrorResponse(c, "phone", []string{"invalid format"})
+	if err := h.service.VerifyEmail(c.Request.Context(), token); err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			h.serviceErrorResponse(c, http.StatusBadRequest, "Invalid or expired verification token", err, gin.H{})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	// Format response
-	data := AuthResponseData{
-		ID:        profile.ID,
-		FullName:  profile.FullName,
-		Email:     profile.Email,
-		Phone:     profile.Phone,
-		AvatarURL: profile.AvatarURL,
+	h.successResponse(c, http.StatusOK, "Email verified successfully", gin.H{})
+}
+
+// CheckEmail reports whether an email address can still be used to register
+func (h *Handler) CheckEmail(c *gin.Context) {
+	available, err := h.service.CheckEmailAvailable(c.Request.Context(), c.Query("email"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidEmail) {
+			h.validationErrorResponse(c, "email", []string{"invalid format"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
 	}
 
-	h.successResponse(c, http.StatusOK, "Profile updated successfully", data)
+	h.successResponse(c, http.StatusOK, "Email availability checked", EmailAvailabilityData{Available: available})
 }
 
-// ============================================================================
-// CONTACT HANDLERS
-// ============================================================================
-
-// ListContacts retrieves contacts with search and pagination
-func (h *Handler) ListContacts(c *gin.Context) {
+// ListAuditLogs returns the logged-in user's recent audit log entries
+func (h *Handler) ListAuditLogs(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
 		return
 	}
 
-	// Parse query parameters
-	var req models.ListContactsRequest
+	var req models.ListAuditLogsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		h.errorResponse(c, http.StatusBadRequest, "Invalid query parameters", gin.H{})
 		return
 	}
 
-	// Set defaults
-	if req.Page < 1 {
-		req.Page = 1
+	resp, err := h.service.ListAuditLogs(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
 	}
-	if req.Limit < 1 {
-		req.Limit = 20
+
+	h.setLinkHeaders(c, resp.Pagination, c.Request.URL.Path)
+	data := AuditLogsListData{
+		Count:   int(resp.Pagination.Total),
+		Page:    resp.Pagination.Page,
+		Limit:   resp.Pagination.Limit,
+		Entries: resp.Data.([]models.AuditLog),
 	}
 
-	// Get search query from 'q' parameter
-	req.Search = c.Query("q")
+	h.successResponse(c, http.StatusOK, "Audit log loaded successfully", data)
+}
 
-	resp, err := h.service.ListContacts(c.Request.Context(), userID.(uint), &req)
+// ListSessions lists the logged-in user's active sessions
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), userID.(uint))
 	if err != nil {
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	// Format response
-	data := ContactsListData{
-		Count:    int(resp.Pagination.Total),
-		Page:     resp.Pagination.Page,
-		Limit:    resp.Pagination.Limit,
-		Contacts: resp.Data.([]*models.ContactResponse),
+	data := SessionsListData{
+		Count:    len(sessions),
+		Sessions: sessions,
 	}
 
-	h.successResponse(c, http.StatusOK, "Contacts loaded successfully", data)
+	h.successResponse(c, http.StatusOK, "Sessions loaded successfully", data)
 }
 
-// CreateContact creates a new contact
-func (h *Handler) CreateContact(c *gin.Context) {
+// RevokeSession signs out one of the logged-in user's sessions
+func (h *Handler) RevokeSession(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
 		return
 	}
 
-	var req models.CreateContactRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
-		return
-	}
-
-	contact, err := h.service.CreateContact(c.Request.Context(), userID.(uint), &req)
-	if err != nil {
-		if errors.Is(err, service.ErrPhoneAlreadyExists) {
-			h.errorResponse(c, http.StatusConflict, "Contact phone already exists", gin.H{
-				"phone": []string{req.Phone},
-			})
-			return
-		}
-		if errors.Is(err, service.ErrInvalidPhone) {
-			h.validationErrorResponse(c, "phone", []string{"invalid format"})
-			return
-		}
-		if errors.Is(err, service.ErrInvalidEmail) {
-			h.validationErrorResponse(c, "email", []string{"invalid format"})
-			return
-		}
-		if errors.Is(err, service.ErrInvalidContactData) {
-			h.errorResponse(c, http.StatusBadRequest, err.Error(), gin.H{})
+	if err := h.service.RevokeSession(c.Request.Context(), userID.(uint), c.Param("jti")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Session not found", err, gin.H{})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	h.successResponse(c, http.StatusCreated, "Contact created successfully", contact)
+	h.successResponse(c, http.StatusOK, "Session revoked successfully", gin.H{})
 }
 
-// GetContact retrieves a contact by ID
-func (h *Handler) GetContact(c *gin.Context) {
+// DeactivateAccount deactivates the logged-in user's account; their data is kept
+func (h *Handler) DeactivateAccount(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
 		return
 	}
 
-	contactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
-		return
-	}
-
-	contact, err := h.service.GetContact(c.Request.Context(), userID.(uint), uint(contactID))
-	if err != nil {
-		if errors.Is(err, service.ErrContactNotFound) {
-			h.errorResponse(c, http.StatusNotFound, "Contact not found", gin.H{})
-			return
-		}
-		if errors.Is(err, service.ErrUnauthorizedAccess) {
-			h.errorResponse(c, http.StatusForbidden, "Forbidden", gin.H{})
+	if err := h.service.DeactivateAccount(c.Request.Context(), userID.(uint)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	h.successResponse(c, http.StatusOK, "Contact detail loaded", contact)
+	h.successResponse(c, http.StatusOK, "Account deactivated successfully", gin.H{})
 }
 
-// UpdateContact updates an existing contact
-func (h *Handler) UpdateContact(c *gin.Context) {
+// DeleteAccount permanently deletes the user's account and data after confirming their password
+func (h *Handler) DeleteAccount(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
 		return
 	}
 
-	contactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
-		return
-	}
-
-	var req models.UpdateContactRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+	var req models.DeleteAccountRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
 		return
 	}
 
-	contact, err := h.service.UpdateContact(c.Request.Context(), userID.(uint), uint(contactID), &req)
-	if err != nil {
-		if errors.Is(err, service.ErrContactNotFound) {
-			h.errorResponse(c, http.StatusNotFound, "Contact not found", gin.H{})
+	if err := h.service.DeleteAccount(h.auditContext(c), userID.(uint), req.Password); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
 			return
 		}
-		if errors.Is(err, service.ErrPhoneAlreadyExists) {
-			h.errorResponse(c, http.StatusConflict, "Phone number already exists", gin.H{})
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			h.validationErrorResponse(c, "password", []string{"is incorrect"})
 			return
 		}
-		if errors.Is(err, service.ErrInvalidPhone) {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Account deleted successfully", gin.H{})
+}
+
+// Logout revokes the bearer token used for the request
+func (h *Handler) Logout(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	if err := h.service.Logout(c.Request.Context(), token); err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			h.serviceErrorResponse(c, http.StatusUnauthorized, "Unauthorized - invalid or expired token", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Logout success", gin.H{})
+}
+
+// ============================================================================
+// USER PROFILE HANDLERS
+// ============================================================================
+
+// GetProfile retrieves the logged-in user's profile
+func (h *Handler) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized - invalid or expired token", gin.H{})
+		return
+	}
+
+	profile, err := h.service.GetProfile(c.Request.Context(), userID.(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	contactsCount, err := h.service.CountContacts(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	// Format response (without token)
+	data := ProfileData{
+		AuthResponseData: AuthResponseData{
+			ID:            profile.ID,
+			FullName:      profile.FullName,
+			Email:         profile.Email,
+			Phone:         profile.Phone,
+			AvatarURL:     profile.AvatarURL,
+			EmailVerified: profile.EmailVerified,
+		},
+		ContactsCount: contactsCount,
+		LastSeenAt:    profile.LastSeenAt,
+	}
+
+	h.successResponse(c, http.StatusOK, "Profile loaded successfully", data)
+}
+
+// UpdateProfile updates the logged-in user's profile
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPhone) {
+			h.validationErrorResponse(c, "phone", []string{"invalid format"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidFullName) {
+			h.validationErrorResponse(c, "full_name", []string{"must not be empty"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidEmail) {
+			h.validationErrorResponse(c, "email", []string{"invalid format"})
+			return
+		}
+		if errors.Is(err, service.ErrEmailAlreadyExists) {
+			h.serviceErrorResponse(c, http.StatusConflict, "Email already registered", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	// Format response
+	data := AuthResponseData{
+		ID:            profile.ID,
+		FullName:      profile.FullName,
+		Email:         profile.Email,
+		Phone:         profile.Phone,
+		AvatarURL:     profile.AvatarURL,
+		EmailVerified: profile.EmailVerified,
+	}
+
+	h.successResponse(c, http.StatusOK, "Profile updated successfully", data)
+}
+
+// UploadAvatar stores a PNG or JPEG image as the logged-in user's avatar
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		h.validationErrorResponse(c, "avatar", []string{"required"})
+		return
+	}
+	if fileHeader.Size > maxAvatarUploadSize {
+		h.validationErrorResponse(c, "avatar", []string{"must be at most 2MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Unable to read uploaded file", gin.H{})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAvatarUploadSize+1))
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Unable to read uploaded file", gin.H{})
+		return
+	}
+
+	avatarURL, err := h.service.UpdateAvatar(c.Request.Context(), userID.(uint), data, fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		if errors.Is(err, service.ErrAvatarTooLarge) {
+			h.validationErrorResponse(c, "avatar", []string{"must be at most 2MB"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidAvatarType) {
+			h.validationErrorResponse(c, "avatar", []string{"must be a PNG or JPEG image"})
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Avatar updated successfully", gin.H{"avatar_url": avatarURL})
+}
+
+// SetWebhook configures the URL the user's contact events are posted to
+func (h *Handler) SetWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	var req models.SetWebhookRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	configured, err := h.service.SetWebhook(c.Request.Context(), userID.(uint), req.URL)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhookURL) {
+			h.validationErrorResponse(c, "url", []string{"must be an absolute http or https URL"})
+			return
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Webhook configured successfully", configured)
+}
+
+// DeleteWebhook stops posting the user's contact events
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	if err := h.service.DeleteWebhook(c.Request.Context(), userID.(uint)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Webhook removed successfully", gin.H{})
+}
+
+// ChangePassword changes the logged-in user's password
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+		return
+	}
+
+	err := h.service.ChangePassword(h.auditContext(c), userID.(uint), req.OldPassword, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "User not found", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			h.validationErrorResponse(c, "old_password", []string{"is incorrect"})
+			return
+		}
+		if errors.Is(err, service.ErrWeakPassword) {
+			h.validationErrorResponse(c, "new_password", service.PasswordPolicyMessages(err))
+			return
+		}
+		if errors.Is(err, service.ErrPasswordUnchanged) {
+			h.validationErrorResponse(c, "new_password", []string{"must be different from the old password"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Password changed successfully", gin.H{})
+}
+
+// ForgotPassword emails a password reset token to the given email
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+		return
+	}
+
+	// Unknown emails get the same response so accounts cannot be enumerated
+	err := h.service.RequestPasswordReset(c.Request.Context(), req.Email)
+	if errors.Is(err, service.ErrEmailUnavailable) {
+		h.errorResponse(c, http.StatusServiceUnavailable, "Password reset is not available", gin.H{})
+		return
+	}
+	if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "If the email is registered, a password reset link has been sent", gin.H{})
+}
+
+// ResetPassword sets a new password using a password reset token
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid request body", gin.H{})
+		return
+	}
+
+	err := h.service.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			h.serviceErrorResponse(c, http.StatusBadRequest, "Invalid or expired reset token", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrWeakPassword) {
+			h.validationErrorResponse(c, "new_password", service.PasswordPolicyMessages(err))
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Password reset successfully", gin.H{})
+}
+
+// ============================================================================
+// CONTACT HANDLERS
+// ============================================================================
+
+// ListContacts retrieves contacts with search and pagination
+func (h *Handler) ListContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	// Parse query parameters
+	var req models.ListContactsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid query parameters", gin.H{})
+		return
+	}
+
+	// Page and limit defaults are applied by the service
+
+	// Get search query from 'q' parameter
+	req.Search = c.Query("q")
+
+	resp, err := h.service.ListContacts(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSortField) {
+			h.validationErrorResponse(c, "sort", []string{"must be one of full_name, created_at, favorite, phone"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidSortOrder) {
+			h.validationErrorResponse(c, "order", []string{"must be asc or desc"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidSearchField) {
+			h.validationErrorResponse(c, "search_fields", []string{"must be a comma-separated list of full_name, phone, email"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCursor) {
+			h.validationErrorResponse(c, "cursor", []string{"must be a next_cursor from a newest-first listing"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	// Page links do not apply to keyset pagination, which uses next_cursor instead
+	if req.Cursor == "" {
+		h.setLinkHeaders(c, resp.Pagination, c.Request.URL.Path)
+	}
+
+	// Format response
+	data := ContactsListData{
+		Count:       int(resp.Pagination.Total),
+		Page:        resp.Pagination.Page,
+		Limit:       resp.Pagination.Limit,
+		Contacts:    resp.Data.([]*models.ContactResponse),
+		NextCursor:  resp.Pagination.NextCursor,
+		HasNext:     resp.Pagination.HasNextPage,
+		TotalPages:  resp.Pagination.TotalPages,
+		HasNextPage: resp.Pagination.HasNextPage,
+		HasPrevPage: resp.Pagination.HasPrevPage,
+	}
+
+	h.successResponse(c, http.StatusOK, "Contacts loaded successfully", data)
+}
+
+// CreateContact creates a new contact
+func (h *Handler) CreateContact(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	var req models.CreateContactRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	contact, err := h.service.CreateContact(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		var verr *service.ValidationError
+		if errors.As(err, &verr) {
+			h.validationErrorsResponse(c, verr.Fields)
+			return
+		}
+		if errors.Is(err, service.ErrPhoneAlreadyExists) {
+			h.serviceErrorResponse(c, http.StatusConflict, "Contact phone already exists", err, gin.H{
+				"phone": []string{req.Phone},
+			})
+			return
+		}
+		if errors.Is(err, service.ErrContactLimitReached) {
+			h.serviceErrorResponse(c, http.StatusForbidden, "Contact limit reached", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusCreated, "Contact created successfully", contact)
+}
+
+// ImportContacts creates contacts in bulk from an uploaded file. The source query parameter
+// selects the format: csv (the default), google for a Google Contacts CSV export, or vcard.
+func (h *Handler) ImportContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	source := strings.ToLower(c.DefaultQuery("source", "csv"))
+	if source != "csv" && source != "google" && source != "vcard" {
+		h.validationErrorResponse(c, "source", []string{"must be csv, google or vcard"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		h.validationErrorResponse(c, "file", []string{"required"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		h.validationErrorResponse(c, "file", []string{"must be at most 1MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Unable to read uploaded file", gin.H{})
+		return
+	}
+	defer file.Close()
+
+	parsed, err := parseImportFile(file, source)
+	if err != nil {
+		h.validationErrorResponse(c, "file", []string{err.Error()})
+		return
+	}
+
+	// Rows are not bound from JSON, so normalize them like created contacts
+	for i := range parsed.rows {
+		normalizeRequest(&parsed.rows[i])
+	}
+
+	result, err := h.service.ImportContacts(c.Request.Context(), userID.(uint), parsed.rows)
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	// Report service errors by file line and merge in rows that failed to parse
+	for i := range result.Errors {
+		result.Errors[i].Row = parsed.lines[result.Errors[i].Row-1]
+	}
+	result.Errors = append(result.Errors, parsed.errors...)
+	result.Failed += len(parsed.errors)
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+
+	// Warnings only matter for rows that made it in
+	failed := make(map[int]bool, len(result.Errors))
+	for _, e := range result.Errors {
+		failed[e.Row] = true
+	}
+	for _, w := range parsed.warnings {
+		if !failed[w.Row] {
+			result.Warnings = append(result.Warnings, w)
+		}
+	}
+
+	h.successResponse(c, http.StatusOK, "Contacts imported", result)
+}
+
+// ExportContacts streams the user's contacts as CSV or vCard
+func (h *Handler) ExportContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "vcard" {
+		h.validationErrorResponse(c, "format", []string{"must be csv or vcard"})
+		return
+	}
+
+	contacts, err := h.service.ExportContacts(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	if format == "vcard" {
+		c.Header("Content-Type", "text/vcard; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="contacts.vcf"`)
+		c.Status(http.StatusOK)
+		err = export.WriteVCard(c.Writer, contacts)
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="contacts.csv"`)
+		c.Status(http.StatusOK)
+		err = export.WriteCSV(c.Writer, contacts)
+	}
+	if err != nil {
+		// Headers are already sent; record the error for logging
+		c.Error(fmt.Errorf("export failed: %w", err))
+	}
+}
+
+// GetContact retrieves a contact by ID
+func (h *Handler) GetContact(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	contactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
+		return
+	}
+
+	contact, err := h.service.GetContact(c.Request.Context(), userID.(uint), uint(contactID))
+	if err != nil {
+		if errors.Is(err, service.ErrContactNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Contact not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	etag := contactETag(contact)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contact detail loaded", contact)
+}
+
+// contactETag returns a weak ETag for a contact. It hashes the whole response, which
+// carries the ID and updated_at, so changes that leave updated_at alone, such as
+// replaced tags, still produce a new tag.
+func contactETag(c *models.ContactResponse) string {
+	body, err := json.Marshal(c)
+	if err != nil {
+		body = []byte(fmt.Sprintf("%d-%d", c.ID, c.UpdatedAt.UnixNano()))
+	}
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag using the weak
+// comparison that applies to GET requests
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetContactHistory returns the revisions recorded for a contact, newest first
+func (h *Handler) GetContactHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	contactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
+		return
+	}
+
+	revisions, err := h.service.GetContactHistory(c.Request.Context(), userID.(uint), uint(contactID))
+	if err != nil {
+		if errors.Is(err, service.ErrContactNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Contact not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	data := ContactHistoryData{
+		Count:     len(revisions),
+		Revisions: revisions,
+	}
+	h.successResponse(c, http.StatusOK, "Contact history loaded", data)
+}
+
+// LookupContact resolves a phone number to one of the user's contacts
+func (h *Handler) LookupContact(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	phone := c.Query("phone")
+	if strings.TrimSpace(phone) == "" {
+		h.validationErrorResponse(c, "phone", []string{"phone is required"})
+		return
+	}
+
+	contact, err := h.service.GetContactByPhone(c.Request.Context(), userID.(uint), phone)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPhone) {
+			h.validationErrorResponse(c, "phone", []string{"invalid format"})
+			return
+		}
+		if errors.Is(err, service.ErrContactNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Contact not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contact detail loaded", contact)
+}
+
+// RecentContacts lists the most recently created contacts (limit defaults to 5, at most 20)
+func (h *Handler) RecentContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 {
+		h.validationErrorResponse(c, "limit", []string{"must be a positive number"})
+		return
+	}
+
+	contacts, err := h.service.RecentContacts(c.Request.Context(), userID.(uint), limit)
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	data := RecentContactsData{
+		Count:    len(contacts),
+		Contacts: contacts,
+	}
+	h.successResponse(c, http.StatusOK, "Recent contacts loaded", data)
+}
+
+// UpcomingBirthdays lists contacts whose birthday falls within the next days days (default 30)
+func (h *Handler) UpcomingBirthdays(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 366 {
+		h.validationErrorResponse(c, "days", []string{"must be a number between 1 and 366"})
+		return
+	}
+
+	contacts, err := h.service.UpcomingBirthdays(c.Request.Context(), userID.(uint), days)
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	data := BirthdaysData{
+		Days:     days,
+		Count:    len(contacts),
+		Contacts: contacts,
+	}
+	h.successResponse(c, http.StatusOK, "Upcoming birthdays loaded", data)
+}
+
+// ContactStats returns aggregate counts of the user's contacts for dashboards
+func (h *Handler) ContactStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	stats, err := h.service.ContactStats(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contact stats loaded", stats)
+}
+
+// UpdateContact updates an existing contact. Serves both PUT and PATCH: omitted fields
+// are left unchanged. A PATCH must carry at least one field; an empty PUT saves the
+// contact unchanged, as it did before PATCH was added.
+func (h *Handler) UpdateContact(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	contactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
+		return
+	}
+
+	var req models.UpdateContactRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+	if c.Request.Method == http.MethodPatch && req.IsEmpty() {
+		h.validationErrorResponse(c, "body", []string{"at least one field is required"})
+		return
+	}
+
+	contact, err := h.service.UpdateContact(c.Request.Context(), userID.(uint), uint(contactID), &req)
+	if err != nil {
+		var verr *service.ValidationError
+		if errors.As(err, &verr) {
+			h.validationErrorsResponse(c, verr.Fields)
+			return
+		}
+		if errors.Is(err, service.ErrContactNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Contact not found", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrPhoneAlreadyExists) {
+			h.serviceErrorResponse(c, http.StatusConflict, "Phone number already exists", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPhone) {
 			h.validationErrorResponse(c, "phone", []string{"invalid format"})
 			return
 		}
@@ -426,11 +1539,19 @@ func (h *Handler) UpdateContact(c *gin.Context) {
 			h.validationErrorResponse(c, "email", []string{"invalid format"})
 			return
 		}
-		if errors.Is(err, service.ErrUnauthorizedAccess) {
-			h.errorResponse(c, http.StatusForbidden, "Forbidden", gin.H{})
+		if errors.Is(err, service.ErrInvalidTags) {
+			h.validationErrorResponse(c, "tags", []string{"must be at most 10 tags of up to 30 characters"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidBirthday) {
+			h.validationErrorResponse(c, "birthday", []string{"must be a past date in YYYY-MM-DD format"})
+			return
+		}
+		if errors.Is(err, service.ErrNotesTooLong) {
+			h.validationErrorResponse(c, "notes", []string{"must be at most 2000 characters"})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
@@ -451,19 +1572,141 @@ func (h *Handler) DeleteContact(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteContact(c.Request.Context(), userID.(uint), uint(contactID))
+	err = h.service.DeleteContact(h.auditContext(c), userID.(uint), uint(contactID))
+	if err != nil {
+		if errors.Is(err, service.ErrContactNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Contact not found", err, gin.H{})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contact deleted successfully", gin.H{})
+}
+
+// MergeContacts merges another contact into the contact in the URL
+func (h *Handler) MergeContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
+		return
+	}
+
+	var req models.MergeContactsRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	contact, err := h.service.MergeContacts(c.Request.Context(), userID.(uint), uint(targetID), req.SourceID)
+	if err != nil {
+		if errors.Is(err, service.ErrContactNotFound) {
+			h.serviceErrorResponse(c, http.StatusNotFound, "Contact not found", err, gin.H{})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidContactData) {
+			h.validationErrorResponse(c, "source_id", []string{"must be a different contact"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contacts merged successfully", contact)
+}
+
+// BatchDeleteContacts deletes multiple contacts by ID
+func (h *Handler) BatchDeleteContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	var req models.BatchDeleteContactsRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	deleted, err := h.service.DeleteContacts(h.auditContext(c), userID.(uint), req.IDs)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidContactData) {
+			h.validationErrorResponse(c, "ids", []string{"must contain valid contact IDs"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contacts deleted successfully", gin.H{
+		"deleted": deleted,
+	})
+}
+
+// BatchFavoriteContacts stars or unstars multiple contacts by ID
+func (h *Handler) BatchFavoriteContacts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	var req models.BatchFavoriteContactsRequest
+	if err := bindAndNormalize(c, &req); err != nil {
+		h.bindingErrorResponse(c, err)
+		return
+	}
+
+	updated, err := h.service.SetFavorites(c.Request.Context(), userID.(uint), req.IDs, *req.Favorite)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidContactData) {
+			h.validationErrorResponse(c, "ids", []string{"must contain valid contact IDs"})
+			return
+		}
+		h.internalErrorResponse(c, err)
+		return
+	}
+
+	h.successResponse(c, http.StatusOK, "Contacts updated successfully", gin.H{
+		"updated": updated,
+	})
+}
+
+// RestoreContact recovers a soft-deleted contact
+func (h *Handler) RestoreContact(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		h.errorResponse(c, http.StatusUnauthorized, "Unauthorized", gin.H{})
+		return
+	}
+
+	contactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "Invalid contact ID", gin.H{})
+		return
+	}
+
+	contact, err := h.service.RestoreContact(c.Request.Context(), userID.(uint), uint(contactID))
 	if err != nil {
 		if errors.Is(err, service.ErrContactNotFound) {
-			h.errorResponse(c, http.StatusNotFound, "Contact not found", gin.H{})
+			h.serviceErrorResponse(c, http.StatusNotFound, "Deleted contact not found", err, gin.H{})
 			return
 		}
-		if errors.Is(err, service.ErrUnauthorizedAccess) {
-			h.errorResponse(c, http.StatusForbidden, "Forbidden", gin.H{})
+		if errors.Is(err, service.ErrPhoneAlreadyExists) {
+			h.serviceErrorResponse(c, http.StatusConflict, "Another contact already uses this phone", err, gin.H{})
 			return
 		}
-		h.errorResponse(c, http.StatusInternalServerError, "Internal server error", gin.H{})
+		h.internalErrorResponse(c, err)
 		return
 	}
 
-	h.successResponse(c, http.StatusOK, "Contact deleted successfully", gin.H{})
+	h.successResponse(c, http.StatusOK, "Contact restored successfully", contact)
 }
@@ -1 +1,775 @@
-package handlers_test
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/repository"
+	"user-service/internal/app/service"
+	"user-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func bindJSON(t *testing.T, body string, obj interface{}) error {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return bindAndNormalize(c, obj)
+}
+
+func TestParseBindingError(t *testing.T) {
+	t.Run("missing required fields", func(t *testing.T) {
+		var req models.RegisterRequest
+		err := bindJSON(t, `{}`, &req)
+
+		fields := parseBindingError(err)
+		assert.Equal(t, []string{"required"}, fields["full_name"])
+		assert.Equal(t, []string{"required"}, fields["email"])
+		assert.Equal(t, []string{"required"}, fields["password"])
+		assert.NotContains(t, fields, "phone")
+	})
+
+	t.Run("bad email and short password", func(t *testing.T) {
+		var req models.LoginRequest
+		err := bindJSON(t, `{"email":"not-an-email","password":"123"}`, &req)
+
+		fields := parseBindingError(err)
+		assert.Equal(t, map[string][]string{
+			"email":    {"email"},
+			"password": {"min"},
+		}, fields)
+	})
+
+	t.Run("optional email on contact", func(t *testing.T) {
+		var req models.CreateContactRequest
+		err := bindJSON(t, `{"full_name":"Jane","phone":"081234567890","email":"bad"}`, &req)
+
+		assert.Equal(t, map[string][]string{"email": {"email"}}, parseBindingError(err))
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		var req models.LoginRequest
+		err := bindJSON(t, `{"email":`, &req)
+
+		assert.Error(t, err)
+		assert.Nil(t, parseBindingError(err))
+	})
+}
+
+func TestBindAndNormalize(t *testing.T) {
+	t.Run("contact fields are trimmed", func(t *testing.T) {
+		var req models.CreateContactRequest
+		err := bindJSON(t, `{"full_name":"  Jane Doe  ","phone":" +62 812-3456-7890 ","email":" Jane@Example.com ",`+
+			`"emails":[{"email":" work@example.com ","label":" work "}]}`, &req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane Doe", req.FullName)
+		assert.Equal(t, "+6281234567890", req.Phone)
+		assert.Equal(t, "jane@example.com", *req.Email)
+		assert.Equal(t, []models.ContactEmailRequest{{Email: "work@example.com", Label: "work"}}, req.Emails)
+	})
+
+	t.Run("register trims name and email but not password", func(t *testing.T) {
+		var req models.RegisterRequest
+		err := bindJSON(t, `{"full_name":"\tJohn Doe\n","email":"  john@example.com","password":" secret123 "}`, &req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "John Doe", req.FullName)
+		assert.Equal(t, "john@example.com", req.Email)
+		assert.Equal(t, " secret123 ", req.Password)
+		assert.Nil(t, req.Phone)
+	})
+
+	t.Run("login email is lowercased", func(t *testing.T) {
+		var req models.LoginRequest
+		err := bindJSON(t, `{"email":" John@Example.com ","password":"password123"}`, &req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "john@example.com", req.Email)
+	})
+
+	t.Run("omitted profile fields stay nil", func(t *testing.T) {
+		var req models.UpdateProfileRequest
+		err := bindJSON(t, `{"full_name":" Jane "}`, &req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane", *req.FullName)
+		assert.Nil(t, req.Email)
+		assert.Nil(t, req.Phone)
+	})
+
+	t.Run("whitespace only name is still required", func(t *testing.T) {
+		var req models.CreateContactRequest
+		err := bindJSON(t, `{"full_name":"   ","phone":"081234567890"}`, &req)
+
+		assert.Equal(t, map[string][]string{"full_name": {"required"}}, parseBindingError(err))
+	})
+}
+
+func TestParseContactsCSV(t *testing.T) {
+	t.Run("valid rows with reordered columns", func(t *testing.T) {
+		csvData := "phone,full_name,email,favorite\n" +
+			"081234567890,Jane Doe,jane@example.com,true\n" +
+			"081234567891,\"Doe, John\",,\n"
+
+		parsed, err := parseContactsCSV(strings.NewReader(csvData))
+
+		assert.NoError(t, err)
+		assert.Len(t, parsed.rows, 2)
+		assert.Equal(t, []int{2, 3}, parsed.lines)
+		assert.Equal(t, "Jane Doe", parsed.rows[0].FullName)
+		assert.Equal(t, "jane@example.com", *parsed.rows[0].Email)
+		assert.True(t, parsed.rows[0].Favorite)
+		assert.Equal(t, "Doe, John", parsed.rows[1].FullName)
+		assert.Nil(t, parsed.rows[1].Email)
+		assert.False(t, parsed.rows[1].Favorite)
+		assert.Empty(t, parsed.errors)
+	})
+
+	t.Run("row level errors", func(t *testing.T) {
+		csvData := "full_name,phone,email,favorite\n" +
+			"Jane Doe,081234567890,,maybe\n" +
+			"Too,Many,Fields,true,extra\n" +
+			"John Doe,081234567891,,false\n"
+
+		parsed, err := parseContactsCSV(strings.NewReader(csvData))
+
+		assert.NoError(t, err)
+		assert.Len(t, parsed.rows, 1)
+		assert.Equal(t, []int{4}, parsed.lines)
+		assert.Equal(t, []service.ImportError{
+			{Row: 2, Message: "favorite must be true or false"},
+			{Row: 3, Message: "malformed CSV row"},
+		}, parsed.errors)
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		_, err := parseContactsCSV(strings.NewReader("full_name,email\nJane,jane@example.com\n"))
+
+		assert.EqualError(t, err, "missing phone column")
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		_, err := parseContactsCSV(strings.NewReader(""))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestParseImportFile(t *testing.T) {
+	t.Run("vcard", func(t *testing.T) {
+		vcf := "BEGIN:VCARD\r\nFN:Jane Doe\r\nTEL:081234567890\r\nTEL:081234567891\r\nEND:VCARD\r\n" +
+			"BEGIN:VCARD\r\nFN:No Phone\r\nEND:VCARD\r\n"
+
+		parsed, err := parseImportFile(strings.NewReader(vcf), "vcard")
+
+		assert.NoError(t, err)
+		assert.Len(t, parsed.rows, 1)
+		assert.Equal(t, []int{1}, parsed.lines)
+		assert.Equal(t, []service.ImportError{{Row: 6, Message: "missing phone number"}}, parsed.errors)
+		assert.Equal(t, []service.ImportError{
+			{Row: 1, Message: "only the first phone number was imported, 1 more ignored"},
+		}, parsed.warnings)
+	})
+
+	t.Run("google", func(t *testing.T) {
+		csvData := "Name,E-mail 1 - Value,Phone 1 - Value\nJane Doe,jane@example.com,081234567890\n"
+
+		parsed, err := parseImportFile(strings.NewReader(csvData), "google")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2}, parsed.lines)
+		assert.Equal(t, "Jane Doe", parsed.rows[0].FullName)
+		assert.Equal(t, "jane@example.com", *parsed.rows[0].Email)
+		assert.Empty(t, parsed.errors)
+	})
+
+	t.Run("plain csv is not a google export", func(t *testing.T) {
+		_, err := parseImportFile(strings.NewReader("full_name,phone\nJane,081234567890\n"), "google")
+
+		assert.EqualError(t, err, "is not a Google Contacts CSV file")
+	})
+}
+
+// listRecorder is a ContactRepository stub that records the request passed to List
+type listRecorder struct {
+	repository.ContactRepository
+	req models.ListContactsRequest
+}
+
+func (r *listRecorder) List(ctx context.Context, userID uint, req *models.ListContactsRequest) ([]models.Contact, int64, error) {
+	r.req = *req
+	return []models.Contact{}, 0, nil
+}
+
+func TestListContacts_PageLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"limit omitted uses default", "", 20},
+		{"zero limit uses default", "?limit=0", 20},
+		{"limit within range", "?limit=5", 5},
+		{"limit above max is capped", "?limit=500", 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &listRecorder{}
+			h := &Handler{service: service.NewService(nil, repo, "secret", service.WithPageSizes(20, 50))}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/contacts"+tt.query, nil)
+			c.Set("userID", uint(1))
+
+			h.ListContacts(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var body struct {
+				Data ContactsListData `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expected, repo.req.Limit)
+			assert.Equal(t, repo.req.Limit, body.Data.Limit)
+			assert.Equal(t, 1, body.Data.Page)
+		})
+	}
+}
+
+// pagedContactRepository is a ContactRepository stub reporting a fixed total
+type pagedContactRepository struct {
+	repository.ContactRepository
+	total int64
+}
+
+func (r *pagedContactRepository) List(ctx context.Context, userID uint, req *models.ListContactsRequest) ([]models.Contact, int64, error) {
+	return []models.Contact{}, r.total, nil
+}
+
+func TestListContacts_LinkHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: service.NewService(nil, &pagedContactRepository{total: 40}, "secret")}
+
+	tests := []struct {
+		name     string
+		page     string
+		expected string
+	}{
+		{"middle page", "2", `</api/v1/contacts?limit=10&page=1&q=jane>; rel="first", ` +
+			`</api/v1/contacts?limit=10&page=1&q=jane>; rel="prev", ` +
+			`</api/v1/contacts?limit=10&page=3&q=jane>; rel="next", ` +
+			`</api/v1/contacts?limit=10&page=4&q=jane>; rel="last"`},
+		{"first page has no prev", "1", `</api/v1/contacts?limit=10&page=1&q=jane>; rel="first", ` +
+			`</api/v1/contacts?limit=10&page=2&q=jane>; rel="next", ` +
+			`</api/v1/contacts?limit=10&page=4&q=jane>; rel="last"`},
+		{"last page has no next", "4", `</api/v1/contacts?limit=10&page=1&q=jane>; rel="first", ` +
+			`</api/v1/contacts?limit=10&page=3&q=jane>; rel="prev", ` +
+			`</api/v1/contacts?limit=10&page=4&q=jane>; rel="last"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/contacts?q=jane&limit=10&page="+tt.page, nil)
+			c.Set("userID", uint(1))
+
+			h.ListContacts(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Header().Get("Link"))
+		})
+	}
+}
+
+func TestListContacts_PaginationMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: service.NewService(nil, &pagedContactRepository{total: 45}, "secret")}
+
+	tests := []struct {
+		page        string
+		hasNextPage bool
+		hasPrevPage bool
+	}{
+		{page: "1", hasNextPage: true, hasPrevPage: false},
+		{page: "3", hasNextPage: true, hasPrevPage: true},
+		{page: "5", hasNextPage: false, hasPrevPage: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("page "+tt.page, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/contacts?limit=10&page="+tt.page, nil)
+			c.Set("userID", uint(1))
+
+			h.ListContacts(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var body struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			// The existing fields are kept
+			assert.EqualValues(t, 45, body.Data["count"])
+			assert.EqualValues(t, 10, body.Data["limit"])
+			assert.Equal(t, tt.hasNextPage, body.Data["has_next"])
+			assert.EqualValues(t, 5, body.Data["total_pages"])
+			assert.Equal(t, tt.hasNextPage, body.Data["has_next_page"])
+			assert.Equal(t, tt.hasPrevPage, body.Data["has_prev_page"])
+		})
+	}
+}
+
+// emailExistsRepository is a UserRepository stub reporting taken@example.com as registered
+type emailExistsRepository struct {
+	repository.UserRepository
+}
+
+func (r *emailExistsRepository) CheckEmailExists(ctx context.Context, email string, excludeID uint) (bool, error) {
+	return email == "taken@example.com", nil
+}
+
+func TestCheckEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: service.NewService(&emailExistsRepository{}, nil, "secret")}
+
+	tests := []struct {
+		name      string
+		email     string
+		status    int
+		available bool
+	}{
+		{"registered email", "Taken@Example.com", http.StatusOK, false},
+		{"free email", "free@example.com", http.StatusOK, true},
+		{"malformed email", "not-an-email", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/auth/check-email?email="+url.QueryEscape(tt.email), nil)
+
+			h.CheckEmail(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				var body struct {
+					Data EmailAvailabilityData `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.available, body.Data.Available)
+			}
+		})
+	}
+}
+
+// unknownUserRepository is a UserRepository stub without any users
+type unknownUserRepository struct {
+	repository.UserRepository
+}
+
+func (r *unknownUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return nil, repository.ErrNotFound
+}
+
+func TestErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		repo    repository.UserRepository
+		handle  func(h *Handler) gin.HandlerFunc
+		body    string
+		status  int
+		code    string
+		message string
+	}{
+		{
+			name:    "email conflict",
+			repo:    &emailExistsRepository{},
+			handle:  func(h *Handler) gin.HandlerFunc { return h.Register },
+			body:    `{"full_name":"Jane","email":"taken@example.com","password":"Password123"}`,
+			status:  http.StatusConflict,
+			code:    ErrCodeEmailExists,
+			message: "Email already registered",
+		},
+		{
+			name:    "invalid credentials",
+			repo:    &unknownUserRepository{},
+			handle:  func(h *Handler) gin.HandlerFunc { return h.Login },
+			body:    `{"email":"jane@example.com","password":"Password123"}`,
+			status:  http.StatusUnauthorized,
+			code:    ErrCodeInvalidCredentials,
+			message: "Invalid email or password",
+		},
+		{
+			name:    "validation error",
+			repo:    &unknownUserRepository{},
+			handle:  func(h *Handler) gin.HandlerFunc { return h.Register },
+			body:    `{"full_name":"Jane","email":"jane@example.com","password":"short"}`,
+			status:  http.StatusBadRequest,
+			code:    ErrCodeValidation,
+			message: "Validation error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{service: service.NewService(tt.repo, nil, "secret")}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/v1/auth", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			tt.handle(h)(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			var body StandardResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body.ErrorCode)
+			assert.Equal(t, tt.message, body.Message)
+		})
+	}
+}
+
+// singleContactRepository is a ContactRepository stub holding one contact
+type singleContactRepository struct {
+	repository.ContactRepository
+	contact models.Contact
+}
+
+func (r *singleContactRepository) GetByID(ctx context.Context, userID, contactID uint) (*models.Contact, error) {
+	if contactID != r.contact.ID {
+		return nil, repository.ErrNotFound
+	}
+	contact := r.contact
+	return &contact, nil
+}
+
+func TestGetContact_ETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &singleContactRepository{contact: models.Contact{
+		ID: 7, UserID: 1, FullName: "Jane Doe", Phone: "081234567890",
+		UpdatedAt: time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC),
+	}}
+	h := &Handler{service: service.NewService(nil, repo, "secret")}
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/contacts/7", nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		c.Params = gin.Params{{Key: "id", Value: "7"}}
+		c.Set("userID", uint(1))
+		h.GetContact(c)
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	t.Run("matching etag returns 304", func(t *testing.T) {
+		w := get(etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("etag among several", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotModified, get(`W/"stale", `+etag).Code)
+	})
+
+	t.Run("stale etag returns the contact", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(`W/"stale"`).Code)
+	})
+
+	t.Run("etag changes with any field", func(t *testing.T) {
+		repo.contact.Tags = []string{"work"}
+		defer func() { repo.contact.Tags = nil }()
+
+		w := get(etag)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+}
+
+// slowContactRepository is a ContactRepository stub whose queries time out
+type slowContactRepository struct {
+	repository.ContactRepository
+}
+
+func (r *slowContactRepository) List(ctx context.Context, userID uint, req *models.ListContactsRequest) ([]models.Contact, int64, error) {
+	return nil, 0, fmt.Errorf("failed to count contacts: %w", repository.ErrQueryTimeout)
+}
+
+func TestListContacts_QueryTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: service.NewService(nil, &slowContactRepository{}, "secret")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/contacts", nil)
+	c.Set("userID", uint(1))
+
+	h.ListContacts(c)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestSetLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	defer logger.SetLevel("info")
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("PUT", "/api/v1/admin/log-level", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.SetLogLevel(c)
+		return w
+	}
+
+	w := put(`{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "debug", logger.Level())
+
+	w = put(`{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "debug", logger.Level())
+}
+
+// userListRecorder is a UserRepository stub that records the request passed to List
+type userListRecorder struct {
+	repository.UserRepository
+	req   models.ListUsersRequest
+	total int64
+}
+
+func (r *userListRecorder) List(ctx context.Context, req *models.ListUsersRequest) ([]models.User, int64, error) {
+	r.req = *req
+	return []models.User{{ID: 2, FullName: "Jane Doe", Email: "jane@example.com"}}, r.total, nil
+}
+
+func TestListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &userListRecorder{total: 3}
+	h := &Handler{service: service.NewService(repo, nil, "secret")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/admin/users?q=+jane+&page=2&limit=1", nil)
+
+	h.ListUsers(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.ListUsersRequest{Page: 2, Limit: 1, Search: "jane"}, repo.req)
+
+	var body struct {
+		Data UsersListData `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 3, body.Data.Count)
+	assert.Equal(t, 2, body.Data.Page)
+	if assert.Len(t, body.Data.Users, 1) {
+		assert.Equal(t, "jane@example.com", body.Data.Users[0].Email)
+	}
+	assert.Contains(t, w.Header().Get("Link"), `page=3&q=+jane+>; rel="next"`)
+}
+
+// accountUserRepository is a UserRepository stub holding one user
+type accountUserRepository struct {
+	repository.UserRepository
+}
+
+func (r *accountUserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	return &models.User{ID: id, FullName: "Jane Doe", Email: "jane@example.com"}, nil
+}
+
+// batchContactRepository is a ContactRepository stub returning its contacts two at a time
+type batchContactRepository struct {
+	repository.ContactRepository
+	contacts []models.Contact
+}
+
+func (r *batchContactRepository) ListInBatches(ctx context.Context, userID uint, batchSize int, fn func([]models.Contact) error) error {
+	for start := 0; start < len(r.contacts); start += 2 {
+		if err := fn(r.contacts[start:min(start+2, len(r.contacts))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &batchContactRepository{contacts: []models.Contact{
+		{ID: 1, FullName: "John Doe", Phone: "081234567890"},
+		{ID: 2, FullName: "Mary Major", Phone: "081234567891", Phones: []models.ContactPhone{
+			{Phone: "081234567891", IsPrimary: true}, {Phone: "082222222222", Label: "home"},
+		}},
+		{ID: 3, FullName: "Richard Roe", Phone: "081234567892", Tags: []string{"work"}},
+	}}
+	h := &Handler{service: service.NewService(&accountUserRepository{}, repo, "secret")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/me/export", nil)
+	c.Set("userID", uint(1))
+
+	h.ExportAccount(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="account.json"`, w.Header().Get("Content-Disposition"))
+
+	var bundle struct {
+		ExportedAt time.Time                `json:"exported_at"`
+		Profile    models.UserResponse      `json:"profile"`
+		AuditLogs  []models.AuditLog        `json:"audit_logs"`
+		Contacts   []models.ContactResponse `json:"contacts"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &bundle))
+	assert.False(t, bundle.ExportedAt.IsZero())
+	assert.Equal(t, "jane@example.com", bundle.Profile.Email)
+	// Audit logging is disabled
+	assert.Nil(t, bundle.AuditLogs)
+	if assert.Len(t, bundle.Contacts, 3) {
+		assert.Equal(t, "John Doe", bundle.Contacts[0].FullName)
+		// The secondary phone is exported alongside the primary one
+		assert.Equal(t, []models.ContactPhone{
+			{Phone: "081234567891", IsPrimary: true}, {Phone: "082222222222", Label: "home"},
+		}, bundle.Contacts[1].Phones)
+		assert.Equal(t, []string{"work"}, bundle.Contacts[2].Tags)
+	}
+}
+
+func TestUpdateContact_EmptyPatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// No repositories: an empty patch must be rejected before reaching the service
+	h := &Handler{service: service.NewService(nil, nil, "secret")}
+
+	for _, body := range []string{`{}`, `{"full_name":null,"tags":null}`} {
+		t.Run(body, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PATCH", "/api/v1/contacts/1", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			c.Set("userID", uint(1))
+
+			h.UpdateContact(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp struct {
+				Data map[string][]string `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, []string{"at least one field is required"}, resp.Data["body"])
+		})
+	}
+}
+
+// updatedContactRepository is a singleContactRepository stub that records updates
+type updatedContactRepository struct {
+	singleContactRepository
+	updated bool
+}
+
+func (r *updatedContactRepository) Update(ctx context.Context, contact *models.Contact, children repository.ContactChildren) error {
+	r.updated = true
+	return nil
+}
+
+func TestUpdateContact_EmptyPut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &updatedContactRepository{singleContactRepository: singleContactRepository{contact: models.Contact{
+		ID: 1, UserID: 1, FullName: "John Doe", Phone: "+6281234567890",
+	}}}
+	h := &Handler{service: service.NewService(nil, repo, "secret")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PUT", "/api/v1/contacts/1", strings.NewReader(`{}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Set("userID", uint(1))
+
+	h.UpdateContact(c)
+
+	// Unlike PATCH, an empty PUT is accepted and leaves the contact as it was
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, repo.updated)
+	assert.Contains(t, w.Body.String(), `"full_name":"John Doe"`)
+}
+
+func TestResponseFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: service.NewService(&emailExistsRepository{}, nil, "secret")}
+
+	checkEmail := func(email, format string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/auth/check-email?email="+url.QueryEscape(email), nil)
+		if format != "" {
+			c.Request.Header.Set(ResponseFormatHeader, format)
+		}
+		h.CheckEmail(c)
+		return w
+	}
+
+	t.Run("envelope by default", func(t *testing.T) {
+		w := checkEmail("free@example.com", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":1,"status_code":200,"message":"Email availability checked","data":{"available":true}}`, w.Body.String())
+	})
+
+	t.Run("plain success is the bare data", func(t *testing.T) {
+		w := checkEmail("free@example.com", "plain")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"available":true}`, w.Body.String())
+	})
+
+	t.Run("envelope error", func(t *testing.T) {
+		w := checkEmail("not-an-email", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var body StandardResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 0, body.Status)
+		assert.Equal(t, ErrCodeValidation, body.ErrorCode)
+	})
+
+	t.Run("plain error", func(t *testing.T) {
+		w := checkEmail("not-an-email", "Plain")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"message":"Validation error","error_code":"VALIDATION_ERROR","details":{"email":["invalid format"]}}`, w.Body.String())
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"user-service/internal/logger"
+	"user-service/pkg/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds each dependency check so a hung dependency
+// cannot block the readiness endpoint
+const healthCheckTimeout = 2 * time.Second
+
+// ready is set once the database schema is migrated; until then the
+// readiness endpoint reports 503 without checking dependencies
+var ready atomic.Bool
+
+// SetReady marks whether the service has finished starting up, i.e. its
+// migrations have run, and may receive traffic
+func SetReady(r bool) {
+	ready.Store(r)
+}
+
+// HealthCheck is the liveness probe: it only reports that the process is up
+// and serving, so a failing dependency does not get the process restarted.
+func (h *Handler) HealthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "healthy",
+		"service": "contact-management-api",
+		"version": "1.0.0",
+	})
+}
+
+// Readiness is the readiness probe. It reports the status of each dependency
+// and responds with 503 until migrations have run and while any check fails.
+// The probe is unauthenticated, so failures are logged and reported only as
+// "unhealthy", never with the error that may reveal hosts or credentials.
+func (h *Handler) Readiness(c *gin.Context) {
+	if !ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not ready",
+			"checks": gin.H{"migrations": "pending"},
+		})
+		return
+	}
+
+	checks := gin.H{"migrations": "ok"}
+	healthy := true
+
+	log := logger.FromContext(c.Request.Context())
+	if err := h.checkDatabase(c.Request.Context()); err != nil {
+		log.Error("Readiness check failed", "dependency", "database", "error", err)
+		checks["database"] = "unhealthy"
+		healthy = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if h.redis == nil {
+		checks["redis"] = "disabled"
+	} else if err := h.checkRedis(c.Request.Context()); err != nil {
+		log.Error("Readiness check failed", "dependency", "redis", "error", err)
+		checks["redis"] = "unhealthy"
+		healthy = false
+	} else {
+		checks["redis"] = "ok"
+	}
+
+	status, code := "ready", http.StatusOK
+	if !healthy {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// checkDatabase pings the database connection
+func (h *Handler) checkDatabase(ctx context.Context) error {
+	if h.db == nil {
+		return errors.New("database not configured")
+	}
+
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	return sqlDB.PingContext(ctx)
+}
+
+// checkRedis pings the Redis client
+func (h *Handler) checkRedis(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	return redis.PingRedisContext(ctx, h.redis)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func newHealthHandler(t *testing.T, pingErr error) (*Handler, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("failed to open gorm connection: %v", err)
+	}
+
+	mock.ExpectPing().WillReturnError(pingErr)
+	return &Handler{db: gormDB}, mock
+}
+
+func performProbe(probe gin.HandlerFunc, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", path, nil)
+
+	probe(c)
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+// setReadyForTest sets the readiness flag and resets it when the test ends
+func setReadyForTest(t *testing.T, r bool) {
+	t.Helper()
+	SetReady(r)
+	t.Cleanup(func() { SetReady(false) })
+}
+
+func TestHealthCheck(t *testing.T) {
+	t.Run("liveness does not check dependencies", func(t *testing.T) {
+		h, mock := newHealthHandler(t, errors.New("connection refused"))
+
+		w, body := performProbe(h.HealthCheck, "/health")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "healthy", body["status"])
+		// The expected ping is never made
+		assert.Error(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReadiness(t *testing.T) {
+	t.Run("not ready before migrations have run", func(t *testing.T) {
+		h, mock := newHealthHandler(t, nil)
+		setReadyForTest(t, false)
+
+		w, body := performProbe(h.Readiness, "/ready")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "not ready", body["status"])
+		checks := body["checks"].(map[string]interface{})
+		assert.Equal(t, "pending", checks["migrations"])
+		assert.Error(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ready once the flag is set", func(t *testing.T) {
+		h, mock := newHealthHandler(t, nil)
+		setReadyForTest(t, true)
+
+		w, body := performProbe(h.Readiness, "/ready")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ready", body["status"])
+		checks := body["checks"].(map[string]interface{})
+		assert.Equal(t, "ok", checks["migrations"])
+		assert.Equal(t, "ok", checks["database"])
+		assert.Equal(t, "disabled", checks["redis"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failing database ping returns 503", func(t *testing.T) {
+		h, mock := newHealthHandler(t, errors.New("connection refused"))
+		setReadyForTest(t, true)
+
+		w, body := performProbe(h.Readiness, "/ready")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "not ready", body["status"])
+		checks := body["checks"].(map[string]interface{})
+		// The error itself is only logged
+		assert.Equal(t, "unhealthy", checks["database"])
+		assert.NotContains(t, w.Body.String(), "connection refused")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
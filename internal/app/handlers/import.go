@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"user-service/internal/app/importer"
+	"user-service/internal/app/models"
+	"user-service/internal/app/service"
+)
+
+const (
+	maxImportFileSize = 1 << 20 // 1MB
+	maxImportRows     = 1000
+)
+
+// parsedContactsCSV holds the rows of an import file along with the CSV line
+// each row came from, so service errors can be reported against the file
+type parsedContactsCSV struct {
+	rows     []models.CreateContactRequest
+	lines    []int
+	errors   []service.ImportError
+	warnings []service.ImportError
+}
+
+// parseImportFile reads an import file in the format of source: csv, google or vcard
+func parseImportFile(r io.Reader, source string) (*parsedContactsCSV, error) {
+	var contacts *importer.Contacts
+	var err error
+	switch source {
+	case "csv":
+		return parseContactsCSV(r)
+	case "google":
+		contacts, err = importer.ParseGoogleCSV(r, maxImportRows)
+	case "vcard":
+		contacts, err = importer.ParseVCard(r, maxImportRows)
+	default:
+		return nil, fmt.Errorf("unknown import source %q", source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsedContactsCSV{
+		rows:     contacts.Rows,
+		lines:    contacts.Lines,
+		errors:   importIssues(contacts.Skipped),
+		warnings: importIssues(contacts.Warnings),
+	}, nil
+}
+
+func importIssues(issues []importer.Issue) []service.ImportError {
+	errs := make([]service.ImportError, len(issues))
+	for i, issue := range issues {
+		errs[i] = service.ImportError{Row: issue.Line, Message: issue.Message}
+	}
+	return errs
+}
+
+// parseContactsCSV reads a contacts CSV with a header row. Columns are matched by
+// name (full_name, phone, email, favorite); full_name and phone are required.
+func parseContactsCSV(r io.Reader) (*parsedContactsCSV, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("is empty")
+	}
+	if err != nil {
+		return nil, errors.New("is not a valid CSV file")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"full_name", "phone"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	parsed := &parsedContactsCSV{
+		rows:   []models.CreateContactRequest{},
+		errors: []service.ImportError{},
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(parsed.rows)+len(parsed.errors) >= maxImportRows {
+			return nil, fmt.Errorf("must contain at most %d rows", maxImportRows)
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			parsed.errors = append(parsed.errors, service.ImportError{Row: parseErr.StartLine, Message: "malformed CSV row"})
+			continue
+		}
+		if err != nil {
+			return nil, errors.New("is not a valid CSV file")
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := models.CreateContactRequest{
+			FullName: field(record, "full_name"),
+			Phone:    field(record, "phone"),
+		}
+		if email := field(record, "email"); email != "" {
+			row.Email = &email
+		}
+		if favorite := field(record, "favorite"); favorite != "" {
+			row.Favorite, err = strconv.ParseBool(favorite)
+			if err != nil {
+				parsed.errors = append(parsed.errors, service.ImportError{Row: line, Message: "favorite must be true or false"})
+				continue
+			}
+		}
+
+		parsed.rows = append(parsed.rows, row)
+		parsed.lines = append(parsed.lines, line)
+	}
+
+	return parsed, nil
+}
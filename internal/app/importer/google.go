@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// googleValueColumn matches the numbered value columns of a Google Contacts export,
+// e.g. "Phone 1 - Value" or "E-mail 2 - Value"
+var googleValueColumn = regexp.MustCompile(`^(phone|e-mail) (\d+) - value$`)
+
+// googleValueSeparator separates several values Google puts into one cell
+const googleValueSeparator = ":::"
+
+// ParseGoogleCSV reads a Google Contacts CSV export. The name is taken from the Name
+// column, or else built from the first, middle and last name columns; phone numbers and
+// emails from the "Phone N - Value" and "E-mail N - Value" columns. At most maxRows
+// contacts are read.
+func ParseGoogleCSV(r io.Reader, maxRows int) (*Contacts, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("is empty")
+	}
+	if err != nil {
+		return nil, errors.New("is not a valid CSV file")
+	}
+
+	columns := make(map[string]int, len(header))
+	var phoneColumns, emailColumns []googleColumn
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+		if match := googleValueColumn.FindStringSubmatch(name); match != nil {
+			n, _ := strconv.Atoi(match[2])
+			if match[1] == "phone" {
+				phoneColumns = append(phoneColumns, googleColumn{index: i, n: n})
+			} else {
+				emailColumns = append(emailColumns, googleColumn{index: i, n: n})
+			}
+		}
+	}
+	if len(phoneColumns) == 0 {
+		return nil, errors.New("is not a Google Contacts CSV file")
+	}
+	sortGoogleColumns(phoneColumns)
+	sortGoogleColumns(emailColumns)
+
+	field := func(record []string, names ...string) string {
+		for _, name := range names {
+			if i, ok := columns[name]; ok && i < len(record) {
+				if value := strings.TrimSpace(record[i]); value != "" {
+					return value
+				}
+			}
+		}
+		return ""
+	}
+
+	contacts := newContacts()
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if contacts.entries() >= maxRows {
+			return nil, fmt.Errorf("must contain at most %d rows", maxRows)
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			contacts.skip(parseErr.StartLine, "malformed CSV row")
+			continue
+		}
+		if err != nil {
+			return nil, errors.New("is not a valid CSV file")
+		}
+		line, _ := reader.FieldPos(0)
+
+		name := field(record, "name")
+		if name == "" {
+			name = joinName(
+				field(record, "first name", "given name"),
+				field(record, "middle name", "additional name"),
+				field(record, "last name", "family name"),
+			)
+		}
+		contacts.add(line, name, googleValues(record, phoneColumns), googleValues(record, emailColumns))
+	}
+
+	return contacts, nil
+}
+
+// googleColumn is the position of a numbered value column
+type googleColumn struct {
+	index int
+	n     int
+}
+
+func sortGoogleColumns(columns []googleColumn) {
+	sort.Slice(columns, func(i, j int) bool { return columns[i].n < columns[j].n })
+}
+
+// googleValues returns the non-empty values of columns, splitting cells holding several
+func googleValues(record []string, columns []googleColumn) []string {
+	var values []string
+	for _, column := range columns {
+		if column.index >= len(record) {
+			continue
+		}
+		for _, value := range strings.Split(record[column.index], googleValueSeparator) {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"user-service/internal/app/models"
+)
+
+// Contacts holds the contacts read from an import file along with the line each one
+// starts on, so problems can be reported against the file
+type Contacts struct {
+	Rows  []models.CreateContactRequest
+	Lines []int
+	// Skipped are entries that could not be mapped to a contact
+	Skipped []Issue
+	// Warnings are about data of imported entries that was left out
+	Warnings []Issue
+}
+
+// Issue describes a problem with the entry starting on Line
+type Issue struct {
+	Line    int
+	Message string
+}
+
+func newContacts() *Contacts {
+	return &Contacts{
+		Rows:     []models.CreateContactRequest{},
+		Skipped:  []Issue{},
+		Warnings: []Issue{},
+	}
+}
+
+// entries is the number of entries read so far
+func (c *Contacts) entries() int {
+	return len(c.Rows) + len(c.Skipped)
+}
+
+func (c *Contacts) skip(line int, message string) {
+	c.Skipped = append(c.Skipped, Issue{Line: line, Message: message})
+}
+
+// add maps one entry. Only the first phone number and email are imported; the rest
+// are reported as warnings.
+func (c *Contacts) add(line int, name string, phones, emails []string) {
+	switch {
+	case name == "":
+		c.skip(line, "missing name")
+		return
+	case len(phones) == 0:
+		c.skip(line, "missing phone number")
+		return
+	}
+
+	row := models.CreateContactRequest{FullName: name, Phone: phones[0]}
+	if len(emails) > 0 {
+		row.Email = &emails[0]
+	}
+	c.Rows = append(c.Rows, row)
+	c.Lines = append(c.Lines, line)
+
+	if extra := len(phones) - 1; extra > 0 {
+		c.Warnings = append(c.Warnings, Issue{Line: line, Message: fmt.Sprintf("only the first phone number was imported, %d more ignored", extra)})
+	}
+	if extra := len(emails) - 1; extra > 0 {
+		c.Warnings = append(c.Warnings, Issue{Line: line, Message: fmt.Sprintf("only the first email was imported, %d more ignored", extra)})
+	}
+}
+
+// joinName joins the non-empty name parts with spaces
+func joinName(parts ...string) string {
+	var name []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			name = append(name, part)
+		}
+	}
+	return strings.Join(name, " ")
+}
@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"user-service/internal/app/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseGoogleCSV(t *testing.T) {
+	contacts, err := ParseGoogleCSV(openFixture(t, "google.csv"), 100)
+
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	assert.Equal(t, []models.CreateContactRequest{
+		{FullName: "Jane Doe", Phone: "+62 812-3456-7890", Email: strPtr("jane@example.com")},
+		{FullName: "John Q. Smith", Phone: "081234567891"},
+	}, contacts.Rows)
+	assert.Equal(t, []int{2, 3}, contacts.Lines)
+	assert.Equal(t, []Issue{
+		{Line: 4, Message: "missing phone number"},
+		{Line: 5, Message: "missing name"},
+	}, contacts.Skipped)
+	assert.Equal(t, []Issue{
+		{Line: 2, Message: "only the first phone number was imported, 1 more ignored"},
+		{Line: 2, Message: "only the first email was imported, 1 more ignored"},
+		{Line: 3, Message: "only the first phone number was imported, 1 more ignored"},
+	}, contacts.Warnings)
+}
+
+func TestParseGoogleCSV_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "empty", input: "", wantErr: "is empty"},
+		{name: "not google", input: "full_name,phone\nJane,0812\n", wantErr: "is not a Google Contacts CSV file"},
+		{name: "too many rows", input: "Name,Phone 1 - Value\nA,1\nB,2\nC,3\n", wantErr: "must contain at most 2 rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGoogleCSV(strings.NewReader(tt.input), 2)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseVCard(t *testing.T) {
+	contacts, err := ParseVCard(openFixture(t, "contacts.vcf"), 100)
+
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	assert.Equal(t, []models.CreateContactRequest{
+		{FullName: "Jane Doe", Phone: "+62 812-3456-7890", Email: strPtr("jane@example.com")},
+		{FullName: "John Q. Smith, Jr.", Phone: "081234567891"},
+	}, contacts.Rows)
+	assert.Equal(t, []int{1, 9}, contacts.Lines)
+	assert.Equal(t, []Issue{
+		{Line: 15, Message: "missing phone number"},
+		{Line: 21, Message: "vCard is missing END:VCARD"},
+	}, contacts.Skipped)
+	assert.Equal(t, []Issue{
+		{Line: 1, Message: "only the first phone number was imported, 1 more ignored"},
+	}, contacts.Warnings)
+}
+
+func TestParseVCard_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "no vcards", input: "full_name,phone\n", wantErr: "contains no vCards"},
+		{name: "too many contacts", input: strings.Repeat("BEGIN:VCARD\nFN:A\nTEL:1\nEND:VCARD\n", 3), wantErr: "must contain at most 2 contacts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseVCard(strings.NewReader(tt.input), 2)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSplitVCardProperty(t *testing.T) {
+	name, value := splitVCardProperty("item1.tel;TYPE=CELL:+62 812:3")
+	assert.Equal(t, "TEL", name)
+	assert.Equal(t, "+62 812:3", value)
+}
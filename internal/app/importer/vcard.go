@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseVCard reads vCard 2.1, 3.0 and 4.0 entries. The name is taken from FN, or else
+// from N; phone numbers from TEL and emails from EMAIL properties, in file order. Other
+// properties are ignored. At most maxRows contacts are read.
+func ParseVCard(r io.Reader, maxRows int) (*Contacts, error) {
+	lines, err := unfoldVCard(r)
+	if err != nil {
+		return nil, errors.New("is not a valid vCard file")
+	}
+
+	contacts := newContacts()
+	var card *vCard
+	for _, l := range lines {
+		name, value := splitVCardProperty(l.text)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			if card != nil {
+				contacts.skip(card.line, "vCard is missing END:VCARD")
+			}
+			if contacts.entries() >= maxRows {
+				return nil, fmt.Errorf("must contain at most %d contacts", maxRows)
+			}
+			card = &vCard{line: l.number}
+		case card == nil:
+			// Text outside of a vCard is ignored
+		case name == "END" && strings.EqualFold(value, "VCARD"):
+			contacts.add(card.line, card.fullName(), card.phones, card.emails)
+			card = nil
+		case name == "FN":
+			card.fn = unescapeVCard(value)
+		case name == "N":
+			card.n = value
+		case name == "TEL":
+			if phone := strings.TrimSpace(strings.TrimPrefix(value, "tel:")); phone != "" {
+				card.phones = append(card.phones, phone)
+			}
+		case name == "EMAIL":
+			if email := strings.TrimSpace(unescapeVCard(value)); email != "" {
+				card.emails = append(card.emails, email)
+			}
+		}
+	}
+	if card != nil {
+		contacts.skip(card.line, "vCard is missing END:VCARD")
+	}
+	if contacts.entries() == 0 {
+		return nil, errors.New("contains no vCards")
+	}
+
+	return contacts, nil
+}
+
+// vCard collects the properties of one entry
+type vCard struct {
+	line   int
+	fn     string
+	n      string
+	phones []string
+	emails []string
+}
+
+// fullName returns FN, or the given, additional and family names of N
+func (c *vCard) fullName() string {
+	if name := strings.TrimSpace(c.fn); name != "" {
+		return name
+	}
+	// N is family;given;additional;prefixes;suffixes
+	parts := splitVCardValue(c.n)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	return joinName(parts[1], parts[2], parts[0])
+}
+
+// vCardLine is a logical (unfolded) vCard line and the line it starts on
+type vCardLine struct {
+	text   string
+	number int
+}
+
+// unfoldVCard joins folded lines: a line starting with a space or tab continues the previous one
+func unfoldVCard(r io.Reader) ([]vCardLine, error) {
+	var lines []vCardLine
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if strings.TrimSpace(text) != "" {
+			lines = append(lines, vCardLine{text: text, number: number})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitVCardProperty returns the upper-cased property name of a line, without its group
+// and parameters, and its raw value. "item1.TEL;TYPE=CELL:+62 812" yields TEL and "+62 812".
+func splitVCardProperty(line string) (string, string) {
+	name, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", ""
+	}
+	name, _, _ = strings.Cut(name, ";")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToUpper(strings.TrimSpace(name)), value
+}
+
+// splitVCardValue splits a structured value on unescaped semicolons and unescapes the parts
+func splitVCardValue(value string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			part.WriteByte(value[i])
+			part.WriteByte(value[i+1])
+			i++
+		case value[i] == ';':
+			parts = append(parts, unescapeVCard(part.String()))
+			part.Reset()
+		default:
+			part.WriteByte(value[i])
+		}
+	}
+	return append(parts, unescapeVCard(part.String()))
+}
+
+// vCardUnescaper reverses the escaping of vCard text values
+var vCardUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\,`, ",",
+	`\;`, ";",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescapeVCard(value string) string {
+	return vCardUnescaper.Replace(value)
+}
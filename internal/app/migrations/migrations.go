@@ -2,17 +2,31 @@ package migrations
 
 import (
 	"database/sql"
+	"fmt"
 )
 
 // Migration represents a database migration
 type Migration struct {
-	ID   string
-	Up   func(*sql.Tx) error
+	ID string
+	Up func(*sql.Tx) error
+	// Down reverses Up. It is nil for irreversible migrations, which rolling back
+	// only marks as unapplied.
 	Down func(*sql.Tx) error
 }
 
-// GetMigrations returns all available migrations
+// GetMigrations returns all available migrations ordered by ID: the ones defined in Go
+// below together with the SQL migrations embedded from the sql directory
 func GetMigrations() []Migration {
+	sqlMigrations, err := loadSQLMigrations(sqlFiles, sqlDir)
+	if err != nil {
+		// The files are embedded at build time, so this can only be a packaging mistake
+		panic(err)
+	}
+	return sortMigrations(append(goMigrations(), sqlMigrations...))
+}
+
+// goMigrations returns the migrations that need Go code, e.g. to inspect the schema
+func goMigrations() []Migration {
 	return []Migration{
 		{
 			ID: "001_create_users_table",
@@ -81,108 +95,304 @@ func GetMigrations() []Migration {
 		},
 		{
 			ID: "003_fix_schema_migrations_table",
+			Up: fixSchemaMigrationsTable,
+			// Irreversible: restoring the old id-based layout would only break the runner
+			Down: nil,
+		},
+		{
+			ID: "004_create_refresh_tokens_table",
 			Up: func(tx *sql.Tx) error {
-				// Check if the table exists and what columns it has
-				var tableExists bool
-				err := tx.QueryRow("SHOW TABLES LIKE 'schema_migrations'").Scan(&tableExists)
-				if err != nil && err.Error() != "sql: no rows in result set" {
-					// Table doesn't exist, just create it
-					_, err = tx.Exec(`
-						CREATE TABLE IF NOT EXISTS schema_migrations (
-							version VARCHAR(255) PRIMARY KEY,
-							name VARCHAR(255) NOT NULL,
-							applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-						) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
-					`)
-					return err
-				}
+				_, err := tx.Exec(`
+					CREATE TABLE IF NOT EXISTS refresh_tokens (
+						id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+						user_id INT UNSIGNED NOT NULL,
+						jti VARCHAR(64) NOT NULL,
+						expires_at TIMESTAMP NOT NULL,
+						revoked_at TIMESTAMP NULL,
+						created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+						-- Foreign key constraint
+						CONSTRAINT fk_refresh_tokens_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 
-				// Table exists, check its structure
-				rows, err := tx.Query("DESCRIBE schema_migrations")
+						-- Indexes
+						UNIQUE INDEX idx_refresh_tokens_jti (jti),
+						INDEX idx_refresh_tokens_user_id (user_id)
+					) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+				`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`DROP TABLE IF EXISTS refresh_tokens`)
+				return err
+			},
+		},
+		{
+			ID: "005_add_email_verified_to_users",
+			Up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE AFTER avatar_url`)
 				if err != nil {
 					return err
 				}
-				defer rows.Close()
-
-				var columns []string
-				for rows.Next() {
-					var field, typ, null, key, def, extra string
-					if err := rows.Scan(&field, &typ, &null, &key, &def, &extra); err != nil {
-						return err
-					}
-					columns = append(columns, field)
-				}
-
-				// Check if it has the old structure (id column)
-				hasIdColumn := false
-				hasNameColumn := false
-				hasVersionColumn := false
-				for _, col := range columns {
-					switch col {
-					case "id":
-						hasIdColumn = true
-					case "name":
-						hasNameColumn = true
-					case "version":
-						hasVersionColumn = true
-					}
-				}
+				// Accounts created before verification existed are treated as verified
+				_, err = tx.Exec(`UPDATE users SET email_verified = TRUE`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`ALTER TABLE users DROP COLUMN email_verified`)
+				return err
+			},
+		},
+		{
+			ID: "006_add_deleted_at_to_contacts",
+			Up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					ALTER TABLE contacts
+						ADD COLUMN deleted_at TIMESTAMP NULL AFTER updated_at,
+						ADD INDEX idx_contacts_deleted_at (deleted_at)
+				`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					ALTER TABLE contacts
+						DROP INDEX idx_contacts_deleted_at,
+						DROP COLUMN deleted_at
+				`)
+				return err
+			},
+		},
+		{
+			ID: "007_create_contact_tags_table",
+			Up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					CREATE TABLE IF NOT EXISTS contact_tags (
+						id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+						contact_id INT UNSIGNED NOT NULL,
+						tag VARCHAR(30) NOT NULL,
+						created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 
-				if hasVersionColumn && hasNameColumn {
-					// Table already has correct structure, nothing to do
-					return nil
-				}
+						-- Foreign key constraint
+						CONSTRAINT fk_contact_tags_contact_id FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE,
 
-				// Rename existing table
-				_, err = tx.Exec("ALTER TABLE schema_migrations RENAME TO schema_migrations_old")
-				if err != nil {
-					return err
-				}
+						-- Indexes
+						UNIQUE INDEX idx_contact_tags_contact_tag (contact_id, tag),
+						INDEX idx_contact_tags_tag (tag)
+					) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+				`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`DROP TABLE IF EXISTS contact_tags`)
+				return err
+			},
+		},
+		{
+			ID: "008_add_deactivated_at_to_users",
+			Up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					ALTER TABLE users
+						ADD COLUMN deactivated_at TIMESTAMP NULL AFTER email_verified
+				`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`ALTER TABLE users DROP COLUMN deactivated_at`)
+				return err
+			},
+		},
+		{
+			ID: "009_create_audit_logs_table",
+			Up: func(tx *sql.Tx) error {
+				// No foreign key on user_id: entries must outlive deleted accounts
+				_, err := tx.Exec(`
+					CREATE TABLE IF NOT EXISTS audit_logs (
+						id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+						user_id INT UNSIGNED NOT NULL,
+						action VARCHAR(50) NOT NULL,
+						target VARCHAR(255) NULL,
+						ip_address VARCHAR(45) NULL,
+						created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 
-				// Create new table with correct structure
-				_, err = tx.Exec(`
-					CREATE TABLE schema_migrations (
-						version VARCHAR(255) PRIMARY KEY,
-						name VARCHAR(255) NOT NULL,
-						applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+						-- Indexes
+						INDEX idx_audit_logs_user_created (user_id, created_at)
 					) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
 				`)
-				if err != nil {
-					return err
-				}
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`DROP TABLE IF EXISTS audit_logs`)
+				return err
+			},
+		},
+		{
+			ID: "010_add_unique_phone_to_contacts",
+			Up: func(tx *sql.Tx) error {
+				// Only active contacts must have unique phones. active_phone is NULL for
+				// soft-deleted rows, and NULLs never collide in a unique index.
+				_, err := tx.Exec(`
+					ALTER TABLE contacts
+						ADD COLUMN active_phone VARCHAR(20)
+							GENERATED ALWAYS AS (IF(deleted_at IS NULL, phone, NULL)) VIRTUAL,
+						ADD UNIQUE INDEX idx_contacts_user_active_phone (user_id, active_phone)
+				`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					ALTER TABLE contacts
+						DROP INDEX idx_contacts_user_active_phone,
+						DROP COLUMN active_phone
+				`)
+				return err
+			},
+		},
+		{
+			ID: "011_create_sessions_table",
+			Up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`
+					CREATE TABLE IF NOT EXISTS sessions (
+						id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+						user_id INT UNSIGNED NOT NULL,
+						jti VARCHAR(64) NOT NULL,
+						user_agent VARCHAR(255) NULL,
+						ip_address VARCHAR(45) NULL,
+						expires_at TIMESTAMP NOT NULL,
+						last_used_at TIMESTAMP NOT NULL,
+						revoked_at TIMESTAMP NULL,
+						created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 
-				// Migrate data based on old table structure
-				if hasIdColumn && hasNameColumn {
-					// Old table has id and name columns
-					_, err = tx.Exec(`
-						INSERT INTO schema_migrations (version, name, applied_at)
-						SELECT id, name, applied_at FROM schema_migrations_old
-					`)
-				} else if hasIdColumn {
-					// Old table has only id column, use id as name too
-					_, err = tx.Exec(`
-						INSERT INTO schema_migrations (version, name, applied_at)
-						SELECT id, id, applied_at FROM schema_migrations_old
-					`)
-				}
-				// If neither, table was empty anyway
+						-- Foreign key constraint
+						CONSTRAINT fk_sessions_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 
-				if err != nil {
+						-- Indexes
+						UNIQUE INDEX idx_sessions_jti (jti),
+						INDEX idx_sessions_user_id (user_id)
+					) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+				`)
+				return err
+			},
+			Down: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`DROP TABLE IF EXISTS sessions`)
+				return err
+			},
+		},
+		{
+			ID: "012_add_deleted_at_to_users",
+			Up: func(tx *sql.Tx) error {
+				if _, err := tx.Exec(`
+					ALTER TABLE users
+						ADD COLUMN deleted_at TIMESTAMP NULL AFTER updated_at,
+						ADD INDEX idx_users_deleted_at (deleted_at)
+				`); err != nil {
 					return err
 				}
 
-				// Drop the old table
-				_, err = tx.Exec("DROP TABLE schema_migrations_old")
+				// Emails stay unique among non-deleted users only, so a deleted user's email
+				// can register again. As for contact phones, active_email is NULL for
+				// soft-deleted rows and NULLs never collide in a unique index.
+				_, err := tx.Exec(`
+					ALTER TABLE users
+						DROP INDEX email,
+						ADD COLUMN active_email VARCHAR(255)
+							GENERATED ALWAYS AS (IF(deleted_at IS NULL, email, NULL)) VIRTUAL,
+						ADD UNIQUE INDEX idx_users_active_email (active_email)
+				`)
 				return err
 			},
 			Down: func(tx *sql.Tx) error {
-				// This migration is not reversible as it's a fix
-				return nil
+				_, err := tx.Exec(`
+					ALTER TABLE users
+						DROP INDEX idx_users_active_email,
+						DROP COLUMN active_email,
+						DROP INDEX idx_users_deleted_at,
+						DROP COLUMN deleted_at,
+						ADD UNIQUE INDEX email (email)
+				`)
+				return err
 			},
 		},
 	}
 }
 
+// fixSchemaMigrationsTable converts a schema_migrations table with the old id column to
+// the version/name layout. MySQL DDL is not transactional, so every step checks what an
+// interrupted earlier run already did and the migration can safely be re-run.
+func fixSchemaMigrationsTable(tx *sql.Tx) error {
+	columns, err := tableColumns(tx, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	oldColumns, err := tableColumns(tx, "schema_migrations_old")
+	if err != nil {
+		return err
+	}
+
+	// Move a table with the old layout aside
+	if len(columns) > 0 && !(columns["version"] && columns["name"]) {
+		if len(oldColumns) > 0 {
+			return fmt.Errorf("schema_migrations and schema_migrations_old both use the old layout; resolve manually")
+		}
+		if _, err := tx.Exec("ALTER TABLE schema_migrations RENAME TO schema_migrations_old"); err != nil {
+			return err
+		}
+		oldColumns = columns
+	}
+
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`); err != nil {
+		return err
+	}
+
+	if len(oldColumns) == 0 {
+		return nil
+	}
+
+	// Copy the applied migrations; rows copied by an interrupted run are skipped
+	if oldColumns["id"] {
+		name := "id"
+		if oldColumns["name"] {
+			name = "name"
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`
+			INSERT IGNORE INTO schema_migrations (version, name, applied_at)
+			SELECT id, %s, applied_at FROM schema_migrations_old
+		`, name)); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("DROP TABLE schema_migrations_old")
+	return err
+}
+
+// tableColumns returns the column names of a table in the current database, or none
+// when the table does not exist
+func tableColumns(tx *sql.Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query(`
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ?
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns[column] = true
+	}
+	return columns, rows.Err()
+}
+
 // CreateMigrationsTable creates the migrations tracking table
 func CreateMigrationsTable(db *sql.DB) error {
 	_, err := db.Exec(`
@@ -195,6 +405,19 @@ func CreateMigrationsTable(db *sql.DB) error {
 	return err
 }
 
+// migrationsTableExists reports whether the schema_migrations table has been created
+func migrationsTableExists(db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'
+	`).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // IsMigrationApplied checks if a migration has been applied
 func IsMigrationApplied(db *sql.DB, migrationID string) (bool, error) {
 	var count int
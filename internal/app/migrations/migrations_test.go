@@ -0,0 +1,291 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMigrationApplied(t *testing.T) {
+	t.Run("version schema", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM schema_migrations WHERE version = \\?").
+			WithArgs("001_create_users_table").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		applied, err := IsMigrationApplied(db, "001_create_users_table")
+
+		assert.NoError(t, err)
+		assert.True(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("id schema", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM schema_migrations WHERE version = \\?").
+			WithArgs("002_create_contacts_table").
+			WillReturnError(errors.New("Error 1054: Unknown column 'version' in 'where clause'"))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM schema_migrations WHERE id = \\?").
+			WithArgs("002_create_contacts_table").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		applied, err := IsMigrationApplied(db, "002_create_contacts_table")
+
+		assert.NoError(t, err)
+		assert.False(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFixSchemaMigrationsTable(t *testing.T) {
+	columnsQuery := "SELECT column_name FROM information_schema.columns"
+
+	t.Run("already migrated", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(columnsQuery).WithArgs("schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("version").AddRow("name").AddRow("applied_at"))
+		mock.ExpectQuery(columnsQuery).WithArgs("schema_migrations_old").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+		assert.NoError(t, fixSchemaMigrationsTable(tx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("resumes interrupted copy", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(columnsQuery).WithArgs("schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("version").AddRow("name").AddRow("applied_at"))
+		mock.ExpectQuery(columnsQuery).WithArgs("schema_migrations_old").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("id").AddRow("applied_at"))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT IGNORE INTO schema_migrations \\(version, name, applied_at\\)\\s+SELECT id, id, applied_at FROM schema_migrations_old").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("DROP TABLE schema_migrations_old").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+		assert.NoError(t, fixSchemaMigrationsTable(tx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetMigrations_Ordered(t *testing.T) {
+	migrations := GetMigrations()
+
+	seen := make(map[string]bool)
+	for i, migration := range migrations {
+		assert.False(t, seen[migration.ID], "duplicate migration %s", migration.ID)
+		seen[migration.ID] = true
+		assert.NotNil(t, migration.Up, migration.ID)
+		if i > 0 {
+			assert.Greater(t, migrationNumber(migration.ID), migrationNumber(migrations[i-1].ID))
+		}
+	}
+}
+
+func TestCreateMigration_DiscoveredByRunner(t *testing.T) {
+	dir := t.TempDir()
+	existing := goMigrations()
+
+	id, err := CreateMigration(dir, "add_nickname_to_users", existing)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%03d_add_nickname_to_users", len(existing)+1), id)
+
+	upFile := filepath.Join(dir, id+".up.sql")
+	stub, err := os.ReadFile(upFile)
+	assert.NoError(t, err)
+	script := string(stub) + "ALTER TABLE users ADD COLUMN nickname VARCHAR(100) NULL;\n"
+	assert.NoError(t, os.WriteFile(upFile, []byte(script), 0o644))
+
+	// A second migration gets the next number
+	nextID, err := CreateMigration(dir, "add_bio_to_users", existing)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%03d_add_bio_to_users", len(existing)+2), nextID)
+
+	loaded, err := loadSQLMigrations(os.DirFS(dir), ".")
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, id, loaded[0].ID)
+	assert.Equal(t, nextID, loaded[1].ID)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM schema_migrations WHERE version = \\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE users ADD COLUMN nickname VARCHAR\\(100\\) NULL").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations \\(version, name, applied_at\\)").
+		WithArgs(id, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	runner := &Runner{db: db, migrations: loaded[:1]}
+	assert.NoError(t, runner.MigrateUp())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateMigration_InvalidName(t *testing.T) {
+	_, err := CreateMigration(t.TempDir(), "Add Foo", nil)
+	assert.Error(t, err)
+}
+
+// testMigrations returns three migrations creating one table each
+func testMigrations() []Migration {
+	var migrations []Migration
+	for _, id := range []string{"001_create_a", "002_create_b", "003_create_c"} {
+		table := id[len(id)-1:]
+		migrations = append(migrations, Migration{
+			ID:   id,
+			Up:   sqlScript("CREATE TABLE " + table + " (id INT)"),
+			Down: sqlScript("DROP TABLE " + table),
+		})
+	}
+	return migrations
+}
+
+// expectApplied expects the status checks of the given migrations, in order
+func expectApplied(mock sqlmock.Sqlmock, statuses map[string]int, ids ...string) {
+	for _, id := range ids {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM schema_migrations WHERE version = \\?").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(statuses[id]))
+	}
+}
+
+func TestRunner_MigrateTo(t *testing.T) {
+	t.Run("forward", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		statuses := map[string]int{"001_create_a": 1}
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectApplied(mock, statuses, "003_create_c", "001_create_a", "002_create_b")
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_migrations").WithArgs("002_create_b", "002_create_b").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		runner := &Runner{db: db, migrations: testMigrations()}
+		assert.NoError(t, runner.MigrateTo("002"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("backward", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		statuses := map[string]int{"001_create_a": 1, "002_create_b": 1, "003_create_c": 1}
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectApplied(mock, statuses, "003_create_c")
+		mock.ExpectBegin()
+		mock.ExpectExec("DROP TABLE c").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations").WithArgs("003_create_c").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectApplied(mock, statuses, "002_create_b")
+		mock.ExpectBegin()
+		mock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations").WithArgs("002_create_b").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectApplied(mock, statuses, "001_create_a")
+
+		runner := &Runner{db: db, migrations: testMigrations()}
+		assert.NoError(t, runner.MigrateTo("001_create_a"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		runner := &Runner{db: db, migrations: testMigrations()}
+		err = runner.MigrateTo("042")
+
+		assert.EqualError(t, err, `unknown migration version "042"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRunner_Plan(t *testing.T) {
+	expectTable := func(mock sqlmock.Sqlmock, count int) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+
+	t.Run("excludes applied migrations", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		statuses := map[string]int{"001_create_a": 1}
+		expectTable(mock, 1)
+		expectApplied(mock, statuses, "001_create_a", "002_create_b", "003_create_c")
+
+		runner := &Runner{db: db, migrations: testMigrations()}
+		plan, err := runner.Plan()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"002_create_b", "003_create_c"}, plan)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fresh database", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		// Nothing is created or executed
+		expectTable(mock, 0)
+
+		runner := &Runner{db: db, migrations: testMigrations()}
+		plan, err := runner.Plan()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"001_create_a", "002_create_b", "003_create_c"}, plan)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRunner_Pending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	statuses := map[string]int{"001_create_a": 1, "003_create_c": 1}
+	expectApplied(mock, statuses, "001_create_a", "002_create_b", "003_create_c")
+
+	runner := &Runner{db: db, migrations: testMigrations()}
+	pending, err := runner.Pending()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"002_create_b"}, pending)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
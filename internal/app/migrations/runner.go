@@ -4,16 +4,18 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 )
 
 // Runner handles running database migrations
 type Runner struct {
-	db *sql.DB
+	db         *sql.DB
+	migrations []Migration
 }
 
 // NewRunner creates a new migration runner
 func NewRunner(db *sql.DB) *Runner {
-	return &Runner{db: db}
+	return &Runner{db: db, migrations: GetMigrations()}
 }
 
 // MigrateUp runs all pending migrations
@@ -25,7 +27,7 @@ func (r *Runner) MigrateUp() error {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 
-	migrations := GetMigrations()
+	migrations := r.migrations
 
 	for _, migration := range migrations {
 		applied, err := IsMigrationApplied(r.db, migration.ID)
@@ -38,32 +40,9 @@ func (r *Runner) MigrateUp() error {
 			continue
 		}
 
-		log.Printf("Applying migration: %s", migration.ID)
-
-		// Start transaction
-		tx, err := r.db.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to start transaction for migration %s: %w", migration.ID, err)
+		if err := r.apply(migration); err != nil {
+			return err
 		}
-
-		// Run migration
-		if err := migration.Up(tx); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to run migration %s: %w", migration.ID, err)
-		}
-
-		// Mark as applied
-		if err := MarkMigrationApplied(tx, migration.ID); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to mark migration %s as applied: %w", migration.ID, err)
-		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", migration.ID, err)
-		}
-
-		log.Printf("Successfully applied migration: %s", migration.ID)
 	}
 
 	log.Println("Database migrations completed successfully")
@@ -74,7 +53,7 @@ func (r *Runner) MigrateUp() error {
 func (r *Runner) MigrateDown() error {
 	log.Println("Rolling back last migration...")
 
-	migrations := GetMigrations()
+	migrations := r.migrations
 	if len(migrations) == 0 {
 		log.Println("No migrations to roll back")
 		return nil
@@ -98,40 +77,162 @@ func (r *Runner) MigrateDown() error {
 		return nil
 	}
 
-	log.Printf("Rolling back migration: %s", lastMigration.ID)
+	return r.rollback(*lastMigration)
+}
+
+// MigrateTo applies or rolls back migrations until exactly the migrations up to and
+// including version are applied. version is a full migration ID or its number, e.g. 002.
+func (r *Runner) MigrateTo(version string) error {
+	target := -1
+	for i, migration := range r.migrations {
+		number, _, _ := strings.Cut(migration.ID, "_")
+		if migration.ID == version || number == version {
+			target = i
+			break
+		}
+	}
+	if target == -1 {
+		return fmt.Errorf("unknown migration version %q", version)
+	}
+
+	log.Printf("Migrating to version %s...", r.migrations[target].ID)
+
+	if err := CreateMigrationsTable(r.db); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	// Roll back newer migrations first, latest first
+	for i := len(r.migrations) - 1; i > target; i-- {
+		applied, err := IsMigrationApplied(r.db, r.migrations[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to check migration status for %s: %w", r.migrations[i].ID, err)
+		}
+		if applied {
+			if err := r.rollback(r.migrations[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Then apply whatever is pending up to the target
+	for _, migration := range r.migrations[:target+1] {
+		applied, err := IsMigrationApplied(r.db, migration.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check migration status for %s: %w", migration.ID, err)
+		}
+		if !applied {
+			if err := r.apply(migration); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("Database is at version %s", r.migrations[target].ID)
+	return nil
+}
+
+// apply runs a migration and marks it as applied in one transaction
+func (r *Runner) apply(migration Migration) error {
+	log.Printf("Applying migration: %s", migration.ID)
+
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction for migration %s: %w", migration.ID, err)
+	}
+
+	// Run migration
+	if err := migration.Up(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to run migration %s: %w", migration.ID, err)
+	}
+
+	// Mark as applied
+	if err := MarkMigrationApplied(tx, migration.ID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to mark migration %s as applied: %w", migration.ID, err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", migration.ID, err)
+	}
+
+	log.Printf("Successfully applied migration: %s", migration.ID)
+	return nil
+}
+
+// rollback reverts a migration and marks it as unapplied in one transaction
+func (r *Runner) rollback(migration Migration) error {
+	log.Printf("Rolling back migration: %s", migration.ID)
 
 	// Start transaction
 	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to start transaction for rollback %s: %w", lastMigration.ID, err)
+		return fmt.Errorf("failed to start transaction for rollback %s: %w", migration.ID, err)
 	}
 
-	// Run rollback
-	if err := lastMigration.Down(tx); err != nil {
+	// Run rollback; irreversible migrations are only marked as unapplied
+	if migration.Down == nil {
+		log.Printf("Migration %s is irreversible, leaving the schema unchanged", migration.ID)
+	} else if err := migration.Down(tx); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to rollback migration %s: %w", lastMigration.ID, err)
+		return fmt.Errorf("failed to rollback migration %s: %w", migration.ID, err)
 	}
 
 	// Mark as unapplied
-	if err := MarkMigrationUnapplied(tx, lastMigration.ID); err != nil {
+	if err := MarkMigrationUnapplied(tx, migration.ID); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to mark migration %s as unapplied: %w", lastMigration.ID, err)
+		return fmt.Errorf("failed to mark migration %s as unapplied: %w", migration.ID, err)
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit rollback %s: %w", lastMigration.ID, err)
+		return fmt.Errorf("failed to commit rollback %s: %w", migration.ID, err)
 	}
 
-	log.Printf("Successfully rolled back migration: %s", lastMigration.ID)
+	log.Printf("Successfully rolled back migration: %s", migration.ID)
 	return nil
 }
 
+// Pending returns the IDs of the migrations that have not been applied yet, in order
+func (r *Runner) Pending() ([]string, error) {
+	var pending []string
+	for _, migration := range r.migrations {
+		applied, err := IsMigrationApplied(r.db, migration.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check migration status for %s: %w", migration.ID, err)
+		}
+		if !applied {
+			pending = append(pending, migration.ID)
+		}
+	}
+	return pending, nil
+}
+
+// Plan returns the IDs of the migrations MigrateUp would apply, in the order it applies
+// them, without changing the database. On a database that was never migrated, where
+// schema_migrations does not exist yet, every migration is planned.
+func (r *Runner) Plan() ([]string, error) {
+	exists, err := migrationsTableExists(r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check migrations table: %w", err)
+	}
+	if !exists {
+		plan := make([]string, len(r.migrations))
+		for i, migration := range r.migrations {
+			plan[i] = migration.ID
+		}
+		return plan, nil
+	}
+	return r.Pending()
+}
+
 // Status shows the current migration status
 func (r *Runner) Status() error {
 	log.Println("Migration Status:")
 
-	migrations := GetMigrations()
+	migrations := r.migrations
 
 	for _, migration := range migrations {
 		applied, err := IsMigrationApplied(r.db, migration.ID)
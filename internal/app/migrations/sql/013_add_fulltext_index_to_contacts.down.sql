@@ -0,0 +1 @@
+ALTER TABLE contacts DROP INDEX idx_contacts_fulltext;
@@ -0,0 +1,2 @@
+-- Backs the full_text search mode of the contact listing
+ALTER TABLE contacts ADD FULLTEXT INDEX idx_contacts_fulltext (full_name, email);
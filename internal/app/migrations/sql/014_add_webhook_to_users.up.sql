@@ -0,0 +1,4 @@
+-- Per-user webhook receiving contact events
+ALTER TABLE users
+	ADD COLUMN webhook_url VARCHAR(2048) NULL AFTER deactivated_at,
+	ADD COLUMN webhook_secret VARCHAR(64) NULL AFTER webhook_url;
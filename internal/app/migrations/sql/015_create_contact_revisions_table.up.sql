@@ -0,0 +1,11 @@
+-- Snapshots of contacts taken on each update and delete
+CREATE TABLE IF NOT EXISTS contact_revisions (
+	id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+	contact_id INT UNSIGNED NOT NULL,
+	user_id INT UNSIGNED NOT NULL,
+	action VARCHAR(20) NOT NULL,
+	snapshot JSON NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_contact_revisions_user_contact (user_id, contact_id, id),
+	CONSTRAINT fk_contact_revisions_contact FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
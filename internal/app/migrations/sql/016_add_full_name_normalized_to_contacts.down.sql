@@ -0,0 +1,3 @@
+ALTER TABLE contacts
+	DROP INDEX idx_contacts_user_full_name_normalized,
+	DROP COLUMN full_name_normalized;
@@ -0,0 +1,7 @@
+-- Lowercased copy of full_name for name search. MySQL cannot strip diacritics itself,
+-- so accents are matched through the accent-insensitive collation while the
+-- application folds them out of the search term.
+ALTER TABLE contacts
+	ADD COLUMN full_name_normalized VARCHAR(255) COLLATE utf8mb4_unicode_ci
+		GENERATED ALWAYS AS (LOWER(full_name)) STORED AFTER full_name,
+	ADD INDEX idx_contacts_user_full_name_normalized (user_id, full_name_normalized);
@@ -0,0 +1 @@
+ALTER TABLE contacts DROP COLUMN birthday;
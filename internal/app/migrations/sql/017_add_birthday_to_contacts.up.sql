@@ -0,0 +1,2 @@
+-- Optional birthday of a contact; only month and day matter for upcoming birthdays
+ALTER TABLE contacts ADD COLUMN birthday DATE NULL AFTER favorite;
@@ -0,0 +1,2 @@
+-- Free-text note of a contact
+ALTER TABLE contacts ADD COLUMN notes TEXT NULL AFTER birthday;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS contact_emails;
+DROP TABLE IF EXISTS contact_phones;
@@ -0,0 +1,31 @@
+-- Every phone number and email address of a contact. The primary row mirrors
+-- contacts.phone and contacts.email, which are kept for backward compatibility.
+CREATE TABLE IF NOT EXISTS contact_phones (
+	id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+	contact_id INT UNSIGNED NOT NULL,
+	phone VARCHAR(20) NOT NULL,
+	label VARCHAR(30) NOT NULL DEFAULT '',
+	is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE INDEX idx_contact_phones_contact_phone (contact_id, phone),
+	INDEX idx_contact_phones_phone (phone),
+	CONSTRAINT fk_contact_phones_contact FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS contact_emails (
+	id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+	contact_id INT UNSIGNED NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	label VARCHAR(30) NOT NULL DEFAULT '',
+	is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE INDEX idx_contact_emails_contact_email (contact_id, email),
+	CONSTRAINT fk_contact_emails_contact FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Existing primary numbers and addresses become the primary rows
+INSERT INTO contact_phones (contact_id, phone, is_primary)
+SELECT id, phone, TRUE FROM contacts;
+
+INSERT INTO contact_emails (contact_id, email, is_primary)
+SELECT id, email, TRUE FROM contacts WHERE email IS NOT NULL AND email <> '';
@@ -0,0 +1,3 @@
+-- Authorization role of a user; promote the first admin with
+-- UPDATE users SET role = 'admin' WHERE email = '...'
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' AFTER email_verified;
@@ -0,0 +1,2 @@
+-- When a user last made an authenticated request, updated at most once a minute
+ALTER TABLE users ADD COLUMN last_seen_at TIMESTAMP NULL DEFAULT NULL AFTER deactivated_at;
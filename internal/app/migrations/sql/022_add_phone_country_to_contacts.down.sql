@@ -0,0 +1,3 @@
+ALTER TABLE contacts
+	DROP INDEX idx_contacts_user_phone_country,
+	DROP COLUMN phone_country;
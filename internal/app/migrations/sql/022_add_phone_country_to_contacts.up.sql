@@ -0,0 +1,4 @@
+-- ISO 3166-1 alpha-2 country detected from the contact's primary phone, NULL when unknown
+ALTER TABLE contacts
+	ADD COLUMN phone_country CHAR(2) NULL AFTER phone,
+	ADD INDEX idx_contacts_user_phone_country (user_id, phone_country);
@@ -0,0 +1,5 @@
+-- Fails while active contacts still share a phone number
+ALTER TABLE contacts
+	MODIFY COLUMN active_phone VARCHAR(20)
+		GENERATED ALWAYS AS (IF(deleted_at IS NULL, phone, NULL)) VIRTUAL,
+	DROP COLUMN phone_shared;
@@ -0,0 +1,6 @@
+-- Contacts saved while duplicate phones are allowed may share their number with other
+-- contacts, so they are left out of the unique index on active phones
+ALTER TABLE contacts
+	ADD COLUMN phone_shared BOOLEAN NOT NULL DEFAULT FALSE AFTER phone_country,
+	MODIFY COLUMN active_phone VARCHAR(20)
+		GENERATED ALWAYS AS (IF(deleted_at IS NULL AND NOT phone_shared, phone, NULL)) VIRTUAL;
@@ -0,0 +1,19 @@
+-- Detect the country of contacts saved before phone_country existed, and clear it for
+-- numbers without a + country code, whose country cannot be told. Mirrors detectCountry
+-- and its countryCallingCodes in the service; two-digit codes are matched first.
+UPDATE contacts SET phone_country = CASE
+	WHEN phone LIKE '+44%' THEN 'GB'
+	WHEN phone LIKE '+60%' THEN 'MY'
+	WHEN phone LIKE '+61%' THEN 'AU'
+	WHEN phone LIKE '+62%' THEN 'ID'
+	WHEN phone LIKE '+63%' THEN 'PH'
+	WHEN phone LIKE '+65%' THEN 'SG'
+	WHEN phone LIKE '+66%' THEN 'TH'
+	WHEN phone LIKE '+81%' THEN 'JP'
+	WHEN phone LIKE '+82%' THEN 'KR'
+	WHEN phone LIKE '+84%' THEN 'VN'
+	WHEN phone LIKE '+86%' THEN 'CN'
+	WHEN phone LIKE '+91%' THEN 'IN'
+	WHEN phone LIKE '+1%' THEN 'US'
+	ELSE NULL
+END;
@@ -0,0 +1,169 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sqlDir is the directory holding the SQL migrations, relative to this package
+const sqlDir = "sql"
+
+// sqlFiles embeds the SQL migrations so the binaries don't depend on the working directory
+//
+//go:embed sql
+var sqlFiles embed.FS
+
+var (
+	// sqlFilePattern matches migration files such as 013_add_foo.up.sql
+	sqlFilePattern = regexp.MustCompile(`^(\d+_[a-z0-9_]+)\.(up|down)\.sql$`)
+	// migrationNamePattern restricts names to what sqlFilePattern accepts
+	migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// loadSQLMigrations reads the <id>.up.sql and optional <id>.down.sql files in dir.
+// A migration without a down file is irreversible. Other files are ignored.
+func loadSQLMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byID := make(map[string]*Migration)
+	for _, entry := range entries {
+		match := sqlFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		id := match[1]
+		if byID[id] == nil {
+			byID[id] = &Migration{ID: id}
+		}
+		if match[2] == "up" {
+			byID[id].Up = sqlScript(string(content))
+		} else {
+			byID[id].Down = sqlScript(string(content))
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byID))
+	for id, migration := range byID {
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %s has a down file but no up file", id)
+		}
+		migrations = append(migrations, *migration)
+	}
+	return sortMigrations(migrations), nil
+}
+
+// sqlScript returns a migration step running the semicolon-separated statements of a script
+func sqlScript(script string) func(*sql.Tx) error {
+	statements := splitStatements(script)
+	return func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// splitStatements splits a script on semicolons, dropping chunks that only hold comments
+func splitStatements(script string) []string {
+	var statements []string
+	for _, chunk := range strings.Split(script, ";") {
+		hasSQL := false
+		for _, line := range strings.Split(chunk, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "--") {
+				hasSQL = true
+				break
+			}
+		}
+		if hasSQL {
+			statements = append(statements, strings.TrimSpace(chunk))
+		}
+	}
+	return statements
+}
+
+// sortMigrations orders migrations by their numeric prefix, then by ID
+func sortMigrations(migrations []Migration) []Migration {
+	sort.SliceStable(migrations, func(i, j int) bool {
+		ni, nj := migrationNumber(migrations[i].ID), migrationNumber(migrations[j].ID)
+		if ni != nj {
+			return ni < nj
+		}
+		return migrations[i].ID < migrations[j].ID
+	})
+	return migrations
+}
+
+// migrationNumber returns the numeric prefix of a migration ID, or 0 when it has none
+func migrationNumber(id string) int {
+	prefix, _, _ := strings.Cut(id, "_")
+	n, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// CreateMigration writes up and down stubs for a new SQL migration into dir and returns
+// its ID. The number follows the highest one among existing and the files already in dir.
+func CreateMigration(dir, name string, existing []Migration) (string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid migration name %q: use lowercase letters, digits and underscores", name)
+	}
+
+	inDir, err := loadSQLMigrations(os.DirFS(dir), ".")
+	if err != nil {
+		return "", err
+	}
+
+	next := 0
+	for _, migration := range append(existing, inDir...) {
+		if n := migrationNumber(migration.ID); n > next {
+			next = n
+		}
+	}
+	id := fmt.Sprintf("%03d_%s", next+1, name)
+
+	stubs := []struct {
+		suffix  string
+		content string
+	}{
+		{".up.sql", fmt.Sprintf("-- %s\n-- Write the schema change here. Separate statements with semicolons.\n", id)},
+		{".down.sql", fmt.Sprintf("-- Rollback of %s\n-- Undo the up migration here, or delete this file if it cannot be reversed.\n", id)},
+	}
+	for _, stub := range stubs {
+		file, err := os.OpenFile(filepath.Join(dir, id+stub.suffix), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+		_, err = file.WriteString(stub.content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write migration file: %w", err)
+		}
+	}
+
+	return id, nil
+}
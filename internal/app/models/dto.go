@@ -1,9 +1,12 @@
 package models
 
+import "time"
+
 // LoginRequest represents the login request payload
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
+	ClientIP string `json:"-"` // Set by the handler for login rate limiting
 }
 
 // RegisterRequest represents the user registration request payload
@@ -14,6 +17,26 @@ type RegisterRequest struct {
 	Password string  `json:"password" binding:"required,min=6"`
 }
 
+// RefreshTokenRequest represents the refresh token request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// IntrospectTokenRequest represents the token introspection request payload
+type IntrospectTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// SetRoleRequest represents the change user role request payload
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+// SetLogLevelRequest represents the change log level request payload
+type SetLogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error"`
+}
+
 // UpdateUserRequest represents the update user profile request payload
 type UpdateUserRequest struct {
 	FullName  string  `json:"full_name" binding:"required"`
@@ -22,18 +45,75 @@ type UpdateUserRequest struct {
 }
 
 // UpdateProfileRequest represents the update profile request payload
+// Omitted or null fields are left unchanged; an empty phone or avatar_url clears it.
 type UpdateProfileRequest struct {
-	FullName  string  `json:"full_name,omitempty"`
-	Phone     string  `json:"phone,omitempty"`
+	FullName  *string `json:"full_name,omitempty"`
+	Phone     *string `json:"phone,omitempty"`
 	AvatarURL *string `json:"avatar_url,omitempty"`
+	Email     *string `json:"email,omitempty"`
+}
+
+// ChangePasswordRequest represents the change password request payload
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest confirms account deletion with the user's password
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// SetWebhookRequest represents the webhook configuration payload
+type SetWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// WebhookResponse represents a configured webhook. Secret signs the deliveries and is
+// only returned when the webhook is set.
+type WebhookResponse struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// ForgotPasswordRequest represents the forgot password request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the reset password request payload
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 // CreateContactRequest represents the create contact request payload
 type CreateContactRequest struct {
-	FullName string  `json:"full_name" binding:"required"`
-	Phone    string  `json:"phone" binding:"required"`
-	Email    *string `json:"email,omitempty" binding:"omitempty,email"`
-	Favorite bool    `json:"favorite"`
+	FullName string   `json:"full_name" binding:"required"`
+	Phone    string   `json:"phone" binding:"required"`
+	Email    *string  `json:"email,omitempty" binding:"omitempty,email"`
+	Favorite bool     `json:"favorite"`
+	Tags     []string `json:"tags,omitempty"`     // At most 10 tags of up to 30 characters
+	Birthday *string  `json:"birthday,omitempty"` // YYYY-MM-DD
+	Notes    *string  `json:"notes,omitempty"`    // At most 2000 characters
+	// Phones and Emails add further numbers and addresses; phone and email stay primary
+	// unless an entry is marked is_primary
+	Phones []ContactPhoneRequest `json:"phones,omitempty" binding:"omitempty,max=10,dive"`
+	Emails []ContactEmailRequest `json:"emails,omitempty" binding:"omitempty,max=10,dive"`
+}
+
+// ContactPhoneRequest is one phone number of a contact
+type ContactPhoneRequest struct {
+	Phone     string `json:"phone" binding:"required"`
+	Label     string `json:"label,omitempty" binding:"max=30"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+// ContactEmailRequest is one email address of a contact
+type ContactEmailRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Label     string `json:"label,omitempty" binding:"max=30"`
+	IsPrimary bool   `json:"is_primary"`
 }
 
 // UpdateContactRequest represents the update contact request payload
@@ -42,14 +122,74 @@ type UpdateContactRequest struct {
 	Phone    *string `json:"phone,omitempty"`
 	Email    *string `json:"email,omitempty" binding:"omitempty,email"`
 	Favorite *bool   `json:"favorite,omitempty"`
+	// Tags replaces all tags when present; an empty array clears them
+	Tags *[]string `json:"tags,omitempty"`
+	// Birthday is YYYY-MM-DD; an empty string clears it
+	Birthday *string `json:"birthday,omitempty"`
+	// Notes is at most 2000 characters; an empty string clears it
+	Notes *string `json:"notes,omitempty"`
+	// Phones replaces all numbers when present; the entry marked is_primary, or else the
+	// first one, becomes the primary phone
+	Phones *[]ContactPhoneRequest `json:"phones,omitempty" binding:"omitempty,min=1,max=10,dive"`
+	// Emails replaces all addresses when present; an empty array clears them
+	Emails *[]ContactEmailRequest `json:"emails,omitempty" binding:"omitempty,max=10,dive"`
+}
+
+// IsEmpty reports whether the request changes nothing, i.e. every field was omitted
+func (r *UpdateContactRequest) IsEmpty() bool {
+	return r.FullName == nil && r.Phone == nil && r.Email == nil && r.Favorite == nil &&
+		r.Tags == nil && r.Birthday == nil && r.Notes == nil && r.Phones == nil && r.Emails == nil
+}
+
+// BatchDeleteContactsRequest represents the batch delete contacts request payload
+type BatchDeleteContactsRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=100"`
+}
+
+// BatchFavoriteContactsRequest represents the batch favorite contacts request payload
+type BatchFavoriteContactsRequest struct {
+	IDs      []uint `json:"ids" binding:"required,min=1,max=100"`
+	Favorite *bool  `json:"favorite" binding:"required"`
+}
+
+// MergeContactsRequest represents the merge contacts request payload
+type MergeContactsRequest struct {
+	SourceID uint `json:"source_id" binding:"required"`
 }
 
 // ListContactsRequest represents query parameters for listing contacts
 type ListContactsRequest struct {
-	Page     int    `form:"page" binding:"min=1"`
-	Limit    int    `form:"limit" binding:"min=1,max=100"`
-	Search   string `form:"q"`
-	Favorite *bool  `form:"favorite"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"` // Defaulted and capped by the service
+	Search string `form:"q"`
+	// SearchFields restricts the search to these columns (full_name, phone, email); all by default
+	SearchFields []string `form:"search_fields" collection_format:"csv"`
+	Favorite     *bool    `form:"favorite"`
+	Sort         string   `form:"sort"`  // One of full_name, created_at, favorite, phone
+	Order        string   `form:"order"` // asc or desc
+	Tag          string   `form:"tag"`   // Only contacts with this tag
+	// Country only returns contacts whose phone was detected in this country (e.g. ID)
+	Country string `form:"country"`
+	// FullText searches full_name and email through the FULLTEXT index instead of
+	// LIKE. Searches with words shorter than the index minimum still use LIKE.
+	FullText bool `form:"full_text"`
+	// IncludeDeleted also returns soft-deleted (trashed) contacts
+	IncludeDeleted bool `form:"include_deleted"`
+	// Cursor continues a newest-first listing after the contact it encodes;
+	// offset pagination is used when empty
+	Cursor string `form:"cursor"`
+	// UpdatedSince (RFC3339) only returns contacts changed after this time, including
+	// soft-deleted ones so sync clients can remove them locally
+	UpdatedSince *time.Time `form:"updated_since"`
+	// WithCount counts the matching contacts for the total, which is the default. When
+	// false the count query is skipped, total and total_pages are -1, and only whether
+	// a next page exists is reported.
+	WithCount *bool `form:"with_count"`
+}
+
+// CountsTotal reports whether the total number of matching contacts is requested
+func (r *ListContactsRequest) CountsTotal() bool {
+	return r.WithCount == nil || *r.WithCount
 }
 
 // Response represents a standard API response
@@ -60,6 +200,19 @@ type Response struct {
 	Data       interface{} `json:"data,omitempty"`
 }
 
+// ListAuditLogsRequest represents query parameters for listing audit log entries
+type ListAuditLogsRequest struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1"` // Defaulted and capped by the service
+}
+
+// ListUsersRequest represents query parameters for the admin user listing
+type ListUsersRequest struct {
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"` // Defaulted and capped by the service
+	Search string `form:"q"`                               // Matches full name or email
+}
+
 // PaginationMeta represents pagination metadata
 type PaginationMeta struct {
 	Page        int   `json:"page"`
@@ -68,6 +221,8 @@ type PaginationMeta struct {
 	TotalPages  int   `json:"total_pages"`
 	HasNextPage bool  `json:"has_next_page"`
 	HasPrevPage bool  `json:"has_prev_page"`
+	// NextCursor fetches the following page with keyset pagination
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // PaginatedResponse represents a paginated API response
@@ -86,6 +241,7 @@ type ErrorResponse struct {
 
 // AuthResponse represents authentication response with token
 type AuthResponse struct {
-	User  *UserResponse `json:"user"`
-	Token string        `json:"token"`
+	User         *UserResponse `json:"user"`
+	Token        string        `json:"token"`
+	RefreshToken string        `json:"refresh_token"`
 }
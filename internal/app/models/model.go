@@ -1,39 +1,75 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // User represents a user in the system
 type User struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	FullName  string    `gorm:"type:varchar(255);not null;index:idx_users_full_name" json:"full_name" binding:"required"`
-	Email     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_users_email" json:"email" binding:"required,email"`
-	Phone     *string   `gorm:"type:varchar(20);index:idx_users_phone" json:"phone,omitempty"` // Optional field
-	Password  string    `gorm:"type:varchar(255);not null" json:"-"`                           // Excluded from JSON
-	AvatarURL *string   `gorm:"type:varchar(255)" json:"avatar_url,omitempty"`
-	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_users_created_at" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	FullName      string     `gorm:"type:varchar(255);not null;index:idx_users_full_name" json:"full_name" binding:"required"`
+	Email         string     `gorm:"type:varchar(255);not null;index:idx_users_email" json:"email" binding:"required,email"` // Unique among non-deleted users
+	Phone         *string    `gorm:"type:varchar(20);index:idx_users_phone" json:"phone,omitempty"`                          // Optional field
+	Password      string     `gorm:"type:varchar(255);not null" json:"-"`                                                    // Excluded from JSON
+	AvatarURL     *string    `gorm:"type:varchar(255)" json:"avatar_url,omitempty"`
+	EmailVerified bool       `gorm:"not null;default:false" json:"email_verified"`
+	Role          string     `gorm:"type:varchar(20);not null;default:user" json:"role"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"` // Set while the account is deactivated
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`   // Last authenticated request, to the minute
+	CreatedAt     time.Time  `gorm:"autoCreateTime;index:idx_users_created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	// DeletedAt is set when the account is deleted; deleted users can be restored by an operator
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_users_deleted_at" json:"-"`
+	// WebhookURL receives the user's contact events, signed with WebhookSecret
+	WebhookURL    *string `gorm:"type:varchar(2048)" json:"-"`
+	WebhookSecret *string `gorm:"type:varchar(64)" json:"-"`
 
 	// Relations
 	Contacts []Contact `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"contacts,omitempty"`
 }
 
+// User roles; admins may use the role-guarded admin endpoints
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // TableName overrides the table name for User model
 func (User) TableName() string {
 	return "users"
 }
 
+// IsActive reports whether the account has not been deactivated
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
 // Contact represents a contact entry for a user
 type Contact struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID    uint      `gorm:"not null;index:idx_contacts_user_id,idx_contacts_user_favorite,idx_contacts_user_created" json:"user_id"`
-	FullName  string    `gorm:"type:varchar(255);not null;index:idx_contacts_full_name" json:"full_name" binding:"required"`
-	Phone     string    `gorm:"type:varchar(20);not null;index:idx_contacts_phone" json:"phone" binding:"required"`
-	Email     *string   `gorm:"type:varchar(255);index:idx_contacts_email" json:"email,omitempty"`
-	Favorite  bool      `gorm:"default:false;index:idx_contacts_favorite,idx_contacts_user_favorite" json:"favorite"`
-	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_contacts_created_at,idx_contacts_user_created" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID   uint   `gorm:"not null;index:idx_contacts_user_id,idx_contacts_user_favorite,idx_contacts_user_created" json:"user_id"`
+	FullName string `gorm:"type:varchar(255);not null;index:idx_contacts_full_name" json:"full_name" binding:"required"`
+	Phone    string `gorm:"type:varchar(20);not null;index:idx_contacts_phone" json:"phone" binding:"required"`
+	// PhoneCountry is the ISO 3166-1 alpha-2 code detected from Phone, nil when unknown
+	PhoneCountry *string `gorm:"type:char(2)" json:"phone_country,omitempty"`
+	// PhoneShared leaves Phone out of the per-user uniqueness check, for contacts saved
+	// while duplicate phones are allowed
+	PhoneShared bool           `gorm:"not null;default:false" json:"-"`
+	Email       *string        `gorm:"type:varchar(255);index:idx_contacts_email" json:"email,omitempty"`
+	Favorite    bool           `gorm:"default:false;index:idx_contacts_favorite,idx_contacts_user_favorite" json:"favorite"`
+	Birthday    *time.Time     `gorm:"type:date" json:"birthday,omitempty"` // Date only, at midnight UTC
+	Notes       *string        `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime;index:idx_contacts_created_at,idx_contacts_user_created" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index:idx_contacts_deleted_at" json:"deleted_at,omitempty"`
+	Tags        []string       `gorm:"-" json:"tags,omitempty"` // Loaded from contact_tags
+	// Phones and Emails list every number and address, including the primary ones mirrored
+	// in Phone and Email. They are loaded for a single contact only.
+	Phones []ContactPhone `gorm:"foreignKey:ContactID" json:"phones,omitempty"`
+	Emails []ContactEmail `gorm:"foreignKey:ContactID" json:"emails,omitempty"`
 
 	// Relations
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
@@ -44,52 +80,243 @@ func (Contact) TableName() string {
 	return "contacts"
 }
 
+// DateLayout is the format of date-only values such as birthdays
+const DateLayout = "2006-01-02"
+
+// ContactTag represents a label attached to a contact
+type ContactTag struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ContactID uint      `gorm:"not null;uniqueIndex:idx_contact_tags_contact_tag" json:"contact_id"`
+	Tag       string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_contact_tags_contact_tag;index:idx_contact_tags_tag" json:"tag"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the table name for ContactTag model
+func (ContactTag) TableName() string {
+	return "contact_tags"
+}
+
+// ContactPhone is one of a contact's phone numbers. Exactly one number of a contact is
+// primary and is kept equal to Contact.Phone.
+type ContactPhone struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	ContactID uint      `gorm:"not null;uniqueIndex:idx_contact_phones_contact_phone" json:"-"`
+	Phone     string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_contact_phones_contact_phone;index:idx_contact_phones_phone" json:"phone"`
+	Label     string    `gorm:"type:varchar(30);not null;default:''" json:"label"`
+	IsPrimary bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+// TableName overrides the table name for ContactPhone model
+func (ContactPhone) TableName() string {
+	return "contact_phones"
+}
+
+// ContactEmail is one of a contact's email addresses. The primary address, if any, is
+// kept equal to Contact.Email.
+type ContactEmail struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	ContactID uint      `gorm:"not null;uniqueIndex:idx_contact_emails_contact_email" json:"-"`
+	Email     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_contact_emails_contact_email" json:"email"`
+	Label     string    `gorm:"type:varchar(30);not null;default:''" json:"label"`
+	IsPrimary bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+// TableName overrides the table name for ContactEmail model
+func (ContactEmail) TableName() string {
+	return "contact_emails"
+}
+
+// Contact revision actions
+const (
+	RevisionActionUpdate = "update"
+	RevisionActionDelete = "delete"
+)
+
+// ContactRevision records a JSON snapshot of a contact taken when it was updated or deleted
+type ContactRevision struct {
+	ID        uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	ContactID uint            `gorm:"not null;index:idx_contact_revisions_user_contact,priority:2" json:"contact_id"`
+	UserID    uint            `gorm:"not null;index:idx_contact_revisions_user_contact,priority:1" json:"user_id"`
+	Action    string          `gorm:"type:varchar(20);not null" json:"action"`
+	Snapshot  json.RawMessage `gorm:"type:json;not null" json:"snapshot"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the table name for ContactRevision model
+func (ContactRevision) TableName() string {
+	return "contact_revisions"
+}
+
+// RefreshToken represents an issued refresh token, tracked by its JWT ID (jti) so it can be revoked
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint       `gorm:"not null;index:idx_refresh_tokens_user_id" json:"user_id"`
+	JTI       string     `gorm:"column:jti;type:varchar(64);not null;uniqueIndex:idx_refresh_tokens_jti" json:"jti"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the table name for RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// Session represents a signed-in device. JTI identifies the session: it is the ID of the
+// refresh token that started it and is carried as the sid claim of every token refreshed from it.
+type Session struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint       `gorm:"not null;index:idx_sessions_user_id" json:"user_id"`
+	JTI        string     `gorm:"column:jti;type:varchar(64);not null;uniqueIndex:idx_sessions_jti" json:"jti"`
+	UserAgent  string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt time.Time  `gorm:"not null" json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the table name for Session model
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// SessionResponse represents an active session sent to clients
+type SessionResponse struct {
+	JTI        string    `json:"jti"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ToResponse converts Session to SessionResponse
+func (s *Session) ToResponse() *SessionResponse {
+	return &SessionResponse{
+		JTI:        s.JTI,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
+
+// Audit log actions
+const (
+	AuditActionLogin          = "login"
+	AuditActionPasswordChange = "password_change"
+	AuditActionAccountDelete  = "account_delete"
+	AuditActionContactDelete  = "contact_delete"
+)
+
+// AuditLog records a security sensitive action performed by a user
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_audit_logs_user_created,priority:1" json:"user_id"`
+	Action    string    `gorm:"type:varchar(50);not null" json:"action"`
+	Target    string    `gorm:"type:varchar(255)" json:"target"`
+	IPAddress string    `gorm:"type:varchar(45)" json:"ip_address"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_audit_logs_user_created,priority:2" json:"created_at"`
+}
+
+// TableName overrides the table name for AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
 // UserResponse represents the user data sent to clients (without sensitive data)
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	FullName  string    `json:"full_name"`
-	Email     string    `json:"email"`
-	Phone     *string   `json:"phone,omitempty"` // Optional field
-	AvatarURL *string   `json:"avatar_url,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint       `json:"id"`
+	FullName      string     `json:"full_name"`
+	Email         string     `json:"email"`
+	Phone         *string    `json:"phone,omitempty"` // Optional field
+	AvatarURL     *string    `json:"avatar_url,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	LastSeenAt    *time.Time `json:"last_seen_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
-		FullName:  u.FullName,
-		Email:     u.Email,
-		Phone:     u.Phone,
-		AvatarURL: u.AvatarURL,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:            u.ID,
+		FullName:      u.FullName,
+		Email:         u.Email,
+		Phone:         u.Phone,
+		AvatarURL:     u.AvatarURL,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		LastSeenAt:    u.LastSeenAt,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
 	}
 }
 
+// ContactStats aggregates a user's contacts for dashboards
+type ContactStats struct {
+	Total       int64 `json:"total"`
+	Favorites   int64 `json:"favorites"`
+	WithEmail   int64 `json:"with_email"`
+	NewThisWeek int64 `json:"new_this_week"` // Created in the last 7 days
+}
+
 // ContactResponse represents the contact data sent to clients
 type ContactResponse struct {
-	ID        uint      `json:"id"`
-	UserID    uint      `json:"user_id"`
-	FullName  string    `json:"full_name"`
-	Phone     string    `json:"phone"`
-	Email     *string   `json:"email,omitempty"`
-	Favorite  bool      `json:"favorite"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       uint   `json:"id"`
+	UserID   uint   `json:"user_id"`
+	FullName string `json:"full_name"`
+	Phone    string `json:"phone"`
+	// PhoneCountry is the ISO 3166-1 alpha-2 code detected from the phone, e.g. "ID"
+	PhoneCountry *string    `json:"phone_country,omitempty"`
+	Email        *string    `json:"email,omitempty"`
+	Favorite     bool       `json:"favorite"`
+	Birthday     *string    `json:"birthday,omitempty"` // YYYY-MM-DD
+	Notes        *string    `json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"` // Set only for trashed contacts
+	Tags         []string   `json:"tags"`
+	// Set when the contact was loaded with its phones and emails
+	Phones []ContactPhone `json:"phones,omitempty"`
+	Emails []ContactEmail `json:"emails,omitempty"`
+	// Warnings are non-fatal problems with a created or updated contact, such as a phone
+	// number another contact already has
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // ToResponse converts Contact to ContactResponse
 func (c *Contact) ToResponse() *ContactResponse {
-	return &ContactResponse{
-		ID:        c.ID,
-		UserID:    c.UserID,
-		FullName:  c.FullName,
-		Phone:     c.Phone,
-		Email:     c.Email,
-		Favorite:  c.Favorite,
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
+	resp := &ContactResponse{
+		ID:           c.ID,
+		UserID:       c.UserID,
+		FullName:     c.FullName,
+		Phone:        c.Phone,
+		PhoneCountry: c.PhoneCountry,
+		Email:        c.Email,
+		Favorite:     c.Favorite,
+		Notes:        c.Notes,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+		Tags:         c.Tags,
+		Phones:       c.Phones,
+		Emails:       c.Emails,
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+	if c.Birthday != nil {
+		birthday := c.Birthday.Format(DateLayout)
+		resp.Birthday = &birthday
+	}
+	if c.DeletedAt.Valid {
+		deletedAt := c.DeletedAt.Time
+		resp.DeletedAt = &deletedAt
 	}
+	return resp
 }
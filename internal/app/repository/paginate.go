@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultPageLimit is the page size used when a listing asks for none
+const defaultPageLimit = 10
+
+// pageReq describes one page of a listing for paginate
+type pageReq struct {
+	// Page is 1-based; Limit is the page size. Both are clamped to at least 1, with
+	// defaultPageLimit used for a missing limit.
+	Page  int
+	Limit int
+	// Order is the ORDER BY clause, e.g. "created_at DESC, id DESC"
+	Order string
+	// After, when set, is a keyset condition such as a cursor: the page starts at the first
+	// row matching it instead of at an offset, while the total still counts every row
+	After clause.Expression
+	// SkipCount skips the count query. The total is then -1 and one row past the page
+	// is fetched so the caller can tell whether a next page exists.
+	SkipCount bool
+	// Name is the plural of what is listed, used in error messages
+	Name string
+	// Scopes apply to the page query only, e.g. preloads the count query must not run
+	Scopes []func(*gorm.DB) *gorm.DB
+}
+
+// paginate counts the rows matching query and fetches the requested page of them in
+// req.Order. query must already hold the model and filters.
+func paginate[T any](query *gorm.DB, req pageReq) ([]T, int64, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = defaultPageLimit
+	}
+
+	total := int64(-1)
+	limit := req.Limit
+	if req.SkipCount {
+		limit++
+	} else if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count %s: %w", req.Name, err)
+	}
+
+	page := query.Scopes(req.Scopes...).Order(req.Order).Limit(limit)
+	if req.After != nil {
+		page = page.Where(req.After)
+	} else {
+		page = page.Offset((req.Page - 1) * req.Limit)
+	}
+
+	var rows []T
+	if err := page.Find(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list %s: %w", req.Name, err)
+	}
+	return rows, total, nil
+}
@@ -2,12 +2,24 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"user-service/internal/app/models"
 
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -19,6 +31,10 @@ var (
 	ErrDuplicatePhone = errors.New("phone number already exists")
 	// ErrInvalidID is returned when ID is invalid
 	ErrInvalidID = errors.New("invalid ID")
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrLimitReached is returned when a user already has the maximum number of contacts
+	ErrLimitReached = errors.New("contact limit reached")
 )
 
 // UserRepository defines the interface for user data operations
@@ -29,28 +45,112 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id uint) (*models.User, error)
 	// GetByEmail retrieves a user by email
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
-	// Update updates an existing user
-	Update(ctx context.Context, user *models.User) error
-	// Delete deletes a user by ID
+	// Update writes the given columns of user, e.g. "password"; other columns are untouched
+	Update(ctx context.Context, user *models.User, columns ...string) error
+	// Delete soft-deletes a user by ID
 	Delete(ctx context.Context, id uint) error
+	// Restore undoes the soft delete of a user
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently deletes a user, including a soft-deleted one, and everything they own
+	Purge(ctx context.Context, id uint) error
+	// SetActive activates or deactivates a user, recording when it was deactivated
+	SetActive(ctx context.Context, id uint, active bool) error
+	// SetRole changes the role of a user
+	SetRole(ctx context.Context, id uint, role string) error
+	// SetLastSeen records when a user was last active
+	SetLastSeen(ctx context.Context, id uint, at time.Time) error
 	// CheckEmailExists checks if email already exists
 	CheckEmailExists(ctx context.Context, email string, excludeUserID uint) (bool, error)
+	// List retrieves users with pagination, optionally searching name and email
+	List(ctx context.Context, req *models.ListUsersRequest) ([]models.User, int64, error)
 }
 
 // ContactRepository defines the interface for contact data operations
 type ContactRepository interface {
-	// Create creates a new contact
-	Create(ctx context.Context, contact *models.Contact) error
-	// GetByID retrieves a contact by ID and user ID
+	// Create creates a new contact with its phones, emails and tags. A positive
+	// maxContacts fails it with ErrLimitReached once the user has that many contacts.
+	Create(ctx context.Context, contact *models.Contact, maxContacts int) error
+	// GetByID retrieves a contact by ID and user ID with its tags, phones and emails
 	GetByID(ctx context.Context, userID, contactID uint) (*models.Contact, error)
-	// Update updates an existing contact
-	Update(ctx context.Context, contact *models.Contact) error
-	// Delete deletes a contact by ID and user ID
+	// Update updates an existing contact and replaces the child rows given in children,
+	// all in one transaction
+	Update(ctx context.Context, contact *models.Contact, children ContactChildren) error
+	// Delete soft-deletes a contact by ID and user ID
 	Delete(ctx context.Context, userID, contactID uint) error
-	// List retrieves contacts with pagination and filtering
+	// DeleteMany soft-deletes the given contacts owned by a user and returns the deleted contacts
+	DeleteMany(ctx context.Context, userID uint, contactIDs []uint) ([]models.Contact, error)
+	// SetFavorite sets the favorite flag of the given contacts owned by a user, recording
+	// revisions in the same transaction, and returns the updated contacts
+	SetFavorite(ctx context.Context, userID uint, contactIDs []uint, favorite bool) ([]models.Contact, error)
+	// Merge saves the merged target contact with its tags, phones and emails and deletes the
+	// source contact in one transaction
+	Merge(ctx context.Context, target *models.Contact, sourceID uint) error
+	// Restore recovers a soft-deleted contact by ID and user ID
+	Restore(ctx context.Context, userID, contactID uint) error
+	// List retrieves contacts with pagination and filtering. Without req.CountsTotal the
+	// total is -1 and one row beyond the page is returned when another page follows.
 	List(ctx context.Context, userID uint, req *models.ListContactsRequest) ([]models.Contact, int64, error)
-	// CheckPhoneExists checks if phone already exists for a user
+	// ListAll retrieves all contacts of a user ordered by name
+	ListAll(ctx context.Context, userID uint) ([]models.Contact, error)
+	// ListInBatches calls fn with all contacts of a user and their tags, ordered by ID and
+	// batchSize at a time, so they never all have to be held in memory
+	ListInBatches(ctx context.Context, userID uint, batchSize int, fn func([]models.Contact) error) error
+	// ListRecent retrieves the limit most recently created contacts of a user, newest first
+	ListRecent(ctx context.Context, userID uint, limit int) ([]models.Contact, error)
+	// ListWithBirthday retrieves all contacts of a user that have a birthday set
+	ListWithBirthday(ctx context.Context, userID uint) ([]models.Contact, error)
+	// Count returns how many contacts a user has
+	Count(ctx context.Context, userID uint) (int64, error)
+	// Stats aggregates a user's contacts; NewThisWeek counts contacts created since the given time
+	Stats(ctx context.Context, userID uint, since time.Time) (*models.ContactStats, error)
+	// CheckPhoneExists checks if phone already exists as any number of a user's contacts
 	CheckPhoneExists(ctx context.Context, userID uint, phone string, excludeContactID uint) (bool, error)
+	// GetByPhone retrieves a user's contact whose phone exactly matches one of phones
+	GetByPhone(ctx context.Context, userID uint, phones []string) (*models.Contact, error)
+	// GetTags retrieves the tags of the given contacts keyed by contact ID
+	GetTags(ctx context.Context, contactIDs []uint) (map[uint][]string, error)
+	// ListRevisions retrieves the revisions of a user's contact, newest first
+	ListRevisions(ctx context.Context, userID, contactID uint) ([]models.ContactRevision, error)
+}
+
+// ContactChildren are the tags, phone numbers and email addresses a contact Update
+// replaces. A nil slice leaves the existing rows alone; an empty one removes them.
+type ContactChildren struct {
+	Tags   []string
+	Phones []models.ContactPhone
+	Emails []models.ContactEmail
+}
+
+// RefreshTokenRepository defines the interface for refresh token data operations
+type RefreshTokenRepository interface {
+	// Create stores a newly issued refresh token
+	Create(ctx context.Context, token *models.RefreshToken) error
+	// GetByJTI retrieves a refresh token by its JWT ID
+	GetByJTI(ctx context.Context, jti string) (*models.RefreshToken, error)
+	// Revoke marks a refresh token as revoked
+	Revoke(ctx context.Context, jti string) error
+}
+
+// SessionRepository defines the interface for signed-in session operations
+type SessionRepository interface {
+	// Create stores a newly started session
+	Create(ctx context.Context, session *models.Session) error
+	// GetByJTI retrieves a session by its ID
+	GetByJTI(ctx context.Context, jti string) (*models.Session, error)
+	// ListActive retrieves a user's unrevoked, unexpired sessions, most recently used first
+	ListActive(ctx context.Context, userID uint) ([]models.Session, error)
+	// Touch records that a session was refreshed and extends its expiry
+	Touch(ctx context.Context, jti string, expiresAt time.Time) error
+	// Revoke ends one of a user's active sessions
+	Revoke(ctx context.Context, userID uint, jti string) error
+}
+
+// AuditLogRepository defines the interface for audit log operations
+type AuditLogRepository interface {
+	// Create stores an audit log entry
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// ListByUser retrieves a user's audit log entries, newest first
+	ListByUser(ctx context.Context, userID uint, page, limit int) ([]models.AuditLog, int64, error)
 }
 
 // userRepository implements UserRepository interface
@@ -100,9 +200,14 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, nil
 }
 
-// Update updates an existing user
-func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	result := r.db.WithContext(ctx).Model(user).Updates(user)
+// Update writes only the given columns of user, so concurrent updates of other columns
+// are not undone by a stale copy. Cleared optional fields (nil pointers) among them are
+// persisted as NULL.
+func (r *userRepository) Update(ctx context.Context, user *models.User, columns ...string) error {
+	if len(columns) == 0 {
+		return errors.New("no user columns to update")
+	}
+	result := r.db.WithContext(ctx).Model(user).Select(columns).Updates(user)
 	if result.Error != nil {
 		if isDuplicateError(result.Error) {
 			return ErrDuplicateEmail
@@ -115,7 +220,7 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// Delete deletes a user by ID
+// Delete soft-deletes a user by ID; the user's data is kept so it can be restored
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
 	result := r.db.WithContext(ctx).Delete(&models.User{}, id)
 	if result.Error != nil {
@@ -127,6 +232,111 @@ func (r *userRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+// Purge permanently deletes a user and all their data in one transaction. Dependent
+// rows are deleted explicitly rather than left to ON DELETE CASCADE, which audit_logs
+// lacks and which does not cover contacts that were only soft-deleted before.
+func (r *userRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Unscoped so soft-deleted rows go too
+		contactIDs := tx.Unscoped().Model(&models.Contact{}).Select("id").Where("user_id = ?", id)
+
+		// Contact data goes first, the contacts it refers to after
+		byContact := []interface{}{"contact_id IN (?)", contactIDs}
+		byUser := []interface{}{"user_id = ?", id}
+		for _, dependent := range []struct {
+			name  string
+			model interface{}
+			where []interface{}
+		}{
+			{"contact tags", &models.ContactTag{}, byContact},
+			{"contact phones", &models.ContactPhone{}, byContact},
+			{"contact emails", &models.ContactEmail{}, byContact},
+			{"contact revisions", &models.ContactRevision{}, byContact},
+			{"contacts", &models.Contact{}, byUser},
+			{"refresh tokens", &models.RefreshToken{}, byUser},
+			{"sessions", &models.Session{}, byUser},
+			{"audit logs", &models.AuditLog{}, byUser},
+		} {
+			if err := tx.Unscoped().Where(dependent.where[0], dependent.where[1:]...).Delete(dependent.model).Error; err != nil {
+				return fmt.Errorf("failed to delete %s: %w", dependent.name, err)
+			}
+		}
+
+		result := tx.Unscoped().Delete(&models.User{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// Restore clears deleted_at of a soft-deleted user
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+
+	if result.Error != nil {
+		// Another account has registered the email in the meantime
+		if isDuplicateError(result.Error) {
+			return ErrDuplicateEmail
+		}
+		return fmt.Errorf("failed to restore user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetActive activates or deactivates a user by clearing or setting deactivated_at
+func (r *userRepository) SetActive(ctx context.Context, id uint, active bool) error {
+	var deactivatedAt *time.Time
+	if !active {
+		now := time.Now()
+		deactivatedAt = &now
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("deactivated_at", deactivatedAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user status: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetRole changes the role of a user
+func (r *userRepository) SetRole(ctx context.Context, id uint, role string) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetLastSeen records when a user was last active. It leaves updated_at alone, which
+// tracks profile changes.
+func (r *userRepository) SetLastSeen(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).UpdateColumn("last_seen_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update last seen: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // CheckEmailExists checks if email already exists
 func (r *userRepository) CheckEmailExists(ctx context.Context, email string, excludeUserID uint) (bool, error) {
 	var count int64
@@ -141,6 +351,25 @@ func (r *userRepository) CheckEmailExists(ctx context.Context, email string, exc
 	return count > 0, nil
 }
 
+// List retrieves users with pagination, newest first
+func (r *userRepository) List(ctx context.Context, req *models.ListUsersRequest) ([]models.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	if req.Search != "" {
+		pattern := "%" + req.Search + "%"
+		query = query.Where(clause.Or(
+			clause.Like{Column: clause.Column{Name: "full_name"}, Value: pattern},
+			clause.Like{Column: clause.Column{Name: "email"}, Value: pattern},
+		))
+	}
+
+	return paginate[models.User](query, pageReq{
+		Page:  req.Page,
+		Limit: req.Limit,
+		Order: "created_at DESC,id DESC",
+		Name:  "users",
+	})
+}
+
 // contactRepository implements ContactRepository interface
 type contactRepository struct {
 	db *gorm.DB
@@ -151,54 +380,354 @@ func NewContactRepository(db *gorm.DB) ContactRepository {
 	return &contactRepository{db: db}
 }
 
-// Create creates a new contact
-func (r *contactRepository) Create(ctx context.Context, contact *models.Contact) error {
-	if err := r.db.WithContext(ctx).Create(contact).Error; err != nil {
-		return fmt.Errorf("failed to create contact: %w", err)
+// Create creates a new contact with its phones, emails and tags in one transaction. The
+// contact limit is checked in the same transaction while holding a lock on the user's
+// row, so concurrent creates for a user cannot exceed it.
+func (r *contactRepository) Create(ctx context.Context, contact *models.Contact, maxContacts int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if maxContacts > 0 {
+			if err := checkContactLimit(tx, contact.UserID, maxContacts); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(contact).Error; err != nil {
+			if isDuplicateError(err) {
+				return ErrDuplicatePhone
+			}
+			return fmt.Errorf("failed to create contact: %w", err)
+		}
+		return insertTags(tx, contact.ID, contact.Tags)
+	})
+}
+
+// checkContactLimit locks the user's row until tx ends, serializing the user's contact
+// creates, and returns ErrLimitReached when they already have maxContacts contacts
+func checkContactLimit(tx *gorm.DB, userID uint, maxContacts int) error {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", userID).
+		Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var count int64
+	if err := tx.Model(&models.Contact{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count contacts: %w", err)
+	}
+	if count >= int64(maxContacts) {
+		return ErrLimitReached
 	}
 	return nil
 }
 
-// GetByID retrieves a contact by ID and user ID
+// GetByID retrieves a contact by ID and user ID with its tags, phones and emails
 func (r *contactRepository) GetByID(ctx context.Context, userID, contactID uint) (*models.Contact, error) {
-	var contact models.Contact
+	var contacts []models.Contact
 	err := r.db.WithContext(ctx).
-		Where("id = ? AND user_id = ?", contactID, userID).
-		First(&contact).Error
+		Preload("Phones", primaryFirst).
+		Preload("Emails", primaryFirst).
+		Where("id = ?", contactID).
+		Where("user_id = ?", userID).
+		Find(&contacts).Error
 
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrNotFound
-		}
 		return nil, fmt.Errorf("failed to get contact: %w", err)
 	}
-	return &contact, nil
+	if len(contacts) == 0 {
+		return nil, ErrNotFound
+	}
+
+	if err := attachTags(r.db.WithContext(ctx), contacts); err != nil {
+		return nil, err
+	}
+	return &contacts[0], nil
 }
 
-// Update updates an existing contact
-func (r *contactRepository) Update(ctx context.Context, contact *models.Contact) error {
-	result := r.db.WithContext(ctx).
-		Model(contact).
-		Where("id = ? AND user_id = ?", contact.ID, contact.UserID).
-		Updates(contact)
+// GetByPhone retrieves a user's contact whose primary or secondary phone exactly matches
+// one of phones, preferring the oldest contact when several match
+func (r *contactRepository) GetByPhone(ctx context.Context, userID uint, phones []string) (*models.Contact, error) {
+	if len(phones) == 0 {
+		return nil, ErrNotFound
+	}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update contact: %w", result.Error)
+	secondary := r.db.Model(&models.ContactPhone{}).Select("contact_id").Where("phone IN ?", phones)
+	var contacts []models.Contact
+	err := r.db.WithContext(ctx).
+		Scopes(withPhonesAndEmails).
+		Where("user_id = ? AND (phone IN ? OR id IN (?))", userID, phones, secondary).
+		Order("id ASC").
+		Limit(1).
+		Find(&contacts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact by phone: %w", err)
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+	if len(contacts) == 0 {
+		return nil, ErrNotFound
 	}
-	return nil
+
+	if err := attachTags(r.db.WithContext(ctx), contacts); err != nil {
+		return nil, err
+	}
+	return &contacts[0], nil
 }
 
-// Delete deletes a contact by ID and user ID
+// Update updates an existing contact and its changed child rows, and records its new state
+// as a revision in the same transaction
+func (r *contactRepository) Update(ctx context.Context, contact *models.Contact, children ContactChildren) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Select the editable columns so cleared values such as a nil birthday are written too
+		result := tx.Model(contact).
+			Where("id = ? AND user_id = ?", contact.ID, contact.UserID).
+			Select("full_name", "phone", "phone_country", "phone_shared", "email", "favorite", "birthday", "notes").
+			Updates(contact)
+
+		if result.Error != nil {
+			if isDuplicateError(result.Error) {
+				return ErrDuplicatePhone
+			}
+			return fmt.Errorf("failed to update contact: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		if children.Tags != nil {
+			if err := replaceTags(tx, contact.ID, children.Tags); err != nil {
+				return err
+			}
+		}
+		if children.Phones != nil {
+			if err := replacePhones(tx, contact.ID, children.Phones); err != nil {
+				return err
+			}
+		}
+		if children.Emails != nil {
+			if err := replaceEmails(tx, contact.ID, children.Emails); err != nil {
+				return err
+			}
+		}
+		return recordRevisions(tx, models.RevisionActionUpdate, *contact)
+	})
+}
+
+// Delete deletes a contact by ID and user ID, recording its last state as a revision
+// in the same transaction
 func (r *contactRepository) Delete(ctx context.Context, userID, contactID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		contacts, err := findContactsForRevision(tx, userID, []uint{contactID})
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ? AND user_id = ?", contactID, userID).Delete(&models.Contact{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete contact: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return recordRevisions(tx, models.RevisionActionDelete, contacts...)
+	})
+}
+
+// DeleteMany soft-deletes the given contacts owned by a user in a single statement.
+// IDs that do not exist or belong to another user are skipped. The contacts are loaded
+// before they are deleted so the caller can report what was removed.
+func (r *contactRepository) DeleteMany(ctx context.Context, userID uint, contactIDs []uint) ([]models.Contact, error) {
+	if len(contactIDs) == 0 {
+		return nil, nil
+	}
+
+	var contacts []models.Contact
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Preload("Phones", primaryFirst).
+			Preload("Emails", primaryFirst).
+			Where("user_id = ? AND id IN ?", userID, contactIDs).
+			Order("id ASC").
+			Find(&contacts).Error
+		if err != nil {
+			return fmt.Errorf("failed to get contacts: %w", err)
+		}
+		if len(contacts) == 0 {
+			return nil
+		}
+		if err := attachTags(tx, contacts); err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ? AND id IN ?", userID, contactIDs).Delete(&models.Contact{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete contacts: %w", result.Error)
+		}
+		return recordRevisions(tx, models.RevisionActionDelete, contacts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// SetFavorite sets the favorite flag of the given contacts owned by a user in a single
+// statement and records their new state as revisions in the same transaction. It returns
+// the updated contacts with their tags, phones and emails; IDs that do not exist or
+// belong to another user are skipped.
+func (r *contactRepository) SetFavorite(ctx context.Context, userID uint, contactIDs []uint, favorite bool) ([]models.Contact, error) {
+	if len(contactIDs) == 0 {
+		return nil, nil
+	}
+
+	var contacts []models.Contact
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// updated_at changes too, so contacts already in the requested state still count
+		result := tx.Model(&models.Contact{}).
+			Where("user_id = ? AND id IN ?", userID, contactIDs).
+			Update("favorite", favorite)
+		if result.Error != nil {
+			return fmt.Errorf("failed to set favorite: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		err := tx.Preload("Phones", primaryFirst).
+			Preload("Emails", primaryFirst).
+			Where("user_id = ? AND id IN ?", userID, contactIDs).
+			Order("id ASC").
+			Find(&contacts).Error
+		if err != nil {
+			return fmt.Errorf("failed to get contacts: %w", err)
+		}
+		if err := attachTags(tx, contacts); err != nil {
+			return err
+		}
+		return recordRevisions(tx, models.RevisionActionUpdate, contacts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// Merge saves the merged target contact with its tags and deletes the source contact in one
+// transaction. The target's phones and emails are replaced too unless they are nil.
+func (r *contactRepository) Merge(ctx context.Context, target *models.Contact, sourceID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Select the columns explicitly so a merged email is written even when it was NULL
+		result := tx.Model(target).
+			Where("user_id = ?", target.UserID).
+			Select("full_name", "phone", "email", "favorite").
+			Updates(target)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update target contact: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		if err := replaceTags(tx, target.ID, target.Tags); err != nil {
+			return err
+		}
+		if target.Phones != nil {
+			if err := replacePhones(tx, target.ID, target.Phones); err != nil {
+				return err
+			}
+		}
+		if target.Emails != nil {
+			if err := replaceEmails(tx, target.ID, target.Emails); err != nil {
+				return err
+			}
+		}
+
+		sources, err := findContactsForRevision(tx, target.UserID, []uint{sourceID})
+		if err != nil {
+			return err
+		}
+		result = tx.Where("id = ? AND user_id = ?", sourceID, target.UserID).Delete(&models.Contact{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete source contact: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		if err := recordRevisions(tx, models.RevisionActionUpdate, *target); err != nil {
+			return err
+		}
+		return recordRevisions(tx, models.RevisionActionDelete, sources...)
+	})
+}
+
+// ListRevisions retrieves the revisions of a user's contact, newest first. Revisions are
+// read on their own so the history of a soft-deleted contact stays available.
+func (r *contactRepository) ListRevisions(ctx context.Context, userID, contactID uint) ([]models.ContactRevision, error) {
+	var revisions []models.ContactRevision
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND contact_id = ?", userID, contactID).
+		Order("id DESC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contact revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// findContactsForRevision loads the given contacts of a user with their tags so their
+// state can be snapshotted before they are deleted
+func findContactsForRevision(tx *gorm.DB, userID uint, contactIDs []uint) ([]models.Contact, error) {
+	var contacts []models.Contact
+	if err := tx.Where("user_id = ? AND id IN ?", userID, contactIDs).Find(&contacts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get contacts: %w", err)
+	}
+	if err := attachTags(tx, contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// recordRevisions stores a JSON snapshot of each contact under the given action
+func recordRevisions(tx *gorm.DB, action string, contacts ...models.Contact) error {
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	rows := make([]models.ContactRevision, len(contacts))
+	for i := range contacts {
+		snapshot, err := json.Marshal(contacts[i].ToResponse())
+		if err != nil {
+			return fmt.Errorf("failed to encode contact revision: %w", err)
+		}
+		rows[i] = models.ContactRevision{
+			ContactID: contacts[i].ID,
+			UserID:    contacts[i].UserID,
+			Action:    action,
+			Snapshot:  snapshot,
+		}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to record contact revision: %w", err)
+	}
+	return nil
+}
+
+// Restore recovers a soft-deleted contact by ID and user ID
+func (r *contactRepository) Restore(ctx context.Context, userID, contactID uint) error {
 	result := r.db.WithContext(ctx).
-		Where("id = ? AND user_id = ?", contactID, userID).
-		Delete(&models.Contact{})
+		Unscoped().
+		Model(&models.Contact{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", contactID, userID).
+		Update("deleted_at", nil)
 
 	if result.Error != nil {
-		return fmt.Errorf("failed to delete contact: %w", result.Error)
+		// Another active contact has taken the phone number in the meantime
+		if isDuplicateError(result.Error) {
+			return ErrDuplicatePhone
+		}
+		return fmt.Errorf("failed to restore contact: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return ErrNotFound
@@ -208,16 +737,26 @@ func (r *contactRepository) Delete(ctx context.Context, userID, contactID uint)
 
 // List retrieves contacts with pagination and filtering
 func (r *contactRepository) List(ctx context.Context, userID uint, req *models.ListContactsRequest) ([]models.Contact, int64, error) {
-	var contacts []models.Contact
-	var total int64
-
 	// Build base query
 	query := r.db.WithContext(ctx).Model(&models.Contact{}).Where("user_id = ?", userID)
 
+	// Include trashed contacts on request
+	if req.IncludeDeleted {
+		query = query.Unscoped()
+	}
+
+	// Incremental sync: contacts changed after the timestamp, with deletions as tombstones
+	if req.UpdatedSince != nil {
+		query = query.Unscoped().Where("updated_at > ? OR deleted_at > ?", *req.UpdatedSince, *req.UpdatedSince)
+	}
+
 	// Apply search filter
 	if req.Search != "" {
-		searchPattern := "%" + req.Search + "%"
-		query = query.Where("full_name LIKE ? OR phone LIKE ?", searchPattern, searchPattern)
+		if terms := fullTextQuery(req.Search); req.FullText && terms != "" {
+			query = query.Where("MATCH (full_name, email) AGAINST (? IN BOOLEAN MODE)", terms)
+		} else {
+			query = query.Where(contactSearchClause(req.SearchFields, req.Search))
+		}
 	}
 
 	// Apply favorite filter
@@ -225,31 +764,440 @@ func (r *contactRepository) List(ctx context.Context, userID uint, req *models.L
 		query = query.Where("favorite = ?", *req.Favorite)
 	}
 
-	// Count total records
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count contacts: %w", err)
+	// Apply tag filter
+	if req.Tag != "" {
+		tagged := r.db.Model(&models.ContactTag{}).Select("contact_id").Where("tag = ?", req.Tag)
+		query = query.Where("id IN (?)", tagged)
+	}
+
+	// Apply phone country filter
+	if req.Country != "" {
+		query = query.Where("phone_country = ?", req.Country)
+	}
+
+	// Order by the requested column, newest first by default. Without a count one extra
+	// row is fetched to tell whether a next page exists.
+	page := pageReq{
+		Page:      req.Page,
+		Limit:     req.Limit,
+		Order:     contactOrderClause(req.Sort, req.Order),
+		SkipCount: !req.CountsTotal(),
+		Name:      "contacts",
+		Scopes:    []func(*gorm.DB) *gorm.DB{withPhonesAndEmails},
 	}
 
-	// Apply pagination
-	offset := (req.Page - 1) * req.Limit
-	query = query.Offset(offset).Limit(req.Limit)
+	// A cursor resumes after the last seen contact so rows inserted meanwhile do not
+	// shift the page
+	if req.Cursor != "" {
+		createdAt, id, err := DecodeContactCursor(req.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		page.After = clause.Expr{SQL: "(created_at, id) < (?, ?)", Vars: []interface{}{createdAt, id}}
+	}
 
-	// Order by created_at DESC (newest first)
-	query = query.Order("created_at DESC")
+	contacts, total, err := paginate[models.Contact](query, page)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	// Execute query
-	if err := query.Find(&contacts).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to list contacts: %w", err)
+	if err := attachTags(r.db.WithContext(ctx), contacts); err != nil {
+		return nil, 0, err
 	}
 
 	return contacts, total, nil
 }
 
-// CheckPhoneExists checks if phone already exists for a user
+// ListAll retrieves all contacts of a user with their phones and emails ordered by name
+func (r *contactRepository) ListAll(ctx context.Context, userID uint) ([]models.Contact, error) {
+	var contacts []models.Contact
+	err := r.db.WithContext(ctx).
+		Scopes(withPhonesAndEmails).
+		Where("user_id = ?", userID).
+		Order("full_name ASC, id ASC").
+		Find(&contacts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	return contacts, nil
+}
+
+// ListInBatches calls fn with all contacts of a user and their tags, batchSize at a time.
+// Batches are read by ID rather than offset so each query stays cheap.
+func (r *contactRepository) ListInBatches(ctx context.Context, userID uint, batchSize int, fn func([]models.Contact) error) error {
+	var lastID uint
+	for {
+		var contacts []models.Contact
+		err := r.db.WithContext(ctx).
+			Scopes(withPhonesAndEmails).
+			Where("user_id = ? AND id > ?", userID, lastID).
+			Order("id ASC").
+			Limit(batchSize).
+			Find(&contacts).Error
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
+		if len(contacts) == 0 {
+			return nil
+		}
+
+		if err := attachTags(r.db.WithContext(ctx), contacts); err != nil {
+			return err
+		}
+		if err := fn(contacts); err != nil {
+			return err
+		}
+		if len(contacts) < batchSize {
+			return nil
+		}
+		lastID = contacts[len(contacts)-1].ID
+	}
+}
+
+// ListRecent retrieves the limit most recently created contacts of a user with their
+// tags, newest first. The order matches idx_contacts_user_created so only limit rows are read.
+func (r *contactRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]models.Contact, error) {
+	var contacts []models.Contact
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&contacts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent contacts: %w", err)
+	}
+
+	if err := attachTags(r.db.WithContext(ctx), contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// ListWithBirthday retrieves all contacts of a user that have a birthday set, with their tags
+func (r *contactRepository) ListWithBirthday(ctx context.Context, userID uint) ([]models.Contact, error) {
+	var contacts []models.Contact
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND birthday IS NOT NULL", userID).
+		Find(&contacts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts with birthday: %w", err)
+	}
+
+	if err := attachTags(r.db.WithContext(ctx), contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// Count returns how many (non-deleted) contacts a user has
+func (r *contactRepository) Count(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Contact{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count contacts: %w", err)
+	}
+	return count, nil
+}
+
+// Stats aggregates a user's (non-deleted) contacts with one COUNT query per figure
+func (r *contactRepository) Stats(ctx context.Context, userID uint, since time.Time) (*models.ContactStats, error) {
+	stats := &models.ContactStats{}
+	counts := []struct {
+		target    *int64
+		condition string
+		args      []interface{}
+	}{
+		{&stats.Total, "", nil},
+		{&stats.Favorites, "favorite = ?", []interface{}{true}},
+		{&stats.WithEmail, "email IS NOT NULL AND email <> ''", nil},
+		{&stats.NewThisWeek, "created_at >= ?", []interface{}{since}},
+	}
+
+	for _, count := range counts {
+		query := r.db.WithContext(ctx).Model(&models.Contact{}).Where("user_id = ?", userID)
+		if count.condition != "" {
+			query = query.Where(count.condition, count.args...)
+		}
+		if err := query.Count(count.target).Error; err != nil {
+			return nil, fmt.Errorf("failed to count contacts: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// replaceTags replaces all tags of a contact within tx
+func replaceTags(tx *gorm.DB, contactID uint, tags []string) error {
+	if err := tx.Where("contact_id = ?", contactID).Delete(&models.ContactTag{}).Error; err != nil {
+		return fmt.Errorf("failed to clear contact tags: %w", err)
+	}
+	return insertTags(tx, contactID, tags)
+}
+
+// insertTags adds tags to a contact within tx
+func insertTags(tx *gorm.DB, contactID uint, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]models.ContactTag, len(tags))
+	for i, tag := range tags {
+		rows[i] = models.ContactTag{ContactID: contactID, Tag: tag}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to set contact tags: %w", err)
+	}
+	return nil
+}
+
+// replacePhones replaces all phone numbers of a contact within tx
+func replacePhones(tx *gorm.DB, contactID uint, phones []models.ContactPhone) error {
+	if err := tx.Where("contact_id = ?", contactID).Delete(&models.ContactPhone{}).Error; err != nil {
+		return fmt.Errorf("failed to clear contact phones: %w", err)
+	}
+	if len(phones) == 0 {
+		return nil
+	}
+
+	rows := make([]models.ContactPhone, len(phones))
+	for i, phone := range phones {
+		rows[i] = models.ContactPhone{ContactID: contactID, Phone: phone.Phone, Label: phone.Label, IsPrimary: phone.IsPrimary}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to set contact phones: %w", err)
+	}
+	return nil
+}
+
+// replaceEmails replaces all email addresses of a contact within tx
+func replaceEmails(tx *gorm.DB, contactID uint, emails []models.ContactEmail) error {
+	if err := tx.Where("contact_id = ?", contactID).Delete(&models.ContactEmail{}).Error; err != nil {
+		return fmt.Errorf("failed to clear contact emails: %w", err)
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	rows := make([]models.ContactEmail, len(emails))
+	for i, email := range emails {
+		rows[i] = models.ContactEmail{ContactID: contactID, Email: email.Email, Label: email.Label, IsPrimary: email.IsPrimary}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to set contact emails: %w", err)
+	}
+	return nil
+}
+
+// primaryFirst orders preloaded phones or emails with the primary one first
+func primaryFirst(db *gorm.DB) *gorm.DB {
+	return db.Order("is_primary DESC, id ASC")
+}
+
+// withPhonesAndEmails preloads every phone and email of the queried contacts, so responses
+// built from them list the secondary ones too
+func withPhonesAndEmails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Phones", primaryFirst).Preload("Emails", primaryFirst)
+}
+
+// GetTags retrieves the tags of the given contacts keyed by contact ID
+func (r *contactRepository) GetTags(ctx context.Context, contactIDs []uint) (map[uint][]string, error) {
+	return contactTags(r.db.WithContext(ctx), contactIDs)
+}
+
+// contactTags loads the tags of the given contacts keyed by contact ID using db
+func contactTags(db *gorm.DB, contactIDs []uint) (map[uint][]string, error) {
+	tags := make(map[uint][]string, len(contactIDs))
+	if len(contactIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []models.ContactTag
+	err := db.
+		Where("contact_id IN ?", contactIDs).
+		Order("tag ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact tags: %w", err)
+	}
+
+	for _, row := range rows {
+		tags[row.ContactID] = append(tags[row.ContactID], row.Tag)
+	}
+	return tags, nil
+}
+
+// attachTags loads tags for the given contacts in one query
+func attachTags(db *gorm.DB, contacts []models.Contact) error {
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(contacts))
+	for i := range contacts {
+		ids[i] = contacts[i].ID
+	}
+
+	tags, err := contactTags(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range contacts {
+		contacts[i].Tags = tags[contacts[i].ID]
+	}
+	return nil
+}
+
+// contactSortColumns is the allowlist of columns contacts can be sorted by.
+// Sort input is never interpolated into SQL unless it is listed here.
+var contactSortColumns = map[string]bool{
+	"full_name":  true,
+	"created_at": true,
+	"favorite":   true,
+	"phone":      true,
+}
+
+// IsValidContactSort reports whether contacts can be sorted by the given field
+func IsValidContactSort(field string) bool {
+	return contactSortColumns[field]
+}
+
+// contactSearchColumns lists the columns contacts can be searched by, in search order
+var contactSearchColumns = []string{"full_name", "phone", "email"}
+
+// IsValidContactSearchField reports whether contacts can be searched by the given field
+func IsValidContactSearchField(field string) bool {
+	for _, column := range contactSearchColumns {
+		if column == field {
+			return true
+		}
+	}
+	return false
+}
+
+// contactSearchClause builds a LIKE condition for search over the requested search
+// fields, or over all searchable columns when none of the fields are searchable.
+// Names are matched on full_name_normalized with a normalized term, so "jose" finds
+// "José". A NULL email never matches LIKE, so contacts without an email are simply
+// not matched on that column.
+func contactSearchClause(fields []string, search string) clause.Expression {
+	requested := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		requested[field] = true
+	}
+
+	var conditions []clause.Expression
+	for _, column := range contactSearchColumns {
+		if requested[column] {
+			conditions = append(conditions, contactSearchCondition(column, search))
+		}
+	}
+	if len(conditions) == 0 {
+		for _, column := range contactSearchColumns {
+			conditions = append(conditions, contactSearchCondition(column, search))
+		}
+	}
+	return clause.Or(conditions...)
+}
+
+// contactSearchCondition matches search anywhere in a searchable column
+func contactSearchCondition(column, search string) clause.Expression {
+	if column == "full_name" {
+		return clause.Like{Column: clause.Column{Name: "full_name_normalized"}, Value: "%" + normalizeSearchTerm(search) + "%"}
+	}
+	return clause.Like{Column: clause.Column{Name: column}, Value: "%" + search + "%"}
+}
+
+// normalizeSearchTerm lowercases a name search term and folds its diacritics, e.g.
+// "José" becomes "jose", so it compares consistently with full_name_normalized
+func normalizeSearchTerm(term string) string {
+	// Transformer chains keep state, so one is built per call
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, term)
+	if err != nil {
+		folded = term
+	}
+	return strings.ToLower(folded)
+}
+
+// fullTextMinWordLength is InnoDB's default innodb_ft_min_token_size; shorter words
+// are not indexed and can never match
+const fullTextMinWordLength = 3
+
+// fullTextQuery turns a search into a boolean mode query requiring every word as a
+// prefix, e.g. "john smi" becomes "+john* +smi*". Operators typed by the user are
+// dropped. It returns "" when a word is too short for the index, so the caller can
+// fall back to LIKE.
+func fullTextQuery(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		if utf8.RuneCountInString(word) < fullTextMinWordLength {
+			return ""
+		}
+		terms[i] = "+" + word + "*"
+	}
+	return strings.Join(terms, " ")
+}
+
+// contactOrderClause builds a safe ORDER BY clause, falling back to created_at DESC
+func contactOrderClause(sort, order string) string {
+	if !IsValidContactSort(sort) {
+		sort = "created_at"
+	}
+	direction := "DESC"
+	if strings.EqualFold(order, "asc") {
+		direction = "ASC"
+	}
+	if sort == "created_at" {
+		// Tie-break on id so keyset cursors follow the same order
+		return "created_at " + direction + ", id " + direction
+	}
+	// Tie-break on created_at so pagination is stable for non-unique columns
+	return sort + " " + direction + ", created_at DESC"
+}
+
+// EncodeContactCursor returns an opaque keyset cursor pointing after contact
+func EncodeContactCursor(contact *models.Contact) string {
+	raw := contact.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatUint(uint64(contact.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeContactCursor extracts the created_at and id encoded by EncodeContactCursor
+func DecodeContactCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	createdAtPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtPart)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil || id == 0 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return createdAt, uint(id), nil
+}
+
+// CheckPhoneExists checks if phone already exists as the primary or any other number of
+// a user's contacts
 func (r *contactRepository) CheckPhoneExists(ctx context.Context, userID uint, phone string, excludeContactID uint) (bool, error) {
 	var count int64
+	numbers := r.db.Model(&models.ContactPhone{}).Select("contact_id").Where("phone = ?", phone)
 	query := r.db.WithContext(ctx).Model(&models.Contact{}).
-		Where("user_id = ? AND phone = ?", userID, phone)
+		Where("user_id = ?", userID).
+		Where("phone = ? OR id IN (?)", phone, numbers)
 
 	if excludeContactID > 0 {
 		query = query.Where("id != ?", excludeContactID)
@@ -262,31 +1210,175 @@ func (r *contactRepository) CheckPhoneExists(ctx context.Context, userID uint, p
 	return count > 0, nil
 }
 
-// isDuplicateError checks if error is a duplicate entry error
-func isDuplicateError(err error) bool {
-	if err == nil {
-		return false
+// refreshTokenRepository implements RefreshTokenRepository interface
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new RefreshTokenRepository instance
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create stores a newly issued refresh token
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
 	}
-	errMsg := err.Error()
-	// MySQL duplicate entry error
-	return contains(errMsg, "Duplicate entry") ||
-		contains(errMsg, "duplicate key") ||
-		contains(errMsg, "UNIQUE constraint failed")
+	return nil
 }
 
-// contains checks if string contains substring (case-insensitive helper)
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		(s == substr || len(s) > len(substr) &&
-			(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-				containsInner(s, substr)))
+// GetByJTI retrieves a refresh token by its JWT ID
+func (r *refreshTokenRepository) GetByJTI(ctx context.Context, jti string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
 }
 
-func containsInner(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
+// Revoke marks a refresh token as revoked
+func (r *refreshTokenRepository) Revoke(ctx context.Context, jti string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("jti = ? AND revoked_at IS NULL", jti).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// sessionRepository implements SessionRepository interface
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new SessionRepository instance
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create stores a newly started session
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetByJTI retrieves a session by its ID
+func (r *sessionRepository) GetByJTI(ctx context.Context, jti string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
 		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
-	return false
+	return &session, nil
+}
+
+// ListActive retrieves a user's unrevoked, unexpired sessions, most recently used first
+func (r *sessionRepository) ListActive(ctx context.Context, userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC, id DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// Touch records that a session was refreshed and extends its expiry
+func (r *sessionRepository) Touch(ctx context.Context, jti string, expiresAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("jti = ? AND revoked_at IS NULL", jti).
+		Updates(map[string]interface{}{"last_used_at": time.Now(), "expires_at": expiresAt})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to touch session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Revoke ends one of a user's active sessions
+func (r *sessionRepository) Revoke(ctx context.Context, userID uint, jti string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("jti = ? AND user_id = ? AND revoked_at IS NULL", jti, userID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// auditLogRepository implements AuditLogRepository interface
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository instance
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create stores an audit log entry
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// ListByUser retrieves a user's audit log entries, newest first
+func (r *auditLogRepository) ListByUser(ctx context.Context, userID uint, page, limit int) ([]models.AuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where("user_id = ?", userID)
+	return paginate[models.AuditLog](query, pageReq{
+		Page:  page,
+		Limit: limit,
+		Order: "created_at DESC,id DESC",
+		Name:  "audit logs",
+	})
+}
+
+// mysqlErrDuplicateEntry is the MySQL error number for unique key violations (ER_DUP_ENTRY)
+const mysqlErrDuplicateEntry = 1062
+
+// isDuplicateError checks if error is a duplicate entry error
+func isDuplicateError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDuplicateEntry
+	}
+
+	// Fall back to the message for other drivers (e.g. SQLite)
+	errMsg := strings.ToLower(err.Error())
+	return strings.Contains(errMsg, "duplicate entry") ||
+		strings.Contains(errMsg, "duplicate key") ||
+		strings.Contains(errMsg, "unique constraint failed")
 }
@@ -2,15 +2,21 @@ package repository
 
 import (
 	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"user-service/internal/app/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	gomysql "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
@@ -34,6 +40,14 @@ func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
 	return gormDB, mock, cleanup
 }
 
+// expectNoPhonesOrEmails expects the phone and email preloads of contacts that have none
+func expectNoPhonesOrEmails(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone"}))
+}
+
 func TestUserRepository_Create(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -44,7 +58,7 @@ func TestUserRepository_Create(t *testing.T) {
 	user := &models.User{
 		FullName: "John Doe",
 		Email:    "john@example.com",
-		Phone:    "1234567890",
+		Phone:    strPtr("1234567890"),
 		Password: "hashedpassword",
 	}
 
@@ -58,6 +72,49 @@ func TestUserRepository_Create(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepository_CreateDuplicateEmail(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{FullName: "John Doe", Email: "john@example.com", Password: "hashedpassword"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `users`").
+		WillReturnError(&gomysql.MySQLError{
+			Number:  1062,
+			Message: "Duplicate entry 'john@example.com' for key 'users.idx_users_email'",
+		})
+	mock.ExpectRollback()
+
+	err := repo.Create(ctx, user)
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsDuplicateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"mysql duplicate entry", &gomysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-0812' for key 'idx_contacts_user_phone'"}, true},
+		{"wrapped mysql duplicate entry", fmt.Errorf("insert: %w", &gomysql.MySQLError{Number: 1062}), true},
+		{"other mysql error", &gomysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, false},
+		{"sqlite unique constraint", errors.New("UNIQUE constraint failed: users.email"), true},
+		{"unrelated error", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDuplicateError(tt.err))
+		})
+	}
+}
+
 func TestUserRepository_GetByID(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -70,7 +127,7 @@ func TestUserRepository_GetByID(t *testing.T) {
 		ID:        1,
 		FullName:  "John Doe",
 		Email:     "john@example.com",
-		Phone:     "1234567890",
+		Phone:     strPtr("1234567890"),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -79,7 +136,7 @@ func TestUserRepository_GetByID(t *testing.T) {
 		AddRow(expectedUser.ID, expectedUser.FullName, expectedUser.Email, expectedUser.Phone, expectedUser.CreatedAt, expectedUser.UpdatedAt)
 
 	mock.ExpectQuery("SELECT \\* FROM `users` WHERE `users`.`id` = \\? AND `users`.`deleted_at` IS NULL").
-		WithArgs(1).
+		WithArgs(1, 1).
 		WillReturnRows(rows)
 
 	user, err := repo.GetByID(ctx, 1)
@@ -101,7 +158,7 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 		ID:        1,
 		FullName:  "John Doe",
 		Email:     "john@example.com",
-		Phone:     "1234567890",
+		Phone:     strPtr("1234567890"),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -110,7 +167,7 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 		AddRow(expectedUser.ID, expectedUser.FullName, expectedUser.Email, expectedUser.Phone, expectedUser.CreatedAt, expectedUser.UpdatedAt)
 
 	mock.ExpectQuery("SELECT \\* FROM `users` WHERE email = \\? AND `users`.`deleted_at` IS NULL").
-		WithArgs("john@example.com").
+		WithArgs("john@example.com", 1).
 		WillReturnRows(rows)
 
 	user, err := repo.GetByEmail(ctx, "john@example.com")
@@ -120,6 +177,266 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepository_UpdateClearsOptionalFields(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com", Password: "hashedpassword"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `users` SET .*`phone`=\\?.*`avatar_url`=\\?.* WHERE `users`.`deleted_at` IS NULL AND `id` = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Update(ctx, user, "full_name", "phone", "avatar_url")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_UpdateOnlyGivenColumns(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// A stale copy must not write back the columns another request changed meanwhile
+	user := &models.User{ID: 1, FullName: "Stale Name", Email: "john@example.com", Password: "newhash", Role: models.RoleUser}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE `users` SET `password`=\\?,`updated_at`=\\? WHERE `users`.`deleted_at` IS NULL AND `id` = \\?$").
+		WithArgs("newhash", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Update(ctx, user, "password"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Error(t, repo.Update(ctx, user))
+}
+
+func TestUserRepository_SetActive(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `users` SET `deactivated_at`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `users` SET `deactivated_at`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs(nil, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.SetActive(ctx, 1, false))
+	assert.NoError(t, repo.SetActive(ctx, 1, true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_Purge(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+
+	contactIDs := "\\(SELECT `id` FROM `contacts` WHERE user_id = \\?\\)"
+	mock.ExpectBegin()
+	for _, table := range []string{"contact_tags", "contact_phones", "contact_emails", "contact_revisions"} {
+		mock.ExpectExec("^DELETE FROM `" + table + "` WHERE contact_id IN " + contactIDs + "$").
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+	// Soft-deleted contacts are removed as well
+	for _, table := range []string{"contacts", "refresh_tokens", "sessions", "audit_logs"} {
+		mock.ExpectExec("^DELETE FROM `" + table + "` WHERE user_id = \\?$").
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+	mock.ExpectExec("^DELETE FROM `users` WHERE `users`.`id` = \\?$").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Purge(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_PurgeNotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	for i := 0; i < 8; i++ {
+		mock.ExpectExec("^DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec("^DELETE FROM `users`").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Purge(context.Background(), 7)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_SetLastSeen(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	seen := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	// updated_at is left alone
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE `users` SET `last_seen_at`=\\? WHERE id = \\? AND `users`.`deleted_at` IS NULL$").
+		WithArgs(seen, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.SetLastSeen(context.Background(), 1, seen))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_SetRole(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `users` SET `role`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs("admin", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `users` SET `role`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs("admin", sqlmock.AnyArg(), 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.SetRole(ctx, 1, "admin"))
+	assert.ErrorIs(t, repo.SetRole(ctx, 99, "admin"), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE `users` SET `deleted_at`=\\? WHERE `users`.`id` = \\? AND `users`.`deleted_at` IS NULL$").
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Deleted users no longer block their email from registering again
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `users` WHERE email = \\? AND `users`.`deleted_at` IS NULL$").
+		WithArgs("john@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	assert.NoError(t, repo.Delete(ctx, 1))
+	exists, err := repo.CheckEmailExists(ctx, "john@example.com", 0)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_Restore(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	restoreSQL := "^UPDATE `users` SET `deleted_at`=\\?,`updated_at`=\\? WHERE id = \\? AND deleted_at IS NOT NULL$"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(restoreSQL).
+		WithArgs(nil, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.Restore(ctx, 1))
+
+	// Not deleted, or no such user
+	mock.ExpectBegin()
+	mock.ExpectExec(restoreSQL).
+		WithArgs(nil, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.ErrorIs(t, repo.Restore(ctx, 2), ErrNotFound)
+
+	// The email was registered again while the user was deleted
+	mock.ExpectBegin()
+	mock.ExpectExec(restoreSQL).
+		WithArgs(nil, sqlmock.AnyArg(), 3).
+		WillReturnError(&gomysql.MySQLError{
+			Number:  1062,
+			Message: "Duplicate entry 'john@example.com' for key 'users.idx_users_active_email'",
+		})
+	mock.ExpectRollback()
+	assert.ErrorIs(t, repo.Restore(ctx, 3), ErrDuplicateEmail)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_List(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	t.Run("search by name or email", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE \\(`full_name` LIKE \\? OR `email` LIKE \\?\\) AND `users`.`deleted_at` IS NULL").
+			WithArgs("%jane%", "%jane%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		rows := sqlmock.NewRows([]string{"id", "full_name", "email"}).
+			AddRow(2, "Jane Doe", "jane@example.com")
+		mock.ExpectQuery("SELECT \\* FROM `users` WHERE \\(`full_name` LIKE \\? OR `email` LIKE \\?\\) .* ORDER BY created_at DESC,id DESC LIMIT \\?").
+			WithArgs("%jane%", "%jane%", 10).
+			WillReturnRows(rows)
+
+		users, total, err := repo.List(ctx, &models.ListUsersRequest{Page: 1, Limit: 10, Search: "jane"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "jane@example.com", users[0].Email)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("later page is offset", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE `users`.`deleted_at` IS NULL").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(25))
+
+		mock.ExpectQuery("SELECT \\* FROM `users` WHERE `users`.`deleted_at` IS NULL ORDER BY created_at DESC,id DESC LIMIT \\? OFFSET \\?").
+			WithArgs(10, 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "email"}))
+
+		users, total, err := repo.List(ctx, &models.ListUsersRequest{Page: 3, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(25), total)
+		assert.Empty(t, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestContactRepository_List(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -136,8 +453,8 @@ func TestContactRepository_List(t *testing.T) {
 	}
 
 	// Mock count query
-	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts`").
-		WithArgs(1, "%John%", "%John%", true).
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND \\(`full_name_normalized` LIKE \\? OR `phone` LIKE \\? OR `email` LIKE \\?\\)").
+		WithArgs(1, "%john%", "%John%", "%John%", true).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
 	// Mock select query
@@ -145,119 +462,1458 @@ func TestContactRepository_List(t *testing.T) {
 		AddRow(1, 1, "John Doe", "1234567890", "john@example.com", true, time.Now(), time.Now()).
 		AddRow(2, 1, "John Smith", "0987654321", "smith@example.com", true, time.Now(), time.Now())
 
-	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE user_id = \\?").
-		WithArgs(1, "%John%", "%John%", true, 10, 0).
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE user_id = \\?.* ORDER BY created_at DESC, id DESC LIMIT \\?").
+		WithArgs(1, "%john%", "%John%", "%John%", true, 10).
 		WillReturnRows(rows)
 
+	// Mock phone, email and tags queries
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}).
+			AddRow(1, 1, "family").
+			AddRow(2, 1, "work"))
+
 	contacts, total, err := repo.List(ctx, 1, req)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), total)
 	assert.Len(t, contacts, 2)
+	assert.Equal(t, []string{"family", "work"}, contacts[0].Tags)
+	assert.Empty(t, contacts[1].Tags)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestContactRepository_Create(t *testing.T) {
+func TestContactRepository_ListByEmail(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
 	repo := NewContactRepository(db)
 	ctx := context.Background()
 
-	email := "jane@example.com"
-	contact := &models.Contact{
-		UserID:   1,
-		FullName: "Jane Doe",
-		Phone:    "1234567890",
-		Email:    &email,
-		Favorite: false,
+	req := &models.ListContactsRequest{
+		Page:         1,
+		Limit:        10,
+		Search:       "example.co",
+		SearchFields: []string{"email"},
 	}
 
-	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO `contacts`").
-		WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND `email` LIKE \\?").
+		WithArgs(1, "%example.co%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone", "email"}).
+		AddRow(1, 1, "John Doe", "1234567890", "john@example.com")
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE user_id = \\? AND `email` LIKE \\?").
+		WithArgs(1, "%example.co%", 10).
+		WillReturnRows(rows)
 
-	err := repo.Create(ctx, contact)
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, total, err := repo.List(ctx, 1, req)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, contacts, 1)
+	assert.Equal(t, "john@example.com", *contacts[0].Email)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestContactRepository_GetByID(t *testing.T) {
+func TestContactRepository_ListSorted(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
 	repo := NewContactRepository(db)
 	ctx := context.Background()
 
-	now := time.Now()
-	email := "jane@example.com"
-	expectedContact := &models.Contact{
-		ID:        1,
-		UserID:    1,
-		FullName:  "Jane Doe",
-		Phone:     "1234567890",
-		Email:     &email,
-		Favorite:  false,
-		CreatedAt: now,
-		UpdatedAt: now,
+	req := &models.ListContactsRequest{
+		Page:  2,
+		Limit: 10,
+		Sort:  "full_name",
+		Order: "asc",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone", "email", "favorite", "created_at", "updated_at"}).
-		AddRow(expectedContact.ID, expectedContact.UserID, expectedContact.FullName, expectedContact.Phone, expectedContact.Email, expectedContact.Favorite, expectedContact.CreatedAt, expectedContact.UpdatedAt)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
 
-	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE id = \\? AND user_id = \\? AND `contacts`.`deleted_at` IS NULL").
-		WithArgs(1, 1).
-		WillReturnRows(rows)
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE user_id = \\? AND `contacts`.`deleted_at` IS NULL ORDER BY full_name ASC, created_at DESC LIMIT \\? OFFSET \\?").
+		WithArgs(1, 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(11, 1, "Zed", "1234567890"))
 
-	contact, err := repo.GetByID(ctx, 1, 1)
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, total, err := repo.List(ctx, 1, req)
 	assert.NoError(t, err)
-	assert.NotNil(t, contact)
-	assert.Equal(t, expectedContact.Phone, contact.Phone)
+	assert.Equal(t, int64(12), total)
+	assert.Len(t, contacts, 1)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestContactRepository_Update(t *testing.T) {
+func TestContactRepository_ListByTag(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
 	repo := NewContactRepository(db)
 	ctx := context.Background()
 
-	email := "jane.updated@example.com"
-	contact := &models.Contact{
-		ID:       1,
-		UserID:   1,
-		FullName: "Jane Doe Updated",
-		Phone:    "9999999999",
-		Email:    &email,
-		Favorite: true,
-	}
+	req := &models.ListContactsRequest{Page: 1, Limit: 10, Tag: "work"}
 
-	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE `contacts`").
-		WithArgs(sqlmock.AnyArg(), contact.FullName, contact.Phone, contact.Email, contact.Favorite, contact.ID, contact.UserID).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND id IN \\(SELECT `contact_id` FROM `contact_tags` WHERE tag = \\?\\) AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(1, "work").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE user_id = \\? AND id IN \\(SELECT `contact_id` FROM `contact_tags` WHERE tag = \\?\\) AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(1, "work", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(3, 1, "Jane Doe", "1234567890"))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}).
+			AddRow(1, 3, "family").
+			AddRow(2, 3, "work"))
 
-	err := repo.Update(ctx, contact)
+	contacts, total, err := repo.List(ctx, 1, req)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, contacts, 1)
+	assert.Equal(t, []string{"family", "work"}, contacts[0].Tags)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestContactRepository_Delete(t *testing.T) {
+func TestPaginate(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	query := func() *gorm.DB {
+		return db.Model(&models.AuditLog{}).Where("user_id = ?", 1)
+	}
+	page := pageReq{Page: 3, Limit: 20, Order: "id DESC", Name: "audit logs"}
+
+	t.Run("counts and offsets", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `audit_logs` WHERE user_id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(45))
+		mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE user_id = \\? ORDER BY id DESC LIMIT \\? OFFSET \\?$").
+			WithArgs(1, 20, 40).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(5, 1))
+
+		rows, total, err := paginate[models.AuditLog](query(), page)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(45), total)
+		assert.Len(t, rows, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("clamps page and limit", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `audit_logs`").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE user_id = \\? ORDER BY id DESC LIMIT \\?$").
+			WithArgs(1, defaultPageLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+		rows, total, err := paginate[models.AuditLog](query(), pageReq{Page: -1, Order: "id DESC", Name: "audit logs"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, rows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("skips the count and fetches one extra row", func(t *testing.T) {
+		skip := page
+		skip.SkipCount = true
+		mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE user_id = \\? ORDER BY id DESC LIMIT \\? OFFSET \\?$").
+			WithArgs(1, 21, 40).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+		_, total, err := paginate[models.AuditLog](query(), skip)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-1), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keyset condition replaces the offset", func(t *testing.T) {
+		after := page
+		after.After = clause.Expr{SQL: "id < ?", Vars: []interface{}{100}}
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `audit_logs` WHERE user_id = \\?$").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(45))
+		mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE user_id = \\? AND id < \\? ORDER BY id DESC LIMIT \\?$").
+			WithArgs(1, 100, 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+		_, total, err := paginate[models.AuditLog](query(), after)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(45), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("names the listing in errors", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `audit_logs`").
+			WillReturnError(errors.New("connection lost"))
+
+		_, _, err := paginate[models.AuditLog](query(), page)
+		assert.EqualError(t, err, "failed to count audit logs: connection lost")
+	})
+}
+
+func TestContactRepository_ListRecent(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
 	repo := NewContactRepository(db)
 	ctx := context.Background()
 
-	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE `contacts` SET `deleted_at`").
-		WithArgs(sqlmock.AnyArg(), 1, 1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND `contacts`.`deleted_at` IS NULL ORDER BY created_at DESC, id DESC LIMIT \\?$").
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(7, 1, "Newest", "081234567897").
+			AddRow(6, 1, "Older", "081234567896"))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?,\\?\\)").
+		WithArgs(7, 6).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, err := repo.ListRecent(ctx, 1, 5)
+	assert.NoError(t, err)
+	if assert.Len(t, contacts, 2) {
+		assert.Equal(t, uint(7), contacts[0].ID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_ListByCountry(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	req := &models.ListContactsRequest{Page: 1, Limit: 10, Country: "SG"}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND phone_country = \\? AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(1, "SG").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE user_id = \\? AND phone_country = \\? AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(1, "SG", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone", "phone_country"}).
+			AddRow(4, 1, "Wei Ling", "+6591234567", "SG"))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, total, err := repo.List(ctx, 1, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, contacts, 1) && assert.NotNil(t, contacts[0].PhoneCountry) {
+		assert.Equal(t, "SG", *contacts[0].PhoneCountry)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_Stats(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND favorite = \\?").
+		WithArgs(1, true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND \\(email IS NOT NULL AND email <> ''\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND created_at >= \\?").
+		WithArgs(1, since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	stats, err := repo.Stats(ctx, 1, since)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ContactStats{Total: 10, Favorites: 3, WithEmail: 7, NewThisWeek: 2}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_CreateWithTags(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	contact := &models.Contact{UserID: 1, FullName: "Jane Doe", Phone: "1234567890", Tags: []string{"family", "work"}}
+
+	// The tags are written in the contact's transaction
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `contacts`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `contact_tags`").
+		WithArgs(1, "family", sqlmock.AnyArg(), 1, "work", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	err := repo.Create(ctx, contact, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_CreateWithLimit(t *testing.T) {
+	t.Run("below the limit", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := NewContactRepository(db)
+
+		// The user's row stays locked until the contact is inserted
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT `id` FROM `users` WHERE id = \\? AND `users`.`deleted_at` IS NULL LIMIT \\? FOR UPDATE").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec("INSERT INTO `contacts`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Create(context.Background(), &models.Contact{UserID: 1, FullName: "Jane", Phone: "081234567890"}, 3)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("at or above the limit", func(t *testing.T) {
+		for _, count := range []int{3, 5} {
+			db, mock, cleanup := setupMockDB(t)
+
+			repo := NewContactRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT `id` FROM `users`").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts`").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+			mock.ExpectRollback()
+
+			err := repo.Create(context.Background(), &models.Contact{UserID: 1, FullName: "Jane", Phone: "081234567890"}, 3)
+			assert.ErrorIs(t, err, ErrLimitReached)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			cleanup()
+		}
+	})
+}
+
+func TestContactRepository_UpdateReplacesTags(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	contact := &models.Contact{ID: 1, UserID: 1, FullName: "Jane", Phone: "1234567890", Tags: []string{"family", "work"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `contact_tags` WHERE contact_id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_tags`").
+		WithArgs(1, "family", sqlmock.AnyArg(), 1, "work", sqlmock.AnyArg()).
+		WillReturnError(errors.New("connection lost"))
+	// The contact row is not left updated without its tags
+	mock.ExpectRollback()
+
+	err := repo.Update(context.Background(), contact, ContactChildren{Tags: contact.Tags})
+	assert.ErrorContains(t, err, "failed to set contact tags")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_UpdateReplacesChildren(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	contact := &models.Contact{ID: 1, UserID: 1, FullName: "Jane", Phone: "081234567890"}
+
+	// The phones and emails are replaced in the update's transaction, before its revision
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `contact_phones` WHERE contact_id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_phones`").
+		WithArgs(1, "081234567890", "", true, sqlmock.AnyArg(), 1, "089876543210", "work", false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("DELETE FROM `contact_emails` WHERE contact_id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Update(ctx, contact, ContactChildren{
+		Phones: []models.ContactPhone{
+			{Phone: "081234567890", IsPrimary: true},
+			{Phone: "089876543210", Label: "work"},
+		},
+		Emails: []models.ContactEmail{},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_UpdateChildrenFailureRollsBack(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	contact := &models.Contact{ID: 1, UserID: 1, FullName: "Jane", Phone: "081234567890"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `contact_phones`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_phones`").
+		WillReturnError(errors.New("connection lost"))
+	// The contact row is not left updated without its phones
+	mock.ExpectRollback()
+
+	err := repo.Update(context.Background(), contact, ContactChildren{
+		Phones: []models.ContactPhone{{Phone: "081234567890", IsPrimary: true}},
+	})
+	assert.ErrorContains(t, err, "failed to set contact phones")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_CheckPhoneExists(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	// A secondary number of another contact counts as taken
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND \\(phone = \\? OR id IN \\(SELECT `contact_id` FROM `contact_phones` WHERE phone = \\?\\)\\) AND id != \\?").
+		WithArgs(1, "089876543210", "089876543210", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	exists, err := repo.CheckPhoneExists(context.Background(), 1, "089876543210", 2)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactOrderClause(t *testing.T) {
+	tests := []struct {
+		sort, order, expected string
+	}{
+		{"", "", "created_at DESC, id DESC"},
+		{"created_at", "asc", "created_at ASC, id ASC"},
+		{"full_name", "asc", "full_name ASC, created_at DESC"},
+		{"favorite", "DESC", "favorite DESC, created_at DESC"},
+		{"phone", "", "phone DESC, created_at DESC"},
+		{"password", "asc", "created_at ASC, id ASC"},
+		{"id; DROP TABLE contacts", "desc", "created_at DESC, id DESC"},
+		{"full_name", "asc; DROP TABLE contacts", "full_name DESC, created_at DESC"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, contactOrderClause(tt.sort, tt.order), "sort=%q order=%q", tt.sort, tt.order)
+	}
+}
+
+func TestContactRepository_Create(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	email := "jane@example.com"
+	contact := &models.Contact{
+		UserID:   1,
+		FullName: "Jane Doe",
+		Phone:    "1234567890",
+		Email:    &email,
+		Favorite: false,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `contacts`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Create(ctx, contact, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_GetByID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	email := "jane@example.com"
+	expectedContact := &models.Contact{
+		ID:        1,
+		UserID:    1,
+		FullName:  "Jane Doe",
+		Phone:     "1234567890",
+		Email:     &email,
+		Favorite:  false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone", "email", "favorite", "created_at", "updated_at"}).
+		AddRow(expectedContact.ID, expectedContact.UserID, expectedContact.FullName, expectedContact.Phone, expectedContact.Email, expectedContact.Favorite, expectedContact.CreatedAt, expectedContact.UpdatedAt)
+
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE id = \\? AND user_id = \\? AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(1, 1).
+		WillReturnRows(rows)
+
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails` WHERE `contact_emails`.`contact_id` = \\? ORDER BY is_primary DESC, id ASC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email", "label", "is_primary"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones` WHERE `contact_phones`.`contact_id` = \\? ORDER BY is_primary DESC, id ASC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone", "label", "is_primary"}).
+			AddRow(1, 1, expectedContact.Phone, "", true).
+			AddRow(2, 1, "0987654321", "work", false))
+
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}).AddRow(1, 1, "work"))
+
+	contact, err := repo.GetByID(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, contact)
+	assert.Equal(t, expectedContact.Phone, contact.Phone)
+	assert.Equal(t, []string{"work"}, contact.Tags)
+	assert.Len(t, contact.Phones, 2)
+	assert.True(t, contact.Phones[0].IsPrimary)
+	assert.Equal(t, "work", contact.Phones[1].Label)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_GetByPhone_SecondaryNumber(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	// The number is only a secondary phone of the contact, found through contact_phones
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND \\(phone IN \\(\\?,\\?\\) OR id IN \\(SELECT `contact_id` FROM `contact_phones` WHERE phone IN \\(\\?,\\?\\)\\)\\)\\) AND `contacts`.`deleted_at` IS NULL ORDER BY id ASC LIMIT \\?$").
+		WithArgs(1, "082222222222", "+6282222222222", "082222222222", "+6282222222222", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(3, 1, "Jane Doe", "081234567890"))
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails` WHERE `contact_emails`.`contact_id` = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones` WHERE `contact_phones`.`contact_id` = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone", "label", "is_primary"}).
+			AddRow(5, 3, "081234567890", "", true).
+			AddRow(6, 3, "082222222222", "home", false))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contact, err := repo.GetByPhone(context.Background(), 1, []string{"082222222222", "+6282222222222"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, contact) {
+		assert.Equal(t, uint(3), contact.ID)
+		assert.Len(t, contact.Phones, 2)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNormalizeSearchTerm(t *testing.T) {
+	tests := map[string]string{
+		"José":    "jose",
+		"JOHN":    "john",
+		"Zoë Ågé": "zoe age",
+		"Müller":  "muller",
+		"0812":    "0812",
+	}
+	for term, want := range tests {
+		assert.Equal(t, want, normalizeSearchTerm(term), term)
+	}
+}
+
+func TestContactRepository_ListAccentInsensitive(t *testing.T) {
+	columns := []string{"id", "user_id", "full_name", "phone"}
+
+	// The term is folded on the way in and the stored name through full_name_normalized,
+	// so the plain and the accented spelling run the same query and both find "José"
+	for _, search := range []string{"jose", "José"} {
+		t.Run(search, func(t *testing.T) {
+			db, mock, cleanup := setupMockDB(t)
+			defer cleanup()
+
+			repo := NewContactRepository(db)
+
+			mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND `full_name_normalized` LIKE \\?").
+				WithArgs(1, "%jose%").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND `full_name_normalized` LIKE \\?").
+				WithArgs(1, "%jose%", 10).
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 1, "José Rizal", "081234567890"))
+			expectNoPhonesOrEmails(mock)
+			mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+			req := &models.ListContactsRequest{Page: 1, Limit: 10, Search: search, SearchFields: []string{"full_name"}}
+			contacts, total, err := repo.List(context.Background(), 1, req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), total)
+			if assert.Len(t, contacts, 1) {
+				assert.Equal(t, "José Rizal", contacts[0].FullName)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestContactRepository_Update(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	email := "jane.updated@example.com"
+	contact := &models.Contact{
+		ID:       1,
+		UserID:   1,
+		FullName: "Jane Doe Updated",
+		Phone:    "9999999999",
+		Email:    &email,
+		Favorite: true,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts`").
+		WithArgs(contact.FullName, contact.Phone, nil, false, contact.Email, contact.Favorite, nil, nil, sqlmock.AnyArg(), contact.ID, contact.UserID, contact.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WithArgs(contact.ID, contact.UserID, models.RevisionActionUpdate, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Update(ctx, contact, ContactChildren{})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// snapshotArg matches a revision snapshot holding the given full name
+type snapshotArg struct {
+	fullName string
+}
+
+func (a snapshotArg) Match(v driver.Value) bool {
+	raw, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var snapshot models.ContactResponse
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return false
+	}
+	return snapshot.FullName == a.fullName
+}
+
+func TestContactRepository_UpdateRecordsOneRevision(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	contact := &models.Contact{ID: 1, UserID: 1, FullName: "Jane Updated", Phone: "1234567890", Tags: []string{"work"}}
+
+	// The revision is written in the update's transaction with the new state, and only once:
+	// sqlmock fails on any statement that was not expected
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_revisions` \\(`contact_id`,`user_id`,`action`,`snapshot`,`created_at`\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)$").
+		WithArgs(1, 1, models.RevisionActionUpdate, snapshotArg{fullName: "Jane Updated"}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Update(context.Background(), contact, ContactChildren{})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_UpdateNotFoundRecordsNoRevision(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Update(context.Background(), &models.Contact{ID: 1, UserID: 1, FullName: "Jane"}, ContactChildren{})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_ListRevisions(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	// Not joined to contacts, so revisions of a soft-deleted contact are still returned
+	mock.ExpectQuery("SELECT \\* FROM `contact_revisions` WHERE user_id = \\? AND contact_id = \\? ORDER BY id DESC$").
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "user_id", "action", "snapshot"}).
+			AddRow(2, 5, 1, models.RevisionActionDelete, []byte(`{"id":5}`)).
+			AddRow(1, 5, 1, models.RevisionActionUpdate, []byte(`{"id":5}`)))
+
+	revisions, err := repo.ListRevisions(context.Background(), 1, 5)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, models.RevisionActionDelete, revisions[0].Action)
+		assert.JSONEq(t, `{"id":5}`, string(revisions[0].Snapshot))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_DuplicatePhone(t *testing.T) {
+	duplicateErr := &gomysql.MySQLError{
+		Number:  1062,
+		Message: "Duplicate entry '1-081234567890' for key 'contacts.idx_contacts_user_active_phone'",
+	}
+
+	t.Run("create", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := NewContactRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `contacts`").WillReturnError(duplicateErr)
+		mock.ExpectRollback()
+
+		err := repo.Create(context.Background(), &models.Contact{UserID: 1, FullName: "Jane", Phone: "081234567890"}, 0)
+		assert.ErrorIs(t, err, ErrDuplicatePhone)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := NewContactRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `contacts`").WillReturnError(duplicateErr)
+		mock.ExpectRollback()
+
+		err := repo.Update(context.Background(), &models.Contact{ID: 2, UserID: 1, FullName: "Jane", Phone: "081234567890"}, ContactChildren{})
+		assert.ErrorIs(t, err, ErrDuplicatePhone)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestContactRepository_Delete(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id IN \\(\\?\\)\\)").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).AddRow(1, 1, "Jane", "1234567890"))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+	mock.ExpectExec("UPDATE `contacts` SET `deleted_at`").
+		WithArgs(sqlmock.AnyArg(), 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WithArgs(1, 1, models.RevisionActionDelete, snapshotArg{fullName: "Jane"}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Delete(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_DeleteMany(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	// Ownership is enforced in the WHERE clause, so another user's contact (3) is not affected
+	mock.ExpectBegin()
+	// The contacts are loaded first so the caller gets what was deleted
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id IN \\(\\?,\\?,\\?\\)\\) AND `contacts`.`deleted_at` IS NULL ORDER BY id ASC").
+		WithArgs(1, 1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(1, 1, "Jane", "1234567890").
+			AddRow(2, 1, "John", "0987654321"))
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone", "is_primary"}).
+			AddRow(1, 1, "1234567890", true).
+			AddRow(2, 2, "0987654321", true))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+	mock.ExpectExec("UPDATE `contacts` SET `deleted_at`=\\? WHERE \\(user_id = \\? AND id IN \\(\\?,\\?,\\?\\)\\) AND `contacts`.`deleted_at` IS NULL").
+		WithArgs(sqlmock.AnyArg(), 1, 1, 2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// One revision per deleted contact, written in a single statement
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WithArgs(1, 1, models.RevisionActionDelete, snapshotArg{fullName: "Jane"}, sqlmock.AnyArg(),
+			2, 1, models.RevisionActionDelete, snapshotArg{fullName: "John"}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeleteMany(ctx, 1, []uint{1, 2, 3})
+	assert.NoError(t, err)
+	if assert.Len(t, deleted, 2) {
+		assert.Equal(t, "Jane", deleted[0].FullName)
+		assert.Len(t, deleted[1].Phones, 1)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_DeleteMany_NoneOwned(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	// Nothing to delete, so no UPDATE or revision is written
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id IN \\(\\?\\)\\)").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeleteMany(context.Background(), 1, []uint{3})
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_SetFavorite(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	// One UPDATE for the whole selection; ownership is enforced in the WHERE clause
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE `contacts` SET `favorite`=\\?,`updated_at`=\\? WHERE \\(user_id = \\? AND id IN \\(\\?,\\?,\\?\\)\\) AND `contacts`.`deleted_at` IS NULL$").
+		WithArgs(true, sqlmock.AnyArg(), 1, 1, 2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// The updated contacts are reloaded and a revision is recorded for each
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id IN \\(\\?,\\?,\\?\\)\\) AND `contacts`.`deleted_at` IS NULL ORDER BY id ASC").
+		WithArgs(1, 1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone", "favorite"}).
+			AddRow(1, 1, "Jane", "1234567890", true).
+			AddRow(2, 1, "John", "0987654321", true))
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WithArgs(1, 1, models.RevisionActionUpdate, snapshotArg{fullName: "Jane"}, sqlmock.AnyArg(),
+			2, 1, models.RevisionActionUpdate, snapshotArg{fullName: "John"}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
 	mock.ExpectCommit()
 
-	err := repo.Delete(ctx, 1, 1)
+	updated, err := repo.SetFavorite(context.Background(), 1, []uint{1, 2, 3}, true)
+	assert.NoError(t, err)
+	if assert.Len(t, updated, 2) {
+		assert.True(t, updated[0].Favorite)
+		assert.Equal(t, uint(2), updated[1].ID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_Merge(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	email := "jane@example.com"
+	target := &models.Contact{ID: 1, UserID: 1, FullName: "Jane", Phone: "1234567890", Email: &email, Favorite: true, Tags: []string{"work"},
+		Phones: []models.ContactPhone{{Phone: "1234567890", IsPrimary: true}, {Phone: "0987654321"}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts` SET `full_name`=\\?,`phone`=\\?,`email`=\\?,`favorite`=\\?,`updated_at`=\\? WHERE user_id = \\?").
+		WithArgs("Jane", "1234567890", &email, true, sqlmock.AnyArg(), 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `contact_tags` WHERE contact_id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `contact_tags`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// The merged numbers replace the target's; its emails are nil and left alone
+	mock.ExpectExec("DELETE FROM `contact_phones` WHERE contact_id = \\?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_phones`").
+		WithArgs(1, "1234567890", "", true, sqlmock.AnyArg(), 1, "0987654321", "", false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectQuery("SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id IN \\(\\?\\)\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).AddRow(2, 1, "Jane D", "1234567890"))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+	mock.ExpectExec("UPDATE `contacts` SET `deleted_at`=\\? WHERE \\(id = \\? AND user_id = \\?\\)").
+		WithArgs(sqlmock.AnyArg(), 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WithArgs(1, 1, models.RevisionActionUpdate, snapshotArg{fullName: "Jane"}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `contact_revisions`").
+		WithArgs(2, 1, models.RevisionActionDelete, snapshotArg{fullName: "Jane D"}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	err := repo.Merge(ctx, target, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_MergeSourceMissing(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	target := &models.Contact{ID: 1, UserID: 1, FullName: "Jane", Phone: "1234567890"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts` SET `full_name`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `contact_tags`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT \\* FROM `contacts`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}))
+	mock.ExpectExec("UPDATE `contacts` SET `deleted_at`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// Nothing is committed when the source is gone
+	mock.ExpectRollback()
+
+	err := repo.Merge(ctx, target, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_Restore(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `contacts` SET `deleted_at`=\\?,`updated_at`=\\? WHERE id = \\? AND user_id = \\? AND deleted_at IS NOT NULL").
+		WithArgs(nil, sqlmock.AnyArg(), 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Restore(ctx, 1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_RestoreNotFound(t *testing.T) {
+	// Covers both a contact that never existed and one that was hard-deleted:
+	// neither has a trashed row left to update.
+	for _, name := range []string{"nonexistent", "hard deleted"} {
+		t.Run(name, func(t *testing.T) {
+			db, mock, cleanup := setupMockDB(t)
+			defer cleanup()
+
+			repo := NewContactRepository(db)
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE `contacts` SET `deleted_at`").
+				WithArgs(nil, sqlmock.AnyArg(), 42, 1).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			err := repo.Restore(ctx, 1, 42)
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestContactRepository_ListIncludeDeleted(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+
+	req := &models.ListContactsRequest{Page: 1, Limit: 10, IncludeDeleted: true}
+
+	// Unscoped queries must not filter on deleted_at
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\?$").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? ORDER BY created_at DESC, id DESC LIMIT \\?$").
+		WithArgs(1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone", "deleted_at"}).
+			AddRow(1, 1, "Jane Doe", "1234567890", time.Now()))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, total, err := repo.List(ctx, 1, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, contacts, 1)
+	assert.True(t, contacts[0].DeletedAt.Valid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_ListUpdatedSince(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "user_id", "full_name", "phone", "updated_at", "deleted_at"}
+
+	// Changes strictly after the timestamp, tombstones included; a contact updated
+	// exactly at the timestamp was already seen by the previous sync
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND \\(updated_at > \\? OR deleted_at > \\?\\)$").
+		WithArgs(1, since, since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND \\(updated_at > \\? OR deleted_at > \\?\\) ORDER BY created_at DESC, id DESC LIMIT \\?$").
+		WithArgs(1, since, since, 10).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(2, 1, "Jane Doe", "0811111111112", since.Add(time.Second), nil).
+			AddRow(1, 1, "John Doe", "0811111111111", since, since.Add(time.Nanosecond)))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, total, err := repo.List(context.Background(), 1, &models.ListContactsRequest{Page: 1, Limit: 10, UpdatedSince: &since})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, contacts, 2) {
+		assert.False(t, contacts[0].DeletedAt.Valid)
+		assert.True(t, contacts[1].DeletedAt.Valid)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_ListInBatches(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id > \\?\\) AND `contacts`.`deleted_at` IS NULL ORDER BY id ASC LIMIT \\?$").
+		WithArgs(7, 0, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(1, 7, "Jane Doe", "081234567890").
+			AddRow(4, 7, "John Doe", "081234567891"))
+	// Every phone and email is exported, not only the primary ones
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails` WHERE `contact_emails`.`contact_id` IN \\(\\?,\\?\\)").
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones` WHERE `contact_phones`.`contact_id` IN \\(\\?,\\?\\)").
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone", "is_primary"}).
+			AddRow(1, 1, "081234567890", true).
+			AddRow(2, 1, "082222222222", false).
+			AddRow(3, 4, "081234567891", true))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?,\\?\\)").
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}).AddRow(1, 4, "work"))
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE \\(user_id = \\? AND id > \\?\\) AND `contacts`.`deleted_at` IS NULL ORDER BY id ASC LIMIT \\?$").
+		WithArgs(7, 4, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "full_name", "phone"}).
+			AddRow(9, 7, "Mary Major", "081234567892"))
+	mock.ExpectQuery("SELECT \\* FROM `contact_emails`").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "email"}))
+	mock.ExpectQuery("SELECT \\* FROM `contact_phones`").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "phone", "is_primary"}).AddRow(4, 9, "081234567892", true))
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags` WHERE contact_id IN \\(\\?\\)").
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	var batches [][]models.Contact
+	err := repo.ListInBatches(context.Background(), 7, 2, func(contacts []models.Contact) error {
+		batches = append(batches, contacts)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, batches, 2) {
+		assert.Len(t, batches[0], 2)
+		if assert.Len(t, batches[0][0].Phones, 2) {
+			assert.Equal(t, "082222222222", batches[0][0].Phones[1].Phone)
+		}
+		assert.Equal(t, []string{"work"}, batches[0][1].Tags)
+		assert.Equal(t, "Mary Major", batches[1][0].FullName)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_Count(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND `contacts`.`deleted_at` IS NULL$").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+	count, err := repo.Count(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactRepository_ListCursorStableAcrossInsert(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "user_id", "full_name", "phone", "created_at"}
+
+	// First page ends at contact 4
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("ORDER BY created_at DESC, id DESC LIMIT \\?$").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(5, 1, "Contact 5", "0811111111115", base.Add(4*time.Minute)).
+			AddRow(4, 1, "Contact 4", "0811111111114", base.Add(3*time.Minute)))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	first, _, err := repo.List(ctx, 1, &models.ListContactsRequest{Page: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, first, 2)
+
+	// A contact inserted before the next request must not shift the page:
+	// the cursor filters on the last seen row instead of skipping an offset
+	cursor := EncodeContactCursor(&first[len(first)-1])
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND `contacts`.`deleted_at` IS NULL AND \\(created_at, id\\) < \\(\\?, \\?\\) ORDER BY created_at DESC, id DESC LIMIT \\?$").
+		WithArgs(1, base.Add(3*time.Minute), 4, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, 1, "Contact 3", "0811111111113", base.Add(2*time.Minute)).
+			AddRow(2, 1, "Contact 2", "0811111111112", base.Add(time.Minute)))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	second, total, err := repo.List(ctx, 1, &models.ListContactsRequest{Page: 1, Limit: 2, Cursor: cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), total)
+	if assert.Len(t, second, 2) {
+		assert.Equal(t, uint(3), second[0].ID)
+		assert.Equal(t, uint(2), second[1].ID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDecodeContactCursor(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	cursor := EncodeContactCursor(&models.Contact{ID: 42, CreatedAt: createdAt})
+
+	decodedAt, id, err := DecodeContactCursor(cursor)
+	assert.NoError(t, err)
+	assert.True(t, createdAt.Equal(decodedAt))
+	assert.Equal(t, uint(42), id)
+
+	for _, invalid := range []string{"", "%%%", "bm8tc2VwYXJhdG9y", "MjAyNHwx", "MjAyNC0wMS0wMlQwMzowNDowNVp8MA"} {
+		_, _, err := DecodeContactCursor(invalid)
+		assert.ErrorIs(t, err, ErrInvalidCursor, "cursor=%q", invalid)
+	}
+}
+
+func TestAuditLogRepository_ListByUser(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewAuditLogRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `audit_logs` WHERE user_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE user_id = \\? ORDER BY created_at DESC,id DESC LIMIT \\? OFFSET \\?").
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "action", "target", "ip_address"}).
+			AddRow(1, 1, "login", "user:1", "127.0.0.1"))
+
+	entries, total, err := repo.ListByUser(ctx, 1, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "login", entries[0].Action)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepository_Revoke(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `refresh_tokens` SET `revoked_at`=\\? WHERE jti = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), "test-jti").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Revoke(ctx, "test-jti")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenRepository_RevokeAlreadyRevoked(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `refresh_tokens` SET `revoked_at`").
+		WithArgs(sqlmock.AnyArg(), "test-jti").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.Revoke(ctx, "test-jti")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSessionRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `sessions` SET `revoked_at`=\\? WHERE jti = \\? AND user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), "session-jti", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Revoke(ctx, 1, "session-jti")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepository_RevokeOtherUsersSession(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSessionRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `sessions` SET `revoked_at`").
+		WithArgs(sqlmock.AnyArg(), "session-jti", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.Revoke(ctx, 2, "session-jti")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepository_ListActive(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewSessionRepository(db)
+
+	mock.ExpectQuery("^SELECT \\* FROM `sessions` WHERE user_id = \\? AND revoked_at IS NULL AND expires_at > \\? ORDER BY last_used_at DESC, id DESC$").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "jti", "user_agent"}).
+			AddRow(2, 1, "laptop-jti", "Laptop/1.0").
+			AddRow(1, 1, "phone-jti", "Phone/1.0"))
+
+	sessions, err := repo.ListActive(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "laptop-jti", sessions[0].JTI)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterQueryTimeout(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	if err := RegisterQueryTimeout(db, 20*time.Millisecond); err != nil {
+		t.Fatalf("failed to register query timeout: %v", err)
+	}
+	repo := NewContactRepository(db)
+
+	// The query blocks far longer than the timeout
+	mock.ExpectQuery("SELECT \\* FROM `contacts`").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	start := time.Now()
+	contact, err := repo.GetByID(context.Background(), 1, 1)
+
+	assert.Nil(t, contact)
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRegisterQueryTimeout_FastQuery(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	if err := RegisterQueryTimeout(db, time.Second); err != nil {
+		t.Fatalf("failed to register query timeout: %v", err)
+	}
+	repo := NewContactRepository(db)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `contacts`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.Count(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestContactRepository_ListFullText(t *testing.T) {
+	columns := []string{"id", "user_id", "full_name", "phone", "email"}
+
+	t.Run("uses the fulltext index", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := NewContactRepository(db)
+
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND MATCH \\(full_name, email\\) AGAINST \\(\\? IN BOOLEAN MODE\\) AND `contacts`.`deleted_at` IS NULL$").
+			WithArgs(1, "+john* +example*").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND MATCH \\(full_name, email\\) AGAINST \\(\\? IN BOOLEAN MODE\\) AND `contacts`.`deleted_at` IS NULL").
+			WithArgs(1, "+john* +example*", 10).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 1, "John Doe", "1234567890", "john@example.com"))
+		expectNoPhonesOrEmails(mock)
+		mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+		// Boolean mode operators in the input are not passed through
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Search: "john -example*", FullText: true}
+		contacts, total, err := repo.List(context.Background(), 1, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, contacts, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("short words fall back to LIKE", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := NewContactRepository(db)
+
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM `contacts` WHERE user_id = \\? AND \\(`full_name_normalized` LIKE \\? OR `phone` LIKE \\? OR `email` LIKE \\?\\)").
+			WithArgs(1, "%jo%", "%jo%", "%jo%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND \\(`full_name_normalized` LIKE \\? OR `phone` LIKE \\? OR `email` LIKE \\?\\)").
+			WithArgs(1, "%jo%", "%jo%", "%jo%", 10).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Search: "jo", FullText: true}
+		_, total, err := repo.List(context.Background(), 1, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestContactRepository_ListWithoutCount(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := NewContactRepository(db)
+	withCount := false
+	columns := []string{"id", "user_id", "full_name", "phone"}
+
+	// No COUNT query; one row beyond the limit is fetched to detect a next page
+	mock.ExpectQuery("^SELECT \\* FROM `contacts` WHERE user_id = \\? AND `contacts`.`deleted_at` IS NULL ORDER BY created_at DESC, id DESC LIMIT \\?$").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, 1, "Contact 3", "083333333333").
+			AddRow(2, 1, "Contact 2", "082222222222").
+			AddRow(1, 1, "Contact 1", "081111111111"))
+	expectNoPhonesOrEmails(mock)
+	mock.ExpectQuery("SELECT \\* FROM `contact_tags`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "contact_id", "tag"}))
+
+	contacts, total, err := repo.List(context.Background(), 1, &models.ListContactsRequest{Page: 1, Limit: 2, WithCount: &withCount})
+
 	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), total)
+	assert.Len(t, contacts, 3, "the extra row signals a next page")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrQueryTimeout is returned when a database query exceeds the configured query timeout
+var ErrQueryTimeout = errors.New("database query timed out")
+
+const (
+	queryTimeoutCallback  = "repository:query_timeout"
+	queryTimeoutCancelKey = "repository:query_timeout_cancel"
+)
+
+// RegisterQueryTimeout bounds every statement run through db by timeout, on top of any
+// deadline the caller's context already has, so every repository method is covered.
+// Statements that run out of time fail with an error matching both ErrQueryTimeout and
+// context.DeadlineExceeded. A non-positive timeout leaves queries unbounded.
+func RegisterQueryTimeout(db *gorm.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	start := func(tx *gorm.DB) {
+		ctx, cancel := context.WithTimeout(tx.Statement.Context, timeout)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(queryTimeoutCancelKey, cancel)
+	}
+	finish := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(queryTimeoutCancelKey)
+		if !ok {
+			return
+		}
+		cancel := value.(context.CancelFunc)
+		defer cancel()
+
+		// Drivers report an expired context differently; the context itself is authoritative
+		if tx.Error != nil && errors.Is(tx.Statement.Context.Err(), context.DeadlineExceeded) && !errors.Is(tx.Error, ErrQueryTimeout) {
+			tx.Error = fmt.Errorf("%w: %w", ErrQueryTimeout, context.DeadlineExceeded)
+		}
+	}
+
+	// Row() and Rows() are left unbounded: their results are read after the callbacks return
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("*").Register(queryTimeoutCallback+":start_create", start),
+		callbacks.Create().After("*").Register(queryTimeoutCallback+":finish_create", finish),
+		callbacks.Query().Before("*").Register(queryTimeoutCallback+":start_query", start),
+		callbacks.Query().After("*").Register(queryTimeoutCallback+":finish_query", finish),
+		callbacks.Update().Before("*").Register(queryTimeoutCallback+":start_update", start),
+		callbacks.Update().After("*").Register(queryTimeoutCallback+":finish_update", finish),
+		callbacks.Delete().Before("*").Register(queryTimeoutCallback+":start_delete", start),
+		callbacks.Delete().After("*").Register(queryTimeoutCallback+":finish_delete", finish),
+		callbacks.Raw().Before("*").Register(queryTimeoutCallback+":start_raw", start),
+		callbacks.Raw().After("*").Register(queryTimeoutCallback+":finish_raw", finish),
+	} {
+		if err != nil {
+			return fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
+	return nil
+}
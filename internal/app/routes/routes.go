@@ -1,31 +1,77 @@
 package routes
 
 import (
+	"time"
+
 	"user-service/internal/app/handlers"
+	"user-service/internal/app/models"
 	"user-service/internal/app/service"
 	"user-service/internal/middleware"
+	"user-service/pkg/redis"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures all routes for the application
 func SetupRoutes(router *gin.Engine, handler *handlers.Handler, svc *service.Service) {
-	// Apply global middleware
-	router.Use(middleware.CORSMiddleware())
+	// Reject oversized bodies before any handler buffers them
+	router.Use(middleware.BodyLimitMiddleware(handler.GetMaxBodyBytes()))
+
+	// Apply global middleware. The timeout runs the rest of the chain in its own
+	// goroutine, so panic recovery must come after it to catch handler panics.
+	// Imports and exports may take longer than other requests.
+	readTimeout, writeTimeout, importTimeout := handler.GetRequestTimeouts()
+	router.Use(middleware.RouteTimeoutMiddleware(middleware.RouteTimeouts{
+		Read:  readTimeout,
+		Write: writeTimeout,
+		Routes: map[string]time.Duration{
+			"/api/v1/contacts/import": importTimeout,
+			"/api/v1/contacts/export": importTimeout,
+			"/api/v1/me/export":       importTimeout,
+		},
+	}))
+	router.Use(middleware.ErrorHandlerMiddleware())
+	router.Use(middleware.SecureHeadersMiddleware(middleware.DefaultSecureHeadersConfig()))
+	router.Use(middleware.CORSMiddleware(handler.GetCORSAllowedOrigins()))
 	router.Use(middleware.LoggerMiddleware())
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "healthy",
-			"service": "contact-management-api",
-			"version": "1.0.0",
-		})
-	})
+	// Prometheus metrics
+	metrics := middleware.NewMetrics()
+	if sqlDB, err := handler.GetDB().DB(); err == nil {
+		_ = metrics.RegisterDB(sqlDB, "mysql")
+	}
+	router.Use(metrics.Middleware())
+	router.GET("/metrics", metrics.Handler())
+
+	// Consistent JSON for unknown routes and methods
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(middleware.NotFoundHandler())
+	router.NoMethod(middleware.MethodNotAllowedHandler())
+
+	// Liveness and readiness probes
+	router.GET("/health", handler.HealthCheck)
+	router.GET("/ready", handler.Readiness)
+
+	// Uploaded avatars
+	router.Static(handlers.AvatarURLPrefix, handler.GetAvatarDir())
 
 	// API v1 routes
 	api := router.Group("/api/v1")
 	{
+		// Auth middleware
+		authMiddleware := middleware.AuthMiddleware(svc)
+
+		// Abusable endpoints are rate limited per client IP and route when Redis is available
+		limited := func(handlers ...gin.HandlerFunc) []gin.HandlerFunc { return handlers }
+		if redisClient := handler.GetRedis(); redisClient != nil {
+			if limit, window := handler.GetWriteRateLimit(); limit > 0 && window > 0 {
+				rateLimit := middleware.RateLimitMiddleware(redis.NewRateLimitCounter(redisClient), limit, window)
+				limited = func(handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+					return append([]gin.HandlerFunc{rateLimit}, handlers...)
+				}
+			}
+		}
+
 		// ========================================
 		// PUBLIC ROUTES (No authentication)
 		// ========================================
@@ -33,30 +79,77 @@ func SetupRoutes(router *gin.Engine, handler *handlers.Handler, svc *service.Ser
 		// Auth endpoints
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", handler.Register) // POST /api/v1/auth/register
-			auth.POST("/login", handler.Login)       // POST /api/v1/auth/login
+			auth.POST("/register", handler.Register)                          // POST /api/v1/auth/register
+			auth.POST("/login", handler.Login)                                // POST /api/v1/auth/login
+			auth.POST("/refresh", handler.RefreshToken)                       // POST /api/v1/auth/refresh
+			auth.POST("/logout", authMiddleware, handler.Logout)              // POST /api/v1/auth/logout
+			auth.GET("/verify", handler.VerifyEmail)                          // GET /api/v1/auth/verify?token=
+			auth.POST("/forgot-password", limited(handler.ForgotPassword)...) // POST /api/v1/auth/forgot-password
+			auth.POST("/reset-password", handler.ResetPassword)               // POST /api/v1/auth/reset-password
+			auth.GET("/check-email", limited(handler.CheckEmail)...)          // GET /api/v1/auth/check-email?email=
+
+			// Token introspection for gateways, only when basic auth credentials are configured
+			if username, password := handler.GetIntrospectionCredentials(); username != "" && password != "" {
+				auth.POST("/introspect", middleware.BasicAuthMiddleware(username, password), handler.IntrospectToken) // POST /api/v1/auth/introspect
+			}
 		}
 
 		// ========================================
 		// PROTECTED ROUTES (Require authentication)
 		// ========================================
 
-		// Auth middleware
-		authMiddleware := middleware.AuthMiddleware(svc)
-
 		// User profile endpoints
-		api.GET("/me", authMiddleware, handler.GetProfile)    // GET /api/v1/me
-		api.PUT("/me", authMiddleware, handler.UpdateProfile) // PUT /api/v1/me
+		api.GET("/me", authMiddleware, handler.GetProfile)                     // GET /api/v1/me
+		api.PUT("/me", authMiddleware, handler.UpdateProfile)                  // PUT /api/v1/me
+		api.PATCH("/me", authMiddleware, handler.UpdateProfile)                // PATCH /api/v1/me (omitted fields unchanged)
+		api.DELETE("/me", authMiddleware, handler.DeleteAccount)               // DELETE /api/v1/me (password confirmation)
+		api.PUT("/me/password", authMiddleware, handler.ChangePassword)        // PUT /api/v1/me/password
+		api.POST("/me/avatar", authMiddleware, handler.UploadAvatar)           // POST /api/v1/me/avatar (multipart image)
+		api.POST("/me/deactivate", authMiddleware, handler.DeactivateAccount)  // POST /api/v1/me/deactivate
+		api.GET("/me/audit", authMiddleware, handler.ListAuditLogs)            // GET /api/v1/me/audit?page=1&limit=20
+		api.GET("/me/export", authMiddleware, handler.ExportAccount)           // GET /api/v1/me/export (JSON download)
+		api.GET("/me/sessions", authMiddleware, handler.ListSessions)          // GET /api/v1/me/sessions
+		api.DELETE("/me/sessions/:jti", authMiddleware, handler.RevokeSession) // DELETE /api/v1/me/sessions/:jti
+		api.PUT("/me/webhook", authMiddleware, handler.SetWebhook)             // PUT /api/v1/me/webhook (returns the signing secret)
+		api.DELETE("/me/webhook", authMiddleware, handler.DeleteWebhook)       // DELETE /api/v1/me/webhook
 
 		// Contact endpoints
 		contacts := api.Group("/contacts")
 		contacts.Use(authMiddleware)
 		{
-			contacts.GET("", handler.ListContacts)         // GET /api/v1/contacts?q=&page=1&limit=20
-			contacts.POST("", handler.CreateContact)       // POST /api/v1/contacts
-			contacts.GET("/:id", handler.GetContact)       // GET /api/v1/contacts/:id
-			contacts.PUT("/:id", handler.UpdateContact)    // PUT /api/v1/contacts/:id
-			contacts.DELETE("/:id", handler.DeleteContact) // DELETE /api/v1/contacts/:id
+			// Writes are rate limited and retried creates carrying the same
+			// Idempotency-Key are replayed; both require Redis
+			write := limited
+			createContact := []gin.HandlerFunc{handler.CreateContact}
+			if redisClient := handler.GetRedis(); redisClient != nil {
+				createContact = append([]gin.HandlerFunc{middleware.IdempotencyMiddleware(redis.NewIdempotencyStore(redisClient))}, createContact...)
+			}
+
+			contacts.GET("", handler.ListContacts)                                    // GET /api/v1/contacts?q=&page=1&limit=20
+			contacts.POST("", write(createContact...)...)                             // POST /api/v1/contacts (Idempotency-Key supported)
+			contacts.POST("/import", write(handler.ImportContacts)...)                // POST /api/v1/contacts/import?source=csv|google|vcard (multipart)
+			contacts.GET("/export", handler.ExportContacts)                           // GET /api/v1/contacts/export?format=csv|vcard
+			contacts.POST("/batch-delete", write(handler.BatchDeleteContacts)...)     // POST /api/v1/contacts/batch-delete
+			contacts.POST("/batch-favorite", write(handler.BatchFavoriteContacts)...) // POST /api/v1/contacts/batch-favorite
+			contacts.GET("/lookup", handler.LookupContact)                            // GET /api/v1/contacts/lookup?phone=
+			contacts.GET("/birthdays", handler.UpcomingBirthdays)                     // GET /api/v1/contacts/birthdays?days=30
+			contacts.GET("/recent", handler.RecentContacts)                           // GET /api/v1/contacts/recent?limit=5
+			contacts.GET("/stats", handler.ContactStats)                              // GET /api/v1/contacts/stats
+			contacts.GET("/:id", handler.GetContact)                                  // GET /api/v1/contacts/:id
+			contacts.GET("/:id/history", handler.GetContactHistory)                   // GET /api/v1/contacts/:id/history
+			contacts.PUT("/:id", write(handler.UpdateContact)...)                     // PUT /api/v1/contacts/:id
+			contacts.PATCH("/:id", write(handler.UpdateContact)...)                   // PATCH /api/v1/contacts/:id (omitted fields unchanged)
+			contacts.DELETE("/:id", write(handler.DeleteContact)...)                  // DELETE /api/v1/contacts/:id
+			contacts.POST("/:id/restore", write(handler.RestoreContact)...)           // POST /api/v1/contacts/:id/restore
+			contacts.POST("/:id/merge", write(handler.MergeContacts)...)              // POST /api/v1/contacts/:id/merge
+		}
+
+		// Admin endpoints, for users with the admin role
+		admins := api.Group("/admin", authMiddleware, middleware.RequireRole(models.RoleAdmin))
+		{
+			admins.GET("/users", handler.ListUsers)            // GET /api/v1/admin/users?q=&page=1&limit=20
+			admins.PUT("/users/:id/role", handler.SetUserRole) // PUT /api/v1/admin/users/:id/role
+			admins.PUT("/log-level", handler.SetLogLevel)      // PUT /api/v1/admin/log-level
 		}
 	}
 }
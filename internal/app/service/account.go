@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"user-service/internal/app/models"
+)
+
+const (
+	// exportContactBatchSize is how many contacts an account export reads at a time
+	exportContactBatchSize = 500
+	// exportAuditPageSize is how many audit log entries an account export reads at a time
+	exportAuditPageSize = 100
+)
+
+// AccountExport is everything stored about a user, for data portability. Contacts are not
+// held in it but streamed with EachContactBatch, as an account may have many.
+type AccountExport struct {
+	ExportedAt time.Time
+	Profile    *models.UserResponse
+	// AuditLogs is nil when audit logging is disabled
+	AuditLogs []models.AuditLog
+
+	contacts func(ctx context.Context, fn func([]models.Contact) error) error
+}
+
+// EachContactBatch calls fn with the user's contacts, a batch at a time
+func (e *AccountExport) EachContactBatch(ctx context.Context, fn func([]*models.ContactResponse) error) error {
+	return e.contacts(ctx, func(contacts []models.Contact) error {
+		batch := make([]*models.ContactResponse, len(contacts))
+		for i := range contacts {
+			batch[i] = contacts[i].ToResponse()
+		}
+		return fn(batch)
+	})
+}
+
+// ExportAccount gathers the user's profile and audit log entries; contacts are read
+// while the export is written
+func (s *Service) ExportAccount(ctx context.Context, userID uint) (*AccountExport, error) {
+	profile, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &AccountExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    profile,
+		contacts: func(ctx context.Context, fn func([]models.Contact) error) error {
+			if err := s.contactRepo.ListInBatches(ctx, userID, exportContactBatchSize, fn); err != nil {
+				return fmt.Errorf("failed to export contacts: %w", err)
+			}
+			return nil
+		},
+	}
+
+	if s.auditLogRepo != nil {
+		export.AuditLogs = []models.AuditLog{}
+		for page := 1; ; page++ {
+			entries, total, err := s.auditLogRepo.ListByUser(ctx, userID, page, exportAuditPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to export audit logs: %w", err)
+			}
+			export.AuditLogs = append(export.AuditLogs, entries...)
+			if len(entries) < exportAuditPageSize || int64(len(export.AuditLogs)) >= total {
+				break
+			}
+		}
+	}
+
+	return export, nil
+}
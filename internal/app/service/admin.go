@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/repository"
+)
+
+// ListUsers returns all users for operators, newest first
+func (s *Service) ListUsers(ctx context.Context, req *models.ListUsersRequest) (*models.PaginatedResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = s.defaultPageSize
+	}
+	if req.Limit > s.maxPageSize {
+		req.Limit = s.maxPageSize
+	}
+	req.Search = strings.TrimSpace(req.Search)
+
+	users, total, err := s.userRepo.List(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	responses := make([]*models.UserResponse, len(users))
+	for i := range users {
+		responses[i] = users[i].ToResponse()
+	}
+
+	totalPages := int((total + int64(req.Limit) - 1) / int64(req.Limit))
+
+	return &models.PaginatedResponse{
+		Data: responses,
+		Pagination: models.PaginationMeta{
+			Page:        req.Page,
+			Limit:       req.Limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNextPage: req.Page < totalPages,
+			HasPrevPage: req.Page > 1,
+		},
+	}, nil
+}
+
+// SetRole changes the role of a user, e.g. to promote them to admin. The new role
+// takes effect with the user's next access token.
+func (s *Service) SetRole(ctx context.Context, userID uint, role string) error {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return ErrInvalidRole
+	}
+
+	if err := s.userRepo.SetRole(ctx, userID, role); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	s.invalidateProfile(ctx, userID)
+	return nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"user-service/internal/app/models"
+	"user-service/internal/logger"
+)
+
+// maxAuditTargetLength matches the audit_logs.target column size
+const maxAuditTargetLength = 255
+
+// clientIPKey is the context key carrying the client IP for audit logging
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the client IP recorded in audit logs
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// clientIPFromContext returns the client IP stored by WithClientIP, if any
+func clientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// recordAudit writes an audit log entry for an action performed by userID.
+// Failures are logged but never fail the audited operation.
+func (s *Service) recordAudit(ctx context.Context, userID uint, action, target string) {
+	if s.auditLogRepo == nil {
+		return
+	}
+
+	entry := &models.AuditLog{
+		UserID:    userID,
+		Action:    action,
+		Target:    target,
+		IPAddress: clientIPFromContext(ctx),
+	}
+	if err := s.auditLogRepo.Create(ctx, entry); err != nil {
+		logger.FromContext(ctx).Warn("Failed to write audit log", "action", action, "user_id", userID, "error", err)
+	}
+}
+
+// userAuditTarget identifies a user as an audit log target
+func userAuditTarget(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// contactAuditTarget identifies one or more contacts as an audit log target
+func contactAuditTarget(contactIDs ...uint) string {
+	ids := make([]string, len(contactIDs))
+	for i, id := range contactIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	target := "contact:" + strings.Join(ids, ",")
+	if len(target) > maxAuditTargetLength {
+		target = target[:maxAuditTargetLength]
+	}
+	return target
+}
+
+// ListAuditLogs returns the user's audit log entries, newest first
+func (s *Service) ListAuditLogs(ctx context.Context, userID uint, req *models.ListAuditLogsRequest) (*models.PaginatedResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = s.defaultPageSize
+	}
+	if req.Limit > s.maxPageSize {
+		req.Limit = s.maxPageSize
+	}
+
+	entries := []models.AuditLog{}
+	var total int64
+	if s.auditLogRepo != nil {
+		var err error
+		entries, total, err = s.auditLogRepo.ListByUser(ctx, userID, req.Page, req.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit logs: %w", err)
+		}
+	}
+
+	totalPages := int((total + int64(req.Limit) - 1) / int64(req.Limit))
+
+	return &models.PaginatedResponse{
+		Data: entries,
+		Pagination: models.PaginationMeta{
+			Page:        req.Page,
+			Limit:       req.Limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNextPage: req.Page < totalPages,
+			HasPrevPage: req.Page > 1,
+		},
+	}, nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"user-service/internal/logger"
+)
+
+// lastSeenInterval is how often a user's last activity is written to the database
+const lastSeenInterval = time.Minute
+
+// LastSeenThrottle limits how often a user's last activity is written
+type LastSeenThrottle interface {
+	// Allow reports whether the user's last activity may be written now, holding off
+	// further writes for interval when it may
+	Allow(ctx context.Context, userID uint, interval time.Duration) (bool, error)
+}
+
+// RecordActivity notes that the user made a request. The last-seen time is written at
+// most once per lastSeenInterval and only with a throttle configured, so requests never
+// all write to the database. Failures are logged.
+func (s *Service) RecordActivity(ctx context.Context, userID uint) {
+	if s.lastSeenThrottle == nil {
+		return
+	}
+
+	allowed, err := s.lastSeenThrottle.Allow(ctx, userID, lastSeenInterval)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to throttle last seen update", "user_id", userID, "error", err)
+		return
+	}
+	if !allowed {
+		return
+	}
+
+	if err := s.userRepo.SetLastSeen(ctx, userID, time.Now()); err != nil {
+		logger.FromContext(ctx).Warn("Failed to update last seen", "user_id", userID, "error", err)
+		return
+	}
+	s.invalidateProfile(ctx, userID)
+}
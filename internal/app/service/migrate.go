@@ -1,3 +0,0 @@
-package service
-
-// DB Migration
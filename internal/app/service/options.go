@@ -0,0 +1,218 @@
+package service
+
+import (
+	"crypto/rsa"
+	"time"
+
+	"user-service/internal/app/repository"
+	"user-service/internal/app/webhook"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Option configures optional Service dependencies and settings
+type Option func(*Service)
+
+// WithRefreshTokenRepository persists issued refresh tokens so they can be revoked.
+// Without it, refresh tokens are validated by signature and expiry only.
+func WithRefreshTokenRepository(repo repository.RefreshTokenRepository) Option {
+	return func(s *Service) {
+		s.refreshTokenRepo = repo
+	}
+}
+
+// WithTokenRevocationStore enables access token revocation on logout
+func WithTokenRevocationStore(store TokenRevocationStore) Option {
+	return func(s *Service) {
+		s.revocationStore = store
+	}
+}
+
+// WithAccessTokenTTL sets the access token lifetime. Non-positive values keep the 24-hour default.
+func WithAccessTokenTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.accessTokenTTL = ttl
+		}
+	}
+}
+
+// WithJWTAudience sets the audience access tokens are issued for and must carry.
+// An empty audience keeps the "user-service" default.
+func WithJWTAudience(audience string) Option {
+	return func(s *Service) {
+		if audience != "" {
+			s.jwtAudience = audience
+		}
+	}
+}
+
+// WithJWTKeys signs HS256 tokens with keys[activeKID] and names the key in their kid
+// header, so the secret can be rotated: tokens verify with the key their kid names for
+// as long as it is in keys. Tokens without a kid only verify with the service secret,
+// and are rejected when it is empty. RSA keys take precedence.
+func WithJWTKeys(keys map[string]string, activeKID string) Option {
+	return func(s *Service) {
+		s.jwtKeys = keys
+		s.jwtActiveKID = activeKID
+	}
+}
+
+// WithWebhookResolver sets the resolver SetWebhook checks webhook hosts with.
+// Without it net.DefaultResolver is used.
+func WithWebhookResolver(resolver webhook.Resolver) Option {
+	return func(s *Service) {
+		s.webhookResolver = resolver
+	}
+}
+
+// WithRSAKeys signs tokens with RS256 using privateKey and verifies them with publicKey
+// instead of HS256 with the shared secret. HS256 tokens are rejected in this mode.
+func WithRSAKeys(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) Option {
+	return func(s *Service) {
+		s.rsaPrivateKey = privateKey
+		s.rsaPublicKey = publicKey
+	}
+}
+
+// WithLoginAttemptLimiter locks out an email/IP pair after maxAttempts failed logins within window.
+// Non-positive values keep the defaults of 5 attempts in 15 minutes.
+func WithLoginAttemptLimiter(counter LoginAttemptCounter, maxAttempts int, window time.Duration) Option {
+	return func(s *Service) {
+		s.loginAttempts = counter
+		if maxAttempts > 0 {
+			s.maxLoginAttempts = maxAttempts
+		}
+		if window > 0 {
+			s.loginAttemptWindow = window
+		}
+	}
+}
+
+// WithBcryptCost sets the bcrypt cost new password hashes are made with. Costs outside
+// bcrypt's supported range keep the default cost.
+func WithBcryptCost(cost int) Option {
+	return func(s *Service) {
+		if cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+			s.bcryptCost = cost
+		}
+	}
+}
+
+// WithPageSizes sets the contact list page size used when none is requested and the
+// largest page size allowed. Non-positive values keep the defaults of 10 and 100.
+func WithPageSizes(defaultSize, maxSize int) Option {
+	return func(s *Service) {
+		if maxSize > 0 {
+			s.maxPageSize = maxSize
+		}
+		if defaultSize > 0 {
+			s.defaultPageSize = defaultSize
+		}
+		if s.defaultPageSize > s.maxPageSize {
+			s.defaultPageSize = s.maxPageSize
+		}
+	}
+}
+
+// WithEmailSender sets the sender used to deliver verification and password reset emails
+func WithEmailSender(sender EmailSender) Option {
+	return func(s *Service) {
+		s.emailSender = sender
+	}
+}
+
+// WithAuditLogRepository enables audit logging of sensitive actions
+func WithAuditLogRepository(repo repository.AuditLogRepository) Option {
+	return func(s *Service) {
+		s.auditLogRepo = repo
+	}
+}
+
+// WithSessionRepository tracks signed-in sessions so users can list and revoke them
+func WithSessionRepository(repo repository.SessionRepository) Option {
+	return func(s *Service) {
+		s.sessionRepo = repo
+	}
+}
+
+// WithAvatarStorage sets the storage uploaded avatars are saved to
+func WithAvatarStorage(storage FileStorage) Option {
+	return func(s *Service) {
+		s.avatarStorage = storage
+	}
+}
+
+// WithProfileCache serves GetProfile from cache for ttl after the first read.
+// Non-positive values keep the one-minute default.
+func WithProfileCache(cache ProfileCache, ttl time.Duration) Option {
+	return func(s *Service) {
+		s.profileCache = cache
+		if ttl > 0 {
+			s.profileCacheTTL = ttl
+		}
+	}
+}
+
+// WithLastSeenThrottle tracks when users were last active, writing it at most once a
+// minute per user as the throttle allows
+func WithLastSeenThrottle(throttle LastSeenThrottle) Option {
+	return func(s *Service) {
+		s.lastSeenThrottle = throttle
+	}
+}
+
+// WithEventPublisher publishes contact create, update and delete events, e.g. to webhooks
+func WithEventPublisher(publisher EventPublisher) Option {
+	return func(s *Service) {
+		s.eventPublisher = publisher
+	}
+}
+
+// WithPhoneNormalization stores contact phone numbers in +62 format so that
+// 0812..., 62812... and +62812... are treated as the same number.
+// Numbers that already carry another country code are left unchanged.
+func WithPhoneNormalization(enabled bool) Option {
+	return func(s *Service) {
+		s.normalizePhones = enabled
+	}
+}
+
+// WithDuplicateContactPhones lets contacts share a phone number, e.g. an office line.
+// Duplicates are then reported as warnings on the contact instead of rejected.
+func WithDuplicateContactPhones(allowed bool) Option {
+	return func(s *Service) {
+		s.allowDuplicatePhones = allowed
+	}
+}
+
+// WithMaxContactsPerUser limits how many contacts a user may have. Zero or a negative
+// value means unlimited.
+func WithMaxContactsPerUser(max int) Option {
+	return func(s *Service) {
+		s.maxContactsPerUser = max
+	}
+}
+
+// WithStrictPasswordPolicy requires new passwords to contain upper and lower case letters
+// and digits in addition to the minimum length
+func WithStrictPasswordPolicy(strict bool) Option {
+	return func(s *Service) {
+		s.strictPasswordPolicy = strict
+	}
+}
+
+// WithRejectDeactivatedTokens makes ValidateToken look up the user and reject tokens of
+// deactivated accounts. This costs a database query per authenticated request.
+func WithRejectDeactivatedTokens(reject bool) Option {
+	return func(s *Service) {
+		s.rejectDeactivatedTokens = reject
+	}
+}
+
+// WithRequireEmailVerification blocks login until the user's email is verified
+func WithRequireEmailVerification(required bool) Option {
+	return func(s *Service) {
+		s.requireEmailVerification = required
+	}
+}
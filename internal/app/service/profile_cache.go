@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/logger"
+)
+
+// defaultProfileCacheTTL is how long a cached profile is served. Keep it short: a read
+// racing an update can cache the old profile, which then lives until it expires.
+const defaultProfileCacheTTL = time.Minute
+
+// ProfileCache stores encoded profiles by user ID
+type ProfileCache interface {
+	// Get returns the profile cached for a user and whether one exists
+	Get(ctx context.Context, userID uint) ([]byte, bool, error)
+	// Set caches a user's profile for the given TTL
+	Set(ctx context.Context, userID uint, profile []byte, ttl time.Duration) error
+	// Delete removes a user's cached profile
+	Delete(ctx context.Context, userID uint) error
+}
+
+// cachedProfile returns the user's profile from the cache, or nil on a miss.
+// Cache failures are logged and treated as misses.
+func (s *Service) cachedProfile(ctx context.Context, userID uint) *models.UserResponse {
+	if s.profileCache == nil {
+		return nil
+	}
+
+	data, ok, err := s.profileCache.Get(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to read cached profile", "user_id", userID, "error", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	var profile models.UserResponse
+	if err := json.Unmarshal(data, &profile); err != nil {
+		logger.FromContext(ctx).Warn("Failed to decode cached profile", "user_id", userID, "error", err)
+		return nil
+	}
+	return &profile
+}
+
+// cacheProfile stores a profile in the cache; failures are logged
+func (s *Service) cacheProfile(ctx context.Context, profile *models.UserResponse) {
+	if s.profileCache == nil {
+		return
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to encode profile for caching", "user_id", profile.ID, "error", err)
+		return
+	}
+	if err := s.profileCache.Set(ctx, profile.ID, data, s.profileCacheTTL); err != nil {
+		logger.FromContext(ctx).Warn("Failed to cache profile", "user_id", profile.ID, "error", err)
+	}
+}
+
+// invalidateProfile drops the user's cached profile after the user changed; failures
+// are logged and leave the old profile until its TTL expires
+func (s *Service) invalidateProfile(ctx context.Context, userID uint) {
+	if s.profileCache == nil {
+		return
+	}
+	if err := s.profileCache.Delete(ctx, userID); err != nil {
+		logger.FromContext(ctx).Warn("Failed to invalidate cached profile", "user_id", userID, "error", err)
+	}
+}
@@ -2,16 +2,26 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"user-service/internal/app/models"
 	"user-service/internal/app/repository"
+	"user-service/internal/app/webhook"
+	"user-service/internal/logger"
+	"user-service/internal/utils"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -22,44 +32,208 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrInvalidEmail       = errors.New("invalid email format")
 	ErrInvalidPhone       = errors.New("invalid phone format")
+	ErrInvalidFullName    = errors.New("full name must not be empty")
 	ErrWeakPassword       = errors.New("password must be at least 8 characters")
 	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrPasswordUnchanged  = errors.New("new password must be different from the old password")
+	ErrEmailNotVerified   = errors.New("email address has not been verified")
+	ErrAccountDeactivated = errors.New("account is deactivated")
+	ErrTooManyAttempts    = errors.New("too many failed login attempts")
+	ErrAvatarTooLarge     = errors.New("avatar exceeds the maximum size")
+	ErrInvalidAvatarType  = errors.New("avatar must be a PNG or JPEG image")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrEmailUnavailable   = errors.New("no email sender is configured")
 
 	// Contact errors
-	ErrContactNotFound    = errors.New("contact not found")
-	ErrPhoneAlreadyExists = errors.New("phone number already exists")
-	ErrInvalidContactData = errors.New("invalid contact data")
-	ErrUnauthorizedAccess = errors.New("unauthorized access to contact")
+	ErrContactNotFound     = errors.New("contact not found")
+	ErrPhoneAlreadyExists  = errors.New("phone number already exists")
+	ErrInvalidContactData  = errors.New("invalid contact data")
+	ErrInvalidSortField    = errors.New("invalid sort field")
+	ErrInvalidSortOrder    = errors.New("invalid sort order")
+	ErrInvalidSearchField  = errors.New("invalid search field")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrInvalidTags         = errors.New("invalid tags")
+	ErrInvalidBirthday     = errors.New("invalid birthday")
+	ErrNotesTooLong        = errors.New("notes are too long")
+	ErrContactLimitReached = errors.New("contact limit reached")
 )
 
 // Email validation regex
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
-// Phone validation regex (International format)
-// Supports: +6281234567890, +1234567890, 081234567890, etc.
-// Minimum 10 characters total (including country code), maximum 16
-var phoneRegex = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+// Token types carried in the token_type claim
+const (
+	TokenTypeAccess            = "access"
+	TokenTypeRefresh           = "refresh"
+	TokenTypeEmailVerification = "email_verification"
+	TokenTypePasswordReset     = "password_reset"
+)
+
+// Token issuer and default access token audience
+const (
+	tokenIssuer          = "user-service"
+	defaultTokenAudience = "user-service"
+)
+
+// Token lifetimes
+const (
+	defaultAccessTokenTTL     = 24 * time.Hour
+	refreshTokenTTL           = 7 * 24 * time.Hour
+	emailVerificationTokenTTL = 24 * time.Hour
+	passwordResetTokenTTL     = 15 * time.Minute
+)
+
+// Avatar upload limits
+const maxAvatarSize = 2 << 20 // 2MB
+
+// avatarExtensions maps the accepted avatar content types to file extensions
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// Default contact list page sizes
+const (
+	defaultPageSize    = 10
+	defaultMaxPageSize = 100
+)
+
+// maxNotesLength is the maximum number of characters in a contact note
+const maxNotesLength = 2000
+
+// maxBirthdayWindowDays bounds how far ahead upcoming birthdays are looked up
+const maxBirthdayWindowDays = 366
+
+// Recently added contacts shortcut sizes
+const (
+	defaultRecentContacts = 5
+	maxRecentContacts     = 20
+)
+
+// Contact tag limits
+const (
+	maxTagsPerContact = 10
+	maxTagLength      = 30
+)
+
+// Login rate limiting defaults
+const (
+	defaultMaxLoginAttempts   = 5
+	defaultLoginAttemptWindow = 15 * time.Minute
+)
 
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID   uint   `json:"user_id"`
-	Email    string `json:"email"`
-	FullName string `json:"full_name"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	FullName  string `json:"full_name"`
+	TokenType string `json:"token_type,omitempty"`
+	// SessionID is the session an access token was issued for
+	SessionID string `json:"sid,omitempty"`
+	// PasswordFingerprint binds a password reset token to the password it replaces
+	PasswordFingerprint string `json:"pwd_fp,omitempty"`
+	// Role is the user's role when an access token was issued
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// RefreshClaims represents the refresh token claims
+type RefreshClaims struct {
+	UserID    uint   `json:"user_id"`
+	TokenType string `json:"token_type"`
+	// SessionID is the session a refresh token belongs to
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// TokenRevocationStore tracks revoked access tokens by their jti
+type TokenRevocationStore interface {
+	// Revoke marks a token ID as revoked for the given duration
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+	// IsRevoked reports whether a token ID has been revoked
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// LoginAttemptCounter counts failed login attempts per key within a sliding window
+type LoginAttemptCounter interface {
+	// Count returns the number of failures recorded for key within the window
+	Count(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Increment records a failure for key and returns the count within the window
+	Increment(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Reset clears all recorded failures for key
+	Reset(ctx context.Context, key string) error
+}
+
+// EmailSender delivers transactional emails such as verification links
+type EmailSender interface {
+	// SendVerificationEmail sends the email verification token to the given address
+	SendVerificationEmail(ctx context.Context, to, token string) error
+	// SendPasswordResetEmail sends the password reset token to the given address
+	SendPasswordResetEmail(ctx context.Context, to, token string) error
+}
+
+// FileStorage persists uploaded files such as avatars
+type FileStorage interface {
+	// Save stores data under name and returns the URL path it is served from
+	Save(ctx context.Context, name string, data []byte) (string, error)
+}
+
 type Service struct {
-	userRepo    repository.UserRepository
-	contactRepo repository.ContactRepository
-	jwtSecret   string
+	userRepo         repository.UserRepository
+	contactRepo      repository.ContactRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	auditLogRepo     repository.AuditLogRepository
+	sessionRepo      repository.SessionRepository
+	revocationStore  TokenRevocationStore
+	emailSender      EmailSender
+	avatarStorage    FileStorage
+	eventPublisher   EventPublisher
+	webhookResolver  webhook.Resolver
+	profileCache     ProfileCache
+	loginAttempts    LoginAttemptCounter
+	lastSeenThrottle LastSeenThrottle
+	jwtSecret        string
+	jwtAudience      string
+	jwtKeys          map[string]string
+	jwtActiveKID     string
+	rsaPrivateKey    *rsa.PrivateKey
+	rsaPublicKey     *rsa.PublicKey
+	accessTokenTTL   time.Duration
+
+	requireEmailVerification bool
+	rejectDeactivatedTokens  bool
+	strictPasswordPolicy     bool
+	normalizePhones          bool
+	allowDuplicatePhones     bool
+	defaultPageSize          int
+	maxPageSize              int
+	maxContactsPerUser       int
+	maxLoginAttempts         int
+	loginAttemptWindow       time.Duration
+	bcryptCost               int
+	profileCacheTTL          time.Duration
 }
 
-func NewService(userRepo repository.UserRepository, contactRepo repository.ContactRepository, jwtSecret string) *Service {
-	return &Service{
-		userRepo:    userRepo,
-		contactRepo: contactRepo,
-		jwtSecret:   jwtSecret,
+func NewService(userRepo repository.UserRepository, contactRepo repository.ContactRepository, jwtSecret string, opts ...Option) *Service {
+	s := &Service{
+		userRepo:       userRepo,
+		contactRepo:    contactRepo,
+		jwtSecret:      jwtSecret,
+		jwtAudience:    defaultTokenAudience,
+		accessTokenTTL: defaultAccessTokenTTL,
+
+		maxLoginAttempts:   defaultMaxLoginAttempts,
+		loginAttemptWindow: defaultLoginAttemptWindow,
+		defaultPageSize:    defaultPageSize,
+		maxPageSize:        defaultMaxPageSize,
+		bcryptCost:         bcrypt.DefaultCost,
+		profileCacheTTL:    defaultProfileCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // ============================================================================
@@ -68,30 +242,27 @@ func NewService(userRepo repository.UserRepository, contactRepo repository.Conta
 
 // Register creates a new user account with hashed password
 func (s *Service) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthResponse, error) {
-	// Validate input
+	// Validate input, collecting every invalid field
+	var verr ValidationError
+	if req.FullName == "" {
+		verr.Add("full_name", ErrInvalidFullName, "is required")
+	}
 	if err := s.validateEmail(req.Email); err != nil {
-		return nil, err
+		verr.Add("email", err, "invalid format")
 	}
 
 	// Validate phone only if provided
 	if req.Phone != nil && *req.Phone != "" {
 		if err := s.validatePhone(*req.Phone); err != nil {
-			return nil, err
+			verr.Add("phone", err, "invalid format")
 		}
 	}
 
 	if err := s.validatePassword(req.Password); err != nil {
-		return nil, err
+		verr.Add("password", err, PasswordPolicyMessages(err)...)
 	}
-
-	// Normalize email
-	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
-	req.FullName = strings.TrimSpace(req.FullName)
-
-	// Normalize phone if provided
-	if req.Phone != nil {
-		trimmed := strings.TrimSpace(*req.Phone)
-		req.Phone = &trimmed
+	if err := verr.errOrNil(); err != nil {
+		return nil, err
 	}
 
 	// Check if email already exists
@@ -109,12 +280,14 @@ func (s *Service) Register(ctx context.Context, req *models.RegisterRequest) (*m
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Create user
+	// Create user (unverified until the emailed token is confirmed)
 	user := &models.User{
-		FullName: req.FullName,
-		Email:    req.Email,
-		Phone:    req.Phone,
-		Password: hashedPassword,
+		FullName:      req.FullName,
+		Email:         req.Email,
+		Phone:         req.Phone,
+		Password:      hashedPassword,
+		EmailVerified: false,
+		Role:          models.RoleUser,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
@@ -124,322 +297,1276 @@ func (s *Service) Register(ctx context.Context, req *models.RegisterRequest) (*m
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return nil, err
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  user.ToResponse(),
-	}, nil
+	// Generate access and refresh tokens
+	return s.issueTokens(ctx, user, "")
 }
 
 // Login authenticates a user and returns JWT token
 func (s *Service) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthResponse, error) {
-	// Normalize email
-	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	// Reject early while the email/IP pair is locked out
+	attemptKey := req.Email + "|" + req.ClientIP
+	if s.loginAttempts != nil {
+		failures, err := s.loginAttempts.Count(ctx, attemptKey, s.loginAttemptWindow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check login attempts: %w", err)
+		}
+		if failures >= int64(s.maxLoginAttempts) {
+			return nil, ErrTooManyAttempts
+		}
+	}
 
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrInvalidCredentials
+			return nil, s.loginFailed(ctx, attemptKey)
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Verify password
 	if err := s.verifyPassword(user.Password, req.Password); err != nil {
-		return nil, ErrInvalidCredentials
+		return nil, s.loginFailed(ctx, attemptKey)
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(user)
+	// Upgrade hashes made with a lower cost while the plaintext is at hand
+	if needsRehash(user.Password, s.bcryptCost) {
+		s.rehashPassword(ctx, user, req.Password)
+	}
+
+	if s.loginAttempts != nil {
+		if err := s.loginAttempts.Reset(ctx, attemptKey); err != nil {
+			return nil, fmt.Errorf("failed to reset login attempts: %w", err)
+		}
+	}
+
+	if !user.IsActive() {
+		return nil, ErrAccountDeactivated
+	}
+
+	if s.requireEmailVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Generate access and refresh tokens
+	resp, err := s.issueTokens(ctx, user, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  user.ToResponse(),
-	}, nil
+	s.recordAudit(ctx, user.ID, models.AuditActionLogin, userAuditTarget(user.ID))
+	return resp, nil
 }
 
-// GetProfile retrieves user profile by ID
-func (s *Service) GetProfile(ctx context.Context, userID uint) (*models.UserResponse, error) {
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrUserNotFound
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+// loginFailed records a failed login attempt and returns the error to report to the caller
+func (s *Service) loginFailed(ctx context.Context, attemptKey string) error {
+	if s.loginAttempts == nil {
+		return ErrInvalidCredentials
 	}
-	return user.ToResponse(), nil
+	if _, err := s.loginAttempts.Increment(ctx, attemptKey, s.loginAttemptWindow); err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return ErrInvalidCredentials
 }
 
-// UpdateProfile updates user profile information
-func (s *Service) UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.UserResponse, error) {
-	// Get existing user
-	user, err := s.userRepo.GetByID(ctx, userID)
+// RefreshToken exchanges a valid refresh token for a new access and refresh token pair.
+// The presented refresh token is revoked (rotated) when a token repository is configured.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	claims, err := s.parseRefreshToken(refreshToken)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrUserNotFound
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, ErrInvalidToken
 	}
 
-	// Update fields if provided
-	if req.FullName != "" {
-		user.FullName = strings.TrimSpace(req.FullName)
+	if s.refreshTokenRepo != nil {
+		stored, err := s.refreshTokenRepo.GetByJTI(ctx, claims.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrInvalidToken
+			}
+			return nil, fmt.Errorf("failed to get refresh token: %w", err)
+		}
+		if stored.RevokedAt != nil || stored.UserID != claims.UserID {
+			return nil, ErrInvalidToken
+		}
+
+		// Rotate: a refresh token can only be used once
+		if err := s.refreshTokenRepo.Revoke(ctx, claims.ID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrInvalidToken
+			}
+			return nil, fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
 	}
 
-	if req.Phone != "" {
-		if err := s.validatePhone(req.Phone); err != nil {
-			return nil, err
+	// A revoked session can no longer be refreshed
+	if s.sessionRepo != nil && claims.SessionID != "" {
+		session, err := s.sessionRepo.GetByJTI(ctx, claims.SessionID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrInvalidToken
+			}
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+		if session.RevokedAt != nil || session.UserID != claims.UserID {
+			return nil, ErrInvalidToken
 		}
-		trimmed := strings.TrimSpace(req.Phone)
-		user.Phone = &trimmed
 	}
 
-	if req.AvatarURL != nil {
-		user.AvatarURL = req.AvatarURL
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountDeactivated
 	}
 
-	// Update in database
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+	return s.issueTokens(ctx, user, claims.SessionID)
+}
+
+// CheckEmailAvailable reports whether no account is registered with email yet
+func (s *Service) CheckEmailAvailable(ctx context.Context, email string) (bool, error) {
+	if err := s.validateEmail(email); err != nil {
+		return false, err
 	}
 
-	return user.ToResponse(), nil
+	exists, err := s.userRepo.CheckEmailExists(ctx, strings.ToLower(strings.TrimSpace(email)), 0)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return !exists, nil
 }
 
-// DeleteAccount deletes user account
-func (s *Service) DeleteAccount(ctx context.Context, userID uint) error {
-	// Check if user exists
-	_, err := s.userRepo.GetByID(ctx, userID)
+// VerifyEmail marks the user's email as verified using a token issued at registration
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.parsePurposeToken(token, TokenTypeEmailVerification)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return ErrUserNotFound
+			return ErrInvalidToken
 		}
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Delete user (cascade delete contacts via FK)
-	if err := s.userRepo.Delete(ctx, userID); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrUserNotFound
-		}
-		return fmt.Errorf("failed to delete user: %w", err)
+	// A token issued for a previous email address must not verify the current one
+	if user.Email != claims.Email {
+		return ErrInvalidToken
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	user.EmailVerified = true
+	if err := s.userRepo.Update(ctx, user, "email_verified"); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
 	}
 
+	s.invalidateProfile(ctx, user.ID)
 	return nil
 }
 
-// ValidateToken validates JWT token and returns user ID
-func (s *Service) ValidateToken(tokenString string) (uint, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.jwtSecret), nil
-	})
+// RequestPasswordReset emails a single-use password reset token to the user with the
+// given email. Without an email sender it fails with ErrEmailUnavailable for every email,
+// registered or not.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.emailSender == nil {
+		return ErrEmailUnavailable
+	}
 
+	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return 0, ErrInvalidToken
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
-		return 0, ErrInvalidToken
+	token, err := s.generatePasswordResetToken(user)
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
 	}
 
-	return claims.UserID, nil
+	// Send in the background so registered emails are not told apart from unknown ones by
+	// the SMTP round trip. Delivery failures are only logged for the same reason.
+	go func(ctx context.Context) {
+		if err := s.emailSender.SendPasswordResetEmail(ctx, user.Email, token); err != nil {
+			logger.FromContext(ctx).Warn("Failed to send password reset email", "user_id", user.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+	return nil
 }
 
-// ============================================================================
-// CONTACT SERVICE METHODS
-// ============================================================================
-
-// CreateContact creates a new contact for a user
-func (s *Service) CreateContact(ctx context.Context, userID uint, req *models.CreateContactRequest) (*models.ContactResponse, error) {
-	// Validate input
-	if req.FullName == "" {
-		return nil, fmt.Errorf("%w: full name is required", ErrInvalidContactData)
-	}
-	if req.Phone == "" {
-		return nil, fmt.Errorf("%w: phone is required", ErrInvalidContactData)
-	}
-	if err := s.validatePhone(req.Phone); err != nil {
-		return nil, err
+// ResetPassword sets a new password using a token issued by RequestPasswordReset
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	claims, err := s.parsePurposeToken(token, TokenTypePasswordReset)
+	if err != nil {
+		return ErrInvalidToken
 	}
 
-	// Validate email if provided
-	if req.Email != nil && *req.Email != "" {
-		if err := s.validateEmail(*req.Email); err != nil {
-			return nil, err
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidToken
 		}
-		normalized := strings.ToLower(strings.TrimSpace(*req.Email))
-		req.Email = &normalized
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Normalize fields
-	req.FullName = strings.TrimSpace(req.FullName)
-	req.Phone = strings.TrimSpace(req.Phone)
-
-	// Check if phone already exists for this user
-	exists, err := s.contactRepo.CheckPhoneExists(ctx, userID, req.Phone, 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check phone: %w", err)
+	// The fingerprint changes with the password, so a token stops working once it has been used
+	if !hmac.Equal([]byte(claims.PasswordFingerprint), []byte(s.passwordFingerprint(user.Password))) {
+		return ErrInvalidToken
 	}
-	if exists {
-		return nil, ErrPhoneAlreadyExists
+
+	if err := s.validatePassword(newPassword); err != nil {
+		return err
 	}
 
-	// Create contact
-	contact := &models.Contact{
-		UserID:   userID,
-		FullName: req.FullName,
-		Phone:    req.Phone,
-		Email:    req.Email,
-		Favorite: false,
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
+	user.Password = hashedPassword
 
-	if err := s.contactRepo.Create(ctx, contact); err != nil {
-		return nil, fmt.Errorf("failed to create contact: %w", err)
+	if err := s.userRepo.Update(ctx, user, "password"); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
 	}
 
-	return contact.ToResponse(), nil
+	s.invalidateProfile(ctx, user.ID)
+	return nil
 }
 
-// GetContact retrieves a contact by ID
-func (s *Service) GetContact(ctx context.Context, userID, contactID uint) (*models.ContactResponse, error) {
-	contact, err := s.contactRepo.GetByID(ctx, userID, contactID)
+// GetProfile retrieves user profile by ID
+func (s *Service) GetProfile(ctx context.Context, userID uint) (*models.UserResponse, error) {
+	if profile := s.cachedProfile(ctx, userID); profile != nil {
+		return profile, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrContactNotFound
+			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get contact: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Verify ownership
-	if contact.UserID != userID {
-		return nil, ErrUnauthorizedAccess
+	profile := user.ToResponse()
+	s.cacheProfile(ctx, profile)
+	return profile, nil
+}
+
+// CountContacts returns how many contacts a user has
+func (s *Service) CountContacts(ctx context.Context, userID uint) (int64, error) {
+	count, err := s.contactRepo.Count(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count contacts: %w", err)
 	}
+	return count, nil
+}
 
-	return contact.ToResponse(), nil
+// ContactStats returns aggregate counts of a user's contacts
+func (s *Service) ContactStats(ctx context.Context, userID uint) (*models.ContactStats, error) {
+	stats, err := s.contactRepo.Stats(ctx, userID, time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact stats: %w", err)
+	}
+	return stats, nil
 }
 
-// UpdateContact updates an existing contact
-func (s *Service) UpdateContact(ctx context.Context, userID, contactID uint, req *models.UpdateContactRequest) (*models.ContactResponse, error) {
-	// Get existing contact
-	contact, err := s.contactRepo.GetByID(ctx, userID, contactID)
+// UpdateProfile updates user profile information
+func (s *Service) UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.UserResponse, error) {
+	// Get existing user
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrContactNotFound
+			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get contact: %w", err)
-	}
-
-	// Verify ownership
-	if contact.UserID != userID {
-		return nil, ErrUnauthorizedAccess
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Update fields if provided
+	// Update fields if provided; nil leaves a field unchanged. Only the columns
+	// requested are written.
+	var columns []string
 	if req.FullName != nil {
-		contact.FullName = strings.TrimSpace(*req.FullName)
+		if *req.FullName == "" {
+			return nil, ErrInvalidFullName
+		}
+		user.FullName = *req.FullName
+		columns = append(columns, "full_name")
 	}
 
 	if req.Phone != nil {
-		if err := s.validatePhone(*req.Phone); err != nil {
-			return nil, err
+		phone := *req.Phone
+		if phone == "" {
+			// An explicit empty string clears the phone
+			user.Phone = nil
+		} else {
+			if err := s.validatePhone(phone); err != nil {
+				return nil, err
+			}
+			user.Phone = &phone
 		}
-		phone := strings.TrimSpace(*req.Phone)
+		columns = append(columns, "phone")
+	}
 
-		// Check if new phone already exists (excluding current contact)
-		exists, err := s.contactRepo.CheckPhoneExists(ctx, userID, phone, contactID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check phone: %w", err)
-		}
-		if exists {
-			return nil, ErrPhoneAlreadyExists
+	if req.AvatarURL != nil {
+		if avatarURL := *req.AvatarURL; avatarURL == "" {
+			user.AvatarURL = nil
+		} else {
+			user.AvatarURL = &avatarURL
 		}
-		contact.Phone = phone
+		columns = append(columns, "avatar_url")
 	}
 
+	emailChanged := false
 	if req.Email != nil {
-		if *req.Email != "" {
-			if err := s.validateEmail(*req.Email); err != nil {
-				return nil, err
+		if err := s.validateEmail(*req.Email); err != nil {
+			return nil, err
+		}
+		email := *req.Email
+		if email != user.Email {
+			exists, err := s.userRepo.CheckEmailExists(ctx, email, userID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check email: %w", err)
+			}
+			if exists {
+				return nil, ErrEmailAlreadyExists
+			}
+			user.Email = email
+			emailChanged = true
+			// The new address has to be confirmed before it counts as verified
+			if s.requireEmailVerification {
+				user.EmailVerified = false
+				columns = append(columns, "email_verified")
 			}
-			normalized := strings.ToLower(strings.TrimSpace(*req.Email))
-			contact.Email = &normalized
-		} else {
-			contact.Email = nil
 		}
+		columns = append(columns, "email")
 	}
 
-	if req.Favorite != nil {
-		contact.Favorite = *req.Favorite
+	// Update in database
+	if len(columns) == 0 {
+		return user.ToResponse(), nil
+	}
+	if err := s.userRepo.Update(ctx, user, columns...); err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return nil, ErrEmailAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
-	// Update in database
-	if err := s.contactRepo.Update(ctx, contact); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrContactNotFound
+	if emailChanged && s.requireEmailVerification {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("failed to update contact: %w", err)
 	}
 
-	return contact.ToResponse(), nil
+	s.invalidateProfile(ctx, userID)
+	return user.ToResponse(), nil
 }
 
-// DeleteContact deletes a contact
-func (s *Service) DeleteContact(ctx context.Context, userID, contactID uint) error {
-	// Check if contact exists and belongs to user
-	contact, err := s.contactRepo.GetByID(ctx, userID, contactID)
+// UpdateAvatar validates an uploaded avatar image, stores it and sets it as the user's avatar.
+// The image type is detected from its content; contentType, when given, must agree with it.
+func (s *Service) UpdateAvatar(ctx context.Context, userID uint, data []byte, contentType string) (string, error) {
+	if len(data) > maxAvatarSize {
+		return "", ErrAvatarTooLarge
+	}
+
+	detected := http.DetectContentType(data)
+	ext, ok := avatarExtensions[detected]
+	if !ok {
+		return "", ErrInvalidAvatarType
+	}
+	if contentType != "" && contentType != detected {
+		return "", ErrInvalidAvatarType
+	}
+
+	if s.avatarStorage == nil {
+		return "", errors.New("avatar storage is not configured")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return ErrContactNotFound
+			return "", ErrUserNotFound
 		}
-		return fmt.Errorf("failed to get contact: %w", err)
+		return "", fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Verify ownership
-	if contact.UserID != userID {
-		return ErrUnauthorizedAccess
+	name := fmt.Sprintf("%d-%s%s", userID, uuid.NewString(), ext)
+	avatarURL, err := s.avatarStorage.Save(ctx, name, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to store avatar: %w", err)
 	}
 
-	// Delete contact
-	if err := s.contactRepo.Delete(ctx, userID, contactID); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrContactNotFound
-		}
-		return fmt.Errorf("failed to delete contact: %w", err)
+	user.AvatarURL = &avatarURL
+	if err := s.userRepo.Update(ctx, user, "avatar_url"); err != nil {
+		return "", fmt.Errorf("failed to update user: %w", err)
 	}
 
-	return nil
+	s.invalidateProfile(ctx, userID)
+	return avatarURL, nil
 }
 
-// ListContacts retrieves contacts with pagination and filtering
-func (s *Service) ListContacts(ctx context.Context, userID uint, req *models.ListContactsRequest) (*models.PaginatedResponse, error) {
-	// Set default values
-	if req.Page < 1 {
-		req.Page = 1
+// ChangePassword verifies the current password and replaces it with a new one
+func (s *Service) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Verify old password
+	if err := s.verifyPassword(user.Password, oldPassword); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	if oldPassword == newPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = hashedPassword
+
+	if err := s.userRepo.Update(ctx, user, "password"); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	s.invalidateProfile(ctx, userID)
+	s.recordAudit(ctx, userID, models.AuditActionPasswordChange, userAuditTarget(userID))
+	return nil
+}
+
+// DeleteAccount permanently deletes the user account and all its data once the user's
+// password is confirmed. It cannot be restored.
+func (s *Service) DeleteAccount(ctx context.Context, userID uint, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.verifyPassword(user.Password, password); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.userRepo.Purge(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	s.invalidateProfile(ctx, userID)
+	// The user's audit log went with the account; this entry only records the deletion
+	s.recordAudit(ctx, userID, models.AuditActionAccountDelete, userAuditTarget(userID))
+	return nil
+}
+
+// RestoreAccount undoes the soft delete of a user account; accounts removed through
+// DeleteAccount are gone for good. It is an operator action and is not exposed through
+// the public API.
+func (s *Service) RestoreAccount(ctx context.Context, userID uint) error {
+	if err := s.userRepo.Restore(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+	return nil
+}
+
+// DeactivateAccount disables login for the user while keeping their data
+func (s *Service) DeactivateAccount(ctx context.Context, userID uint) error {
+	return s.setAccountActive(ctx, userID, false)
+}
+
+// ReactivateAccount restores access to a deactivated account
+func (s *Service) ReactivateAccount(ctx context.Context, userID uint) error {
+	return s.setAccountActive(ctx, userID, true)
+}
+
+func (s *Service) setAccountActive(ctx context.Context, userID uint, active bool) error {
+	if err := s.userRepo.SetActive(ctx, userID, active); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to update account status: %w", err)
+	}
+	return nil
+}
+
+// ValidateToken validates JWT token and returns user ID. ctx bounds the revocation and
+// account lookups, so it should be the request's context.
+func (s *Service) ValidateToken(ctx context.Context, tokenString string) (uint, error) {
+	claims, err := s.ValidateTokenClaims(ctx, tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// ValidateTokenClaims validates JWT token like ValidateToken and returns its claims
+func (s *Service) ValidateTokenClaims(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	claims, err := s.parseAccessToken(tokenString)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Reject tokens revoked via logout
+	if s.revocationStore != nil && claims.ID != "" {
+		revoked, err := s.revocationStore.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	// Reject tokens derived from a revoked session
+	if s.revocationStore != nil && claims.SessionID != "" {
+		revoked, err := s.revocationStore.IsRevoked(ctx, claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check session revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	// Optionally reject tokens issued before the account was deactivated
+	if s.rejectDeactivatedTokens {
+		user, err := s.userRepo.GetByID(ctx, claims.UserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrInvalidToken
+			}
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if !user.IsActive() {
+			return nil, ErrAccountDeactivated
+		}
+	}
+
+	return claims, nil
+}
+
+// IntrospectToken returns the claims of an access token and whether it is still active.
+// Expired tokens still yield their claims, reported as inactive, as do revoked tokens and
+// tokens of deactivated accounts. Tokens that fail any other check return ErrInvalidToken.
+func (s *Service) IntrospectToken(ctx context.Context, tokenString string) (*JWTClaims, bool, error) {
+	claims, err := s.parseAccessToken(tokenString)
+	if err != nil {
+		if !errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, false, ErrInvalidToken
+		}
+		claims, err = s.parseExpiredAccessToken(tokenString)
+		if err != nil {
+			return nil, false, ErrInvalidToken
+		}
+		return claims, false, nil
+	}
+
+	// Apply the same revocation and deactivation checks as authentication
+	if _, err := s.ValidateToken(ctx, tokenString); err != nil {
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrAccountDeactivated) {
+			return claims, false, nil
+		}
+		return nil, false, err
+	}
+	return claims, true, nil
+}
+
+// parseExpiredAccessToken validates an expired access token as of just before it expired,
+// so its signature, issuer, audience and type are still checked
+func (s *Service) parseExpiredAccessToken(tokenString string) (*JWTClaims, error) {
+	unverified := &JWTClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, unverified); err != nil {
+		return nil, err
+	}
+	if unverified.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	beforeExpiry := unverified.ExpiresAt.Add(-time.Second)
+	return s.parseAccessToken(tokenString, jwt.WithTimeFunc(func() time.Time { return beforeExpiry }))
+}
+
+// Logout revokes an access token for the rest of its lifetime
+func (s *Service) Logout(ctx context.Context, tokenString string) error {
+	claims, err := s.parseAccessToken(tokenString)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	// Without a revocation store (or for legacy tokens without a jti) the client just discards the token
+	if s.revocationStore == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if err := s.revocationStore.Revoke(ctx, claims.ID, ttl); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// CONTACT SERVICE METHODS
+// ============================================================================
+
+// CreateContact creates a new contact for a user
+func (s *Service) CreateContact(ctx context.Context, userID uint, req *models.CreateContactRequest) (*models.ContactResponse, error) {
+	// Validate input, collecting every invalid field
+	var verr ValidationError
+	if req.FullName == "" {
+		verr.Add("full_name", ErrInvalidContactData, "is required")
+	}
+	if req.Phone == "" {
+		verr.Add("phone", ErrInvalidContactData, "is required")
+	} else if err := s.validatePhone(req.Phone); err != nil {
+		verr.Add("phone", err, "invalid format")
+	}
+
+	// Validate email if provided
+	if req.Email != nil && *req.Email != "" {
+		if err := s.validateEmail(*req.Email); err != nil {
+			verr.Add("email", err, "invalid format")
+		}
+	}
+
+	tags, err := s.normalizeTags(req.Tags)
+	if err != nil {
+		verr.Add("tags", err, fmt.Sprintf("must be at most %d tags of up to %d characters", maxTagsPerContact, maxTagLength))
+	}
+	var birthday *time.Time
+	if req.Birthday != nil && *req.Birthday != "" {
+		if birthday, err = parseBirthday(*req.Birthday, time.Now()); err != nil {
+			verr.Add("birthday", err, "must be a past date in YYYY-MM-DD format")
+		}
+	}
+	notes, err := normalizeNotes(req.Notes)
+	if err != nil {
+		verr.Add("notes", err, fmt.Sprintf("must be at most %d characters", maxNotesLength))
+	}
+	var phones []models.ContactPhone
+	if req.Phone != "" {
+		if phones, err = s.contactPhones(s.normalizePhone(req.Phone), req.Phones); err != nil {
+			verr.Add("phones", err, "must be valid numbers with at most one primary")
+		}
+	}
+	emails, err := s.contactEmails(req.Email, req.Emails)
+	if err != nil {
+		verr.Add("emails", err, "must be valid addresses with at most one primary")
+	}
+	if err := verr.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	// The primary entries may differ from the phone and email given
+	req.Phone = primaryPhone(phones)
+	req.Email = primaryEmail(emails)
+
+	// Check if any of the numbers already exists for this user
+	var warnings []string
+	shared := false
+	for _, phone := range phones {
+		exists, err := s.contactRepo.CheckPhoneExists(ctx, userID, phone.Phone, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check phone: %w", err)
+		}
+		if exists {
+			if !s.allowDuplicatePhones {
+				return nil, ErrPhoneAlreadyExists
+			}
+			warnings = append(warnings, duplicatePhoneWarning(phone.Phone))
+			// Only the primary number is covered by the unique index
+			shared = shared || phone.Phone == req.Phone
+		}
+	}
+
+	// Create contact
+	contact := &models.Contact{
+		UserID:       userID,
+		FullName:     req.FullName,
+		Phone:        req.Phone,
+		PhoneCountry: phoneCountry(req.Phone),
+		PhoneShared:  shared,
+		Email:        req.Email,
+		Favorite:     req.Favorite,
+		Birthday:     birthday,
+		Notes:        notes,
+		Phones:       phones,
+		Emails:       emails,
+	}
+	if len(tags) > 0 {
+		contact.Tags = tags
+	}
+
+	// The repository enforces the contact limit in the insert transaction
+	if err := s.contactRepo.Create(ctx, contact, s.maxContactsPerUser); err != nil {
+		// Lost a race with a concurrent create of the same phone
+		if errors.Is(err, repository.ErrDuplicatePhone) {
+			return nil, ErrPhoneAlreadyExists
+		}
+		if errors.Is(err, repository.ErrLimitReached) {
+			return nil, fmt.Errorf("%w: at most %d contacts per account", ErrContactLimitReached, s.maxContactsPerUser)
+		}
+		return nil, fmt.Errorf("failed to create contact: %w", err)
+	}
+
+	resp := contact.ToResponse()
+	resp.Warnings = warnings
+	s.publishContactEvent(webhook.EventContactCreated, userID, resp)
+	return resp, nil
+}
+
+// ImportError describes why a single imported row was rejected
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportResult summarizes a bulk contact import
+type ImportResult struct {
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+	// Warnings are about data the file held for imported rows that was left out
+	Warnings []ImportError `json:"warnings,omitempty"`
+}
+
+// ImportContacts creates contacts in bulk. Each row goes through the same validation as
+// CreateContact; invalid rows and duplicate phones are skipped and reported by their
+// 1-based position in rows. Once the contact limit is reached the remaining rows are
+// reported as failed. Unexpected errors abort the import.
+func (s *Service) ImportContacts(ctx context.Context, userID uint, rows []models.CreateContactRequest) (*ImportResult, error) {
+	result := &ImportResult{Errors: []ImportError{}}
+
+	for i := range rows {
+		_, err := s.CreateContact(ctx, userID, &rows[i])
+		if err == nil {
+			result.Imported++
+			continue
+		}
+
+		if errors.Is(err, ErrContactLimitReached) {
+			for row := i + 1; row <= len(rows); row++ {
+				result.Failed++
+				result.Errors = append(result.Errors, ImportError{Row: row, Message: err.Error()})
+			}
+			break
+		}
+
+		if errors.Is(err, ErrInvalidContactData) ||
+			errors.Is(err, ErrInvalidPhone) ||
+			errors.Is(err, ErrInvalidEmail) ||
+			errors.Is(err, ErrInvalidBirthday) ||
+			errors.Is(err, ErrNotesTooLong) ||
+			errors.Is(err, ErrPhoneAlreadyExists) {
+			result.Failed++
+			result.Errors = append(result.Errors, ImportError{Row: i + 1, Message: err.Error()})
+			continue
+		}
+
+		return nil, fmt.Errorf("failed to import row %d: %w", i+1, err)
+	}
+
+	return result, nil
+}
+
+// requireOwnedContact loads one of the user's contacts. The repository scopes the lookup
+// by user, so a contact owned by someone else is reported as ErrContactNotFound and its
+// existence is not revealed.
+func (s *Service) requireOwnedContact(ctx context.Context, userID, contactID uint) (*models.Contact, error) {
+	contact, err := s.contactRepo.GetByID(ctx, userID, contactID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrContactNotFound
+		}
+		return nil, fmt.Errorf("failed to get contact: %w", err)
+	}
+	return contact, nil
+}
+
+// GetContact retrieves a contact by ID
+func (s *Service) GetContact(ctx context.Context, userID, contactID uint) (*models.ContactResponse, error) {
+	contact, err := s.requireOwnedContact(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	return contact.ToResponse(), nil
+}
+
+// GetContactHistory returns the revisions of a contact, newest first. The history of a
+// soft-deleted contact stays readable because deleting it records a revision.
+func (s *Service) GetContactHistory(ctx context.Context, userID, contactID uint) ([]models.ContactRevision, error) {
+	revisions, err := s.contactRepo.ListRevisions(ctx, userID, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact history: %w", err)
+	}
+	if len(revisions) == 0 {
+		// Nothing recorded yet: tell an unchanged contact apart from one the user does not own
+		if _, err := s.requireOwnedContact(ctx, userID, contactID); err != nil {
+			return nil, err
+		}
+		return []models.ContactRevision{}, nil
+	}
+	return revisions, nil
+}
+
+// GetContactByPhone resolves a phone number to one of the user's contacts. The number is
+// matched exactly in its given, +62 and local 0 formats so 0812... finds a stored +62812...
+func (s *Service) GetContactByPhone(ctx context.Context, userID uint, phone string) (*models.ContactResponse, error) {
+	phone = strings.TrimSpace(phone)
+	if err := s.validatePhone(phone); err != nil {
+		return nil, err
+	}
+
+	contact, err := s.contactRepo.GetByPhone(ctx, userID, phoneLookupCandidates(phone))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrContactNotFound
+		}
+		return nil, fmt.Errorf("failed to get contact by phone: %w", err)
+	}
+
+	return contact.ToResponse(), nil
+}
+
+// UpdateContact updates an existing contact
+func (s *Service) UpdateContact(ctx context.Context, userID, contactID uint, req *models.UpdateContactRequest) (*models.ContactResponse, error) {
+	// Get existing contact
+	contact, err := s.requireOwnedContact(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields if provided
+	if req.FullName != nil {
+		contact.FullName = *req.FullName
+	}
+
+	var phones []models.ContactPhone
+	var warnings []string
+	if req.Phone != nil || req.Phones != nil {
+		if phones, err = s.updatedContactPhones(contact, req); err != nil {
+			if req.Phones == nil {
+				return nil, err
+			}
+			var verr ValidationError
+			verr.Add("phones", err, "must be valid numbers with at most one primary")
+			return nil, &verr
+		}
+
+		// Check if any new number already exists (excluding current contact). Only the
+		// primary number is covered by the unique index; an unchanged one keeps its flag.
+		primary := primaryPhone(phones)
+		shared := contact.PhoneShared && primary == contact.Phone
+		current := map[string]bool{contact.Phone: true}
+		for _, phone := range contact.Phones {
+			current[phone.Phone] = true
+		}
+		for _, phone := range phones {
+			if current[phone.Phone] {
+				continue
+			}
+			exists, err := s.contactRepo.CheckPhoneExists(ctx, userID, phone.Phone, contactID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check phone: %w", err)
+			}
+			if exists {
+				if !s.allowDuplicatePhones {
+					return nil, ErrPhoneAlreadyExists
+				}
+				warnings = append(warnings, duplicatePhoneWarning(phone.Phone))
+				shared = shared || phone.Phone == primary
+			}
+		}
+		contact.Phone = primary
+		contact.PhoneCountry = phoneCountry(contact.Phone)
+		contact.PhoneShared = shared
+		contact.Phones = phones
+	}
+
+	var emails []models.ContactEmail
+	if req.Email != nil || req.Emails != nil {
+		if req.Email != nil && *req.Email != "" {
+			if err := s.validateEmail(*req.Email); err != nil {
+				return nil, err
+			}
+		}
+		if emails, err = s.updatedContactEmails(contact, req); err != nil {
+			if req.Emails == nil {
+				return nil, err
+			}
+			var verr ValidationError
+			verr.Add("emails", err, "must be valid addresses with at most one primary")
+			return nil, &verr
+		}
+		contact.Email = primaryEmail(emails)
+		contact.Emails = emails
+	}
+
+	if req.Favorite != nil {
+		contact.Favorite = *req.Favorite
+	}
+
+	if req.Birthday != nil {
+		if *req.Birthday != "" {
+			birthday, err := parseBirthday(*req.Birthday, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			contact.Birthday = birthday
+		} else {
+			contact.Birthday = nil
+		}
+	}
+
+	if req.Notes != nil {
+		if contact.Notes, err = normalizeNotes(req.Notes); err != nil {
+			return nil, err
+		}
+	}
+
+	var tags []string
+	if req.Tags != nil {
+		tags, err = s.normalizeTags(*req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		// Set before updating so the recorded revision carries the new tags
+		contact.Tags = tags
+	}
+
+	// Update in database, together with the tags, phones and emails when they changed
+	children := repository.ContactChildren{Tags: tags, Phones: phones, Emails: emails}
+	if err := s.contactRepo.Update(ctx, contact, children); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrContactNotFound
+		}
+		if errors.Is(err, repository.ErrDuplicatePhone) {
+			return nil, ErrPhoneAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update contact: %w", err)
+	}
+
+	resp := contact.ToResponse()
+	resp.Warnings = warnings
+	s.publishContactEvent(webhook.EventContactUpdated, userID, resp)
+	return resp, nil
+}
+
+// DeleteContact deletes a contact
+func (s *Service) DeleteContact(ctx context.Context, userID, contactID uint) error {
+	// Check if contact exists and belongs to user
+	contact, err := s.requireOwnedContact(ctx, userID, contactID)
+	if err != nil {
+		return err
+	}
+
+	// Delete contact
+	if err := s.contactRepo.Delete(ctx, userID, contactID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrContactNotFound
+		}
+		return fmt.Errorf("failed to delete contact: %w", err)
+	}
+
+	s.recordAudit(ctx, userID, models.AuditActionContactDelete, contactAuditTarget(contactID))
+	s.publishContactEvent(webhook.EventContactDeleted, userID, contact.ToResponse())
+	return nil
+}
+
+// MergeContacts merges the source contact into the target and deletes the source.
+// The target wins for fields it already has; empty target fields are filled from the
+// source, the target becomes a favorite if either contact was, and tags are combined.
+func (s *Service) MergeContacts(ctx context.Context, userID, targetID, sourceID uint) (*models.ContactResponse, error) {
+	if targetID == sourceID {
+		return nil, fmt.Errorf("%w: cannot merge a contact into itself", ErrInvalidContactData)
+	}
+
+	// Both contacts must belong to the user
+	target, err := s.requireOwnedContact(ctx, userID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.requireOwnedContact(ctx, userID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.FullName == "" {
+		target.FullName = source.FullName
+	}
+	// Combine phones and emails, keeping the target's primary when it has one
+	target.Phones = mergedPhones(target, source)
+	target.Phone = primaryPhone(target.Phones)
+	target.Emails = mergedEmails(target, source)
+	target.Email = primaryEmail(target.Emails)
+	target.Favorite = target.Favorite || source.Favorite
+
+	// Combine tags, keeping the target's first and staying within the per-contact limit
+	seen := make(map[string]bool, len(target.Tags))
+	for _, tag := range target.Tags {
+		seen[tag] = true
+	}
+	for _, tag := range source.Tags {
+		if !seen[tag] && len(target.Tags) < maxTagsPerContact {
+			seen[tag] = true
+			target.Tags = append(target.Tags, tag)
+		}
+	}
+
+	if err := s.contactRepo.Merge(ctx, target, source.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrContactNotFound
+		}
+		return nil, fmt.Errorf("failed to merge contacts: %w", err)
+	}
+
+	// The source is gone now, so record it as deleted like DeleteContact does
+	s.recordAudit(ctx, userID, models.AuditActionContactDelete, contactAuditTarget(source.ID))
+
+	resp := target.ToResponse()
+	s.publishContactEvent(webhook.EventContactUpdated, userID, resp)
+	s.publishContactEvent(webhook.EventContactDeleted, userID, source.ToResponse())
+	return resp, nil
+}
+
+// DeleteContacts deletes the given contacts owned by the user and returns how many were deleted.
+// IDs that are missing or owned by another user are skipped, so callers can compare the count.
+// Like DeleteContact, it publishes a delete event per contact.
+func (s *Service) DeleteContacts(ctx context.Context, userID uint, ids []uint) (int, error) {
+	unique, err := uniqueContactIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.contactRepo.DeleteMany(ctx, userID, unique)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete contacts: %w", err)
+	}
+
+	if len(deleted) > 0 {
+		ids := make([]uint, len(deleted))
+		for i := range deleted {
+			ids[i] = deleted[i].ID
+		}
+		s.recordAudit(ctx, userID, models.AuditActionContactDelete, contactAuditTarget(ids...))
+	}
+	for i := range deleted {
+		s.publishContactEvent(webhook.EventContactDeleted, userID, deleted[i].ToResponse())
+	}
+	return len(deleted), nil
+}
+
+// SetFavorites stars or unstars the given contacts owned by the user in one statement and
+// returns how many were updated. IDs that are missing or owned by another user are skipped.
+// Like UpdateContact, it records a revision and publishes an update event per contact.
+func (s *Service) SetFavorites(ctx context.Context, userID uint, ids []uint, favorite bool) (int, error) {
+	unique, err := uniqueContactIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := s.contactRepo.SetFavorite(ctx, userID, unique, favorite)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set favorites: %w", err)
+	}
+
+	for i := range updated {
+		s.publishContactEvent(webhook.EventContactUpdated, userID, updated[i].ToResponse())
+	}
+	return len(updated), nil
+}
+
+// uniqueContactIDs drops zero and repeated IDs so an affected count is comparable to the
+// number of distinct IDs requested
+func uniqueContactIDs(ids []uint) ([]uint, error) {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("%w: at least one contact ID is required", ErrInvalidContactData)
+	}
+	return unique, nil
+}
+
+// RecentContacts returns the user's most recently created contacts, newest first. limit
+// defaults to 5 and is capped at 20.
+func (s *Service) RecentContacts(ctx context.Context, userID uint, limit int) ([]*models.ContactResponse, error) {
+	if limit < 1 {
+		limit = defaultRecentContacts
+	}
+	if limit > maxRecentContacts {
+		limit = maxRecentContacts
+	}
+
+	contacts, err := s.contactRepo.ListRecent(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent contacts: %w", err)
+	}
+
+	responses := make([]*models.ContactResponse, len(contacts))
+	for i := range contacts {
+		responses[i] = contacts[i].ToResponse()
+	}
+	return responses, nil
+}
+
+// UpcomingBirthdays returns the user's contacts whose birthday falls within the next days
+// days, today included, ordered by the nearest birthday first
+func (s *Service) UpcomingBirthdays(ctx context.Context, userID uint, days int) ([]*models.ContactResponse, error) {
+	if days < 1 || days > maxBirthdayWindowDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidContactData, maxBirthdayWindowDays)
+	}
+
+	contacts, err := s.contactRepo.ListWithBirthday(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list birthdays: %w", err)
+	}
+	return upcomingBirthdays(contacts, time.Now(), days), nil
+}
+
+// upcomingBirthdays picks the contacts whose next birthday is at most days after today.
+// Only month and day are compared, so a window starting in December reaches into
+// January; a February 29 birthday falls on March 1 in common years.
+func upcomingBirthdays(contacts []models.Contact, today time.Time, days int) []*models.ContactResponse {
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	type upcoming struct {
+		contact   *models.Contact
+		daysUntil int
+	}
+	var matches []upcoming
+	for i := range contacts {
+		birthday := contacts[i].Birthday
+		if birthday == nil {
+			continue
+		}
+		next := time.Date(start.Year(), birthday.Month(), birthday.Day(), 0, 0, 0, 0, time.UTC)
+		if next.Before(start) {
+			next = time.Date(start.Year()+1, birthday.Month(), birthday.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		daysUntil := int(next.Sub(start).Hours() / 24)
+		if daysUntil <= days {
+			matches = append(matches, upcoming{contact: &contacts[i], daysUntil: daysUntil})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].daysUntil != matches[j].daysUntil {
+			return matches[i].daysUntil < matches[j].daysUntil
+		}
+		return matches[i].contact.FullName < matches[j].contact.FullName
+	})
+
+	responses := make([]*models.ContactResponse, len(matches))
+	for i, match := range matches {
+		responses[i] = match.contact.ToResponse()
+	}
+	return responses
+}
+
+// RestoreContact recovers a soft-deleted contact
+func (s *Service) RestoreContact(ctx context.Context, userID, contactID uint) (*models.ContactResponse, error) {
+	if err := s.contactRepo.Restore(ctx, userID, contactID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrContactNotFound
+		}
+		if errors.Is(err, repository.ErrDuplicatePhone) {
+			return nil, ErrPhoneAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to restore contact: %w", err)
+	}
+
+	contact, err := s.requireOwnedContact(ctx, userID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	return contact.ToResponse(), nil
+}
+
+// ListContacts retrieves contacts with pagination and filtering
+func (s *Service) ListContacts(ctx context.Context, userID uint, req *models.ListContactsRequest) (*models.PaginatedResponse, error) {
+	// Set default values
+	if req.Page < 1 {
+		req.Page = 1
 	}
 	if req.Limit < 1 {
-		req.Limit = 10
+		req.Limit = s.defaultPageSize
+	}
+	if req.Limit > s.maxPageSize {
+		req.Limit = s.maxPageSize
+	}
+
+	// Trim search query
+	if req.Search != "" {
+		req.Search = strings.TrimSpace(req.Search)
+	}
+
+	req.Tag = strings.ToLower(strings.TrimSpace(req.Tag))
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+
+	// Validate sorting against the repository allowlist
+	req.Sort = strings.ToLower(strings.TrimSpace(req.Sort))
+	req.Order = strings.ToLower(strings.TrimSpace(req.Order))
+	if req.Sort != "" && !repository.IsValidContactSort(req.Sort) {
+		return nil, ErrInvalidSortField
+	}
+	if req.Order != "" && req.Order != "asc" && req.Order != "desc" {
+		return nil, ErrInvalidSortOrder
 	}
-	if req.Limit > 100 {
-		req.Limit = 100 // Max limit
+	for i, field := range req.SearchFields {
+		req.SearchFields[i] = strings.ToLower(strings.TrimSpace(field))
+		if !repository.IsValidContactSearchField(req.SearchFields[i]) {
+			return nil, ErrInvalidSearchField
+		}
 	}
 
-	// Trim search query
-	if req.Search != "" {
-		req.Search = strings.TrimSpace(req.Search)
+	// Keyset pagination only follows the default newest-first ordering
+	newestFirst := (req.Sort == "" || req.Sort == "created_at") && req.Order != "asc"
+	if req.Cursor != "" {
+		if !newestFirst {
+			return nil, ErrInvalidCursor
+		}
+		if _, _, err := repository.DecodeContactCursor(req.Cursor); err != nil {
+			return nil, ErrInvalidCursor
+		}
 	}
 
 	// Get contacts from repository
@@ -448,35 +1575,64 @@ func (s *Service) ListContacts(ctx context.Context, userID uint, req *models.Lis
 		return nil, fmt.Errorf("failed to list contacts: %w", err)
 	}
 
+	// Without a count, an extra row beyond the page means another page follows
+	hasMore := false
+	if !req.CountsTotal() && len(contacts) > req.Limit {
+		contacts = contacts[:req.Limit]
+		hasMore = true
+	}
+
 	// Convert to response format
 	contactResponses := make([]*models.ContactResponse, len(contacts))
 	for i, contact := range contacts {
 		contactResponses[i] = contact.ToResponse()
 	}
 
-	// Calculate pagination metadata
-	totalPages := int((total + int64(req.Limit) - 1) / int64(req.Limit))
+	// Calculate pagination metadata; -1 marks an unknown total
+	meta := models.PaginationMeta{
+		Page:        req.Page,
+		Limit:       req.Limit,
+		Total:       -1,
+		TotalPages:  -1,
+		HasNextPage: hasMore,
+		HasPrevPage: req.Page > 1,
+	}
+	if req.CountsTotal() {
+		meta.Total = total
+		meta.TotalPages = int((total + int64(req.Limit) - 1) / int64(req.Limit))
+		meta.HasNextPage = req.Page < meta.TotalPages
+	}
+
+	// A full newest-first page may be followed by another one
+	if newestFirst && len(contacts) == req.Limit && (req.CountsTotal() || hasMore) {
+		meta.NextCursor = repository.EncodeContactCursor(&contacts[len(contacts)-1])
+	}
+	if req.Cursor != "" {
+		meta.HasNextPage = meta.NextCursor != ""
+		meta.HasPrevPage = true
+	}
 
 	return &models.PaginatedResponse{
-		Data: contactResponses,
-		Pagination: models.PaginationMeta{
-			Page:        req.Page,
-			Limit:       req.Limit,
-			Total:       total,
-			TotalPages:  totalPages,
-			HasNextPage: req.Page < totalPages,
-			HasPrevPage: req.Page > 1,
-		},
+		Data:       contactResponses,
+		Pagination: meta,
 	}, nil
 }
 
+// ExportContacts retrieves all of a user's contacts for export
+func (s *Service) ExportContacts(ctx context.Context, userID uint) ([]models.Contact, error) {
+	contacts, err := s.contactRepo.ListAll(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export contacts: %w", err)
+	}
+	return contacts, nil
+}
+
 // ============================================================================
 // HELPER METHODS
 // ============================================================================
 
 // validateEmail validates email format
 func (s *Service) validateEmail(email string) error {
-	email = strings.TrimSpace(email)
 	if email == "" {
 		return fmt.Errorf("%w: email is required", ErrInvalidEmail)
 	}
@@ -493,54 +1649,686 @@ func (s *Service) validatePhone(phone string) error {
 	if phone == "" {
 		return fmt.Errorf("%w: phone is required", ErrInvalidPhone)
 	}
-	if !phoneRegex.MatchString(phone) {
+	if !utils.ValidatePhone(phone) {
 		return ErrInvalidPhone
 	}
 	return nil
 }
 
-// validatePassword validates password strength
+// normalizeTags trims, lowercases and deduplicates tags and enforces tag limits
+func (s *Service) normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("%w: tags must be at most %d characters", ErrInvalidTags, maxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerContact {
+		return nil, fmt.Errorf("%w: at most %d tags per contact", ErrInvalidTags, maxTagsPerContact)
+	}
+	return normalized, nil
+}
+
+// contactPhones builds the phone numbers of a contact from primary, an already
+// normalized phone, and the requested entries. primary stays the primary number unless
+// an entry is marked primary, and is kept even when not listed; without either the first
+// entry becomes primary. Entries are validated, normalized and deduplicated.
+func (s *Service) contactPhones(primary string, entries []models.ContactPhoneRequest) ([]models.ContactPhone, error) {
+	phones := make([]models.ContactPhone, 0, len(entries)+1)
+	index := make(map[string]int, len(entries)+1)
+	if primary != "" {
+		index[primary] = 0
+		phones = append(phones, models.ContactPhone{Phone: primary})
+	}
+
+	marked := -1
+	for _, entry := range entries {
+		if err := s.validatePhone(entry.Phone); err != nil {
+			return nil, err
+		}
+		phone := s.normalizePhone(entry.Phone)
+		i, ok := index[phone]
+		if !ok {
+			i = len(phones)
+			index[phone] = i
+			phones = append(phones, models.ContactPhone{Phone: phone})
+		}
+		if entry.Label != "" {
+			phones[i].Label = entry.Label
+		}
+		if entry.IsPrimary {
+			if marked >= 0 && marked != i {
+				return nil, fmt.Errorf("%w: only one phone can be primary", ErrInvalidContactData)
+			}
+			marked = i
+		}
+	}
+
+	if len(phones) == 0 {
+		return nil, fmt.Errorf("%w: at least one phone is required", ErrInvalidContactData)
+	}
+	if marked < 0 {
+		marked = 0
+	}
+	phones[marked].IsPrimary = true
+	return phones, nil
+}
+
+// contactEmails builds the email addresses of a contact like contactPhones does. A
+// contact may have no email, in which case the result is empty.
+func (s *Service) contactEmails(primary *string, entries []models.ContactEmailRequest) ([]models.ContactEmail, error) {
+	emails := make([]models.ContactEmail, 0, len(entries)+1)
+	index := make(map[string]int, len(entries)+1)
+	if primary != nil && *primary != "" {
+		index[*primary] = 0
+		emails = append(emails, models.ContactEmail{Email: *primary})
+	}
+
+	marked := -1
+	for _, entry := range entries {
+		if err := s.validateEmail(entry.Email); err != nil {
+			return nil, err
+		}
+		i, ok := index[entry.Email]
+		if !ok {
+			i = len(emails)
+			index[entry.Email] = i
+			emails = append(emails, models.ContactEmail{Email: entry.Email})
+		}
+		if entry.Label != "" {
+			emails[i].Label = entry.Label
+		}
+		if entry.IsPrimary {
+			if marked >= 0 && marked != i {
+				return nil, fmt.Errorf("%w: only one email can be primary", ErrInvalidContactData)
+			}
+			marked = i
+		}
+	}
+
+	if len(emails) == 0 {
+		return emails, nil
+	}
+	if marked < 0 {
+		marked = 0
+	}
+	emails[marked].IsPrimary = true
+	return emails, nil
+}
+
+// updatedContactPhones applies an update request to the numbers of contact. Phones
+// replaces every number; a phone alone replaces the primary number and keeps the others.
+func (s *Service) updatedContactPhones(contact *models.Contact, req *models.UpdateContactRequest) ([]models.ContactPhone, error) {
+	primary := ""
+	if req.Phone != nil {
+		if err := s.validatePhone(*req.Phone); err != nil {
+			return nil, err
+		}
+		primary = s.normalizePhone(*req.Phone)
+	}
+
+	if req.Phones != nil {
+		return s.contactPhones(primary, *req.Phones)
+	}
+
+	var others []models.ContactPhoneRequest
+	for _, phone := range contact.Phones {
+		if !phone.IsPrimary {
+			others = append(others, models.ContactPhoneRequest{Phone: phone.Phone, Label: phone.Label})
+		}
+	}
+	return s.contactPhones(primary, others)
+}
+
+// updatedContactEmails applies an update request to the addresses of contact. Emails
+// replaces every address; an email alone replaces the primary address, and an empty one
+// removes it so the next address, if any, becomes primary.
+func (s *Service) updatedContactEmails(contact *models.Contact, req *models.UpdateContactRequest) ([]models.ContactEmail, error) {
+	if req.Emails != nil {
+		return s.contactEmails(req.Email, *req.Emails)
+	}
+
+	var others []models.ContactEmailRequest
+	for _, email := range contact.Emails {
+		if !email.IsPrimary {
+			others = append(others, models.ContactEmailRequest{Email: email.Email, Label: email.Label})
+		}
+	}
+	return s.contactEmails(req.Email, others)
+}
+
+// mergedPhones returns the numbers of target followed by those of source it lacks. The
+// target's primary number stays primary; without one the source's becomes primary.
+func mergedPhones(target, source *models.Contact) []models.ContactPhone {
+	phones := make([]models.ContactPhone, 0, len(target.Phones)+len(source.Phones)+2)
+	index := make(map[string]int)
+	for _, contact := range []*models.Contact{target, source} {
+		entries := contact.Phones
+		// Contacts saved before multiple numbers only have their primary phone
+		if len(entries) == 0 && contact.Phone != "" {
+			entries = []models.ContactPhone{{Phone: contact.Phone, IsPrimary: true}}
+		}
+		for _, entry := range entries {
+			if _, ok := index[entry.Phone]; ok {
+				continue
+			}
+			index[entry.Phone] = len(phones)
+			phones = append(phones, models.ContactPhone{Phone: entry.Phone, Label: entry.Label})
+		}
+		if i, ok := index[primaryPhone(entries)]; ok && primaryPhone(phones) == "" {
+			phones[i].IsPrimary = true
+		}
+	}
+	if len(phones) > 0 && primaryPhone(phones) == "" {
+		phones[0].IsPrimary = true
+	}
+	return phones
+}
+
+// mergedEmails combines the addresses of target and source like mergedPhones does
+func mergedEmails(target, source *models.Contact) []models.ContactEmail {
+	emails := make([]models.ContactEmail, 0, len(target.Emails)+len(source.Emails)+2)
+	index := make(map[string]int)
+	for _, contact := range []*models.Contact{target, source} {
+		entries := contact.Emails
+		// Contacts saved before multiple addresses only have their primary email
+		if len(entries) == 0 && contact.Email != nil && *contact.Email != "" {
+			entries = []models.ContactEmail{{Email: *contact.Email, IsPrimary: true}}
+		}
+		for _, entry := range entries {
+			if _, ok := index[entry.Email]; ok {
+				continue
+			}
+			index[entry.Email] = len(emails)
+			emails = append(emails, models.ContactEmail{Email: entry.Email, Label: entry.Label})
+		}
+		if primary := primaryEmail(entries); primary != nil && primaryEmail(emails) == nil {
+			emails[index[*primary]].IsPrimary = true
+		}
+	}
+	if len(emails) > 0 && primaryEmail(emails) == nil {
+		emails[0].IsPrimary = true
+	}
+	return emails
+}
+
+// primaryPhone returns the primary number of phones
+func primaryPhone(phones []models.ContactPhone) string {
+	for _, phone := range phones {
+		if phone.IsPrimary {
+			return phone.Phone
+		}
+	}
+	return ""
+}
+
+// primaryEmail returns the primary address of emails, or nil when there is none
+func primaryEmail(emails []models.ContactEmail) *string {
+	for _, email := range emails {
+		if email.IsPrimary {
+			primary := email.Email
+			return &primary
+		}
+	}
+	return nil
+}
+
+// parseBirthday parses a YYYY-MM-DD birthday as midnight UTC and rejects dates after now
+func parseBirthday(value string, now time.Time) (*time.Time, error) {
+	birthday, err := time.Parse(models.DateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("%w: must be in YYYY-MM-DD format", ErrInvalidBirthday)
+	}
+	if birthday.After(now) {
+		return nil, fmt.Errorf("%w: must not be in the future", ErrInvalidBirthday)
+	}
+	return &birthday, nil
+}
+
+// normalizeNotes enforces the note length limit and turns an empty note into nil so
+// it is stored as NULL
+func normalizeNotes(notes *string) (*string, error) {
+	if notes == nil || *notes == "" {
+		return nil, nil
+	}
+	if len([]rune(*notes)) > maxNotesLength {
+		return nil, fmt.Errorf("%w: must be at most %d characters", ErrNotesTooLong, maxNotesLength)
+	}
+	return notes, nil
+}
+
+// normalizePhone strips separators from a contact phone number and, when enabled, converts
+// Indonesian numbers to +62 format so duplicates are detected across formats
+func (s *Service) normalizePhone(phone string) string {
+	phone = utils.CleanPhone(phone)
+	if !s.normalizePhones {
+		return phone
+	}
+	return utils.NormalizeIndonesiaPhone(phone)
+}
+
+// countryCallingCodes maps the calling codes of common contact countries to their ISO
+// 3166-1 alpha-2 codes. detectCountry tries longer codes first, so +65 is not taken for +6.
+var countryCallingCodes = map[string]string{
+	"1":  "US",
+	"44": "GB",
+	"60": "MY",
+	"61": "AU",
+	"62": "ID",
+	"63": "PH",
+	"65": "SG",
+	"66": "TH",
+	"81": "JP",
+	"82": "KR",
+	"84": "VN",
+	"86": "CN",
+	"91": "IN",
+}
+
+// detectCountry returns the ISO 3166-1 alpha-2 country of an E.164 phone number from
+// its calling code, or "" when it is not known. Numbers without the leading + are
+// national numbers whose country cannot be told, e.g. 8123456789 is not Japanese; with
+// phone normalization enabled Indonesian numbers are stored as +62 and detected.
+//
+// Keep migration 024_backfill_contact_phone_country in sync with countryCallingCodes.
+func detectCountry(phone string) string {
+	phone = utils.CleanPhone(phone)
+	if !strings.HasPrefix(phone, "+") {
+		return ""
+	}
+	digits := phone[1:]
+	for length := 2; length >= 1; length-- {
+		if len(digits) > length {
+			if country, ok := countryCallingCodes[digits[:length]]; ok {
+				return country
+			}
+		}
+	}
+	return ""
+}
+
+// duplicatePhoneWarning describes a phone number that other contacts of the user already have
+func duplicatePhoneWarning(phone string) string {
+	return fmt.Sprintf("phone %s is already used by another contact", phone)
+}
+
+// phoneCountry returns the detected country of phone for storage, nil when unknown
+func phoneCountry(phone string) *string {
+	country := detectCountry(phone)
+	if country == "" {
+		return nil
+	}
+	return &country
+}
+
+// phoneLookupCandidates returns the formats a stored phone number may have been saved in.
+// Numbers with a non-Indonesian country code are only matched as given.
+func phoneLookupCandidates(phone string) []string {
+	candidates := []string{phone}
+	normalized := utils.NormalizeIndonesiaPhone(phone)
+	if !strings.HasPrefix(normalized, "+62") {
+		return candidates
+	}
+	for _, candidate := range []string{normalized, "62" + normalized[3:], "0" + normalized[3:]} {
+		if candidate != phone {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// ValidationError collects every invalid field of a request so clients see all problems
+// at once. It matches the sentinel of each failed field (e.g. ErrInvalidPhone) with errors.Is.
+type ValidationError struct {
+	Fields map[string][]string
+	errs   []error
+}
+
+// Add records messages for field, caused by err
+func (e *ValidationError) Add(field string, err error, messages ...string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string][]string)
+	}
+	e.Fields[field] = append(e.Fields[field], messages...)
+	e.errs = append(e.errs, err)
+}
+
+// errOrNil returns e when any field failed and nil otherwise
+func (e *ValidationError) errOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + ": " + strings.Join(e.Fields[field], ", ")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap returns the errors of all failed fields
+func (e *ValidationError) Unwrap() []error {
+	return e.errs
+}
+
+// PasswordPolicyError lists every password policy rule a password violates.
+// It matches ErrWeakPassword with errors.Is.
+type PasswordPolicyError struct {
+	Messages []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return strings.Join(e.Messages, "; ")
+}
+
+// Is reports ErrWeakPassword as the sentinel for policy violations
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// PasswordPolicyMessages returns the policy violations carried by a weak password error
+func PasswordPolicyMessages(err error) []string {
+	var policyErr *PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return policyErr.Messages
+	}
+	return []string{"must be at least 8 characters"}
+}
+
+// validatePassword validates password strength. With the strict policy enabled the password
+// must also mix upper and lower case letters and digits; existing passwords are never re-checked.
 func (s *Service) validatePassword(password string) error {
+	if s.strictPasswordPolicy {
+		if ok, messages := utils.ValidatePassword(password); !ok {
+			return &PasswordPolicyError{Messages: messages}
+		}
+		return nil
+	}
 	if len(password) < 8 {
 		return ErrWeakPassword
 	}
 	return nil
 }
 
-// hashPassword hashes a password using bcrypt
+// hashPassword hashes a password using bcrypt with the configured cost
 func (s *Service) hashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
 	if err != nil {
 		return "", err
 	}
 	return string(hash), nil
 }
 
+// needsRehash reports whether hash was made with a lower bcrypt cost than cost
+func needsRehash(hash string, cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return hashCost < cost
+}
+
+// rehashPassword stores the password hashed with the configured cost. Failures are only
+// logged: the login itself already succeeded and the upgrade is retried next time.
+func (s *Service) rehashPassword(ctx context.Context, user *models.User, password string) {
+	hashedPassword, err := s.hashPassword(password)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to rehash password", "user_id", user.ID, "error", err)
+		return
+	}
+
+	previous := user.Password
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(ctx, user, "password"); err != nil {
+		user.Password = previous
+		logger.FromContext(ctx).Warn("Failed to store rehashed password", "user_id", user.ID, "error", err)
+	}
+}
+
 // verifyPassword verifies a password against a hash
 func (s *Service) verifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-// generateToken generates a JWT token for a user
-func (s *Service) generateToken(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+// issueTokens generates an access and refresh token pair for a user
+func (s *Service) issueTokens(ctx context.Context, user *models.User, sessionID string) (*models.AuthResponse, error) {
+	refreshToken, jti, expiresAt, err := s.generateRefreshToken(user, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	// The first refresh token of a session names it
+	newSession := sessionID == ""
+	if newSession {
+		sessionID = jti
+	}
+
+	token, err := s.generateToken(user, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if s.refreshTokenRepo != nil {
+		record := &models.RefreshToken{
+			UserID:    user.ID,
+			JTI:       jti,
+			ExpiresAt: expiresAt,
+		}
+		if err := s.refreshTokenRepo.Create(ctx, record); err != nil {
+			return nil, fmt.Errorf("failed to store refresh token: %w", err)
+		}
+	}
+
+	if err := s.saveSession(ctx, user.ID, sessionID, newSession, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user.ToResponse(),
+	}, nil
+}
+
+// parseAccessToken validates an access token's signature, expiry, audience and type
+func (s *Service) parseAccessToken(tokenString string, opts ...jwt.ParserOption) (*JWTClaims, error) {
+	claims, err := s.parseClaims(tokenString, append(opts, jwt.WithAudience(s.jwtAudience))...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject other token types used as access tokens (tokens without a type predate token_type)
+	if claims.TokenType != TokenTypeAccess && claims.TokenType != "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// parsePurposeToken validates a single-purpose token (e.g. email verification) of the given type
+func (s *Service) parsePurposeToken(tokenString, tokenType string) (*JWTClaims, error) {
+	claims, err := s.parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// parseClaims validates a token's signature, expiry and issuer and returns its claims
+func (s *Service) parseClaims(tokenString string, opts ...jwt.ParserOption) (*JWTClaims, error) {
+	opts = append(opts, jwt.WithIssuer(tokenIssuer))
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.verificationKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// generatePurposeToken generates a short-lived, single-purpose token (e.g. email verification) for a user
+func (s *Service) generatePurposeToken(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &JWTClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
+		},
+	}
+
+	return s.signToken(claims)
+}
+
+// generatePasswordResetToken generates a password reset token bound to the user's current password
+func (s *Service) generatePasswordResetToken(user *models.User) (string, error) {
+	now := time.Now()
+
+	claims := &JWTClaims{
+		UserID:              user.ID,
+		Email:               user.Email,
+		TokenType:           TokenTypePasswordReset,
+		PasswordFingerprint: s.passwordFingerprint(user.Password),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(passwordResetTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
+		},
+	}
+
+	return s.signToken(claims)
+}
+
+// passwordFingerprint derives a keyed digest of a password hash without exposing the hash itself
+func (s *Service) passwordFingerprint(hashedPassword string) string {
+	mac := hmac.New(sha256.New, s.fingerprintKey())
+	mac.Write([]byte(hashedPassword))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// sendVerificationEmail issues an email verification token and hands it to the email sender
+func (s *Service) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := s.generatePurposeToken(user, TokenTypeEmailVerification, emailVerificationTokenTTL)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	if s.emailSender == nil {
+		return nil
+	}
+
+	// Delivery failures are not fatal: the account already exists
+	if err := s.emailSender.SendVerificationEmail(ctx, user.Email, token); err != nil {
+		logger.FromContext(ctx).Warn("Failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	return nil
+}
+
+// generateToken generates a JWT access token for a user's session
+func (s *Service) generateToken(user *models.User, sessionID string) (string, error) {
+	now := time.Now()
+	expirationTime := now.Add(s.accessTokenTTL)
 
 	claims := &JWTClaims{
-		UserID:   user.ID,
-		Email:    user.Email,
-		FullName: user.FullName,
+		UserID:    user.ID,
+		Email:     user.Email,
+		FullName:  user.FullName,
+		TokenType: TokenTypeAccess,
+		SessionID: sessionID,
+		Role:      user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // jti, used for revocation on logout
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "user-service",
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{s.jwtAudience},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	tokenString, err := s.signToken(claims)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// generateRefreshToken generates a refresh token for a user and returns it with its jti and expiry.
+// An empty sessionID starts a new session named after the token's jti.
+func (s *Service) generateRefreshToken(user *models.User, sessionID string) (string, string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(refreshTokenTTL)
+	jti := uuid.New().String()
+	if sessionID == "" {
+		sessionID = jti
+	}
+
+	claims := &RefreshClaims{
+		UserID:    user.ID,
+		TokenType: TokenTypeRefresh,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
+		},
+	}
+
+	tokenString, err := s.signToken(claims)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return tokenString, jti, expirationTime, nil
+}
+
+// parseRefreshToken validates a refresh token's signature, expiry and type
+func (s *Service) parseRefreshToken(tokenString string) (*RefreshClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, s.verificationKey, jwt.WithIssuer(tokenIssuer))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*RefreshClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	if claims.TokenType != TokenTypeRefresh || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
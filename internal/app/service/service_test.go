@@ -2,13 +2,30 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"user-service/internal/app/models"
 	"user-service/internal/app/repository"
+	"user-service/internal/app/webhook"
+	"user-service/internal/utils"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // MockUserRepository is a mock implementation of UserRepository
@@ -37,7 +54,7 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User, columns ...string) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
@@ -47,18 +64,51 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetLastSeen(ctx context.Context, id uint, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetActive(ctx context.Context, id uint, active bool) error {
+	args := m.Called(ctx, id, active)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetRole(ctx context.Context, id uint, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) CheckEmailExists(ctx context.Context, email string, excludeUserID uint) (bool, error) {
 	args := m.Called(ctx, email, excludeUserID)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserRepository) List(ctx context.Context, req *models.ListUsersRequest) ([]models.User, int64, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
 // MockContactRepository is a mock implementation of ContactRepository
 type MockContactRepository struct {
 	mock.Mock
 }
 
-func (m *MockContactRepository) Create(ctx context.Context, contact *models.Contact) error {
-	args := m.Called(ctx, contact)
+func (m *MockContactRepository) Create(ctx context.Context, contact *models.Contact, maxContacts int) error {
+	args := m.Called(ctx, contact, maxContacts)
 	return args.Error(0)
 }
 
@@ -70,8 +120,8 @@ func (m *MockContactRepository) GetByID(ctx context.Context, userID, contactID u
 	return args.Get(0).(*models.Contact), args.Error(1)
 }
 
-func (m *MockContactRepository) Update(ctx context.Context, contact *models.Contact) error {
-	args := m.Called(ctx, contact)
+func (m *MockContactRepository) Update(ctx context.Context, contact *models.Contact, children repository.ContactChildren) error {
+	args := m.Called(ctx, contact, children)
 	return args.Error(0)
 }
 
@@ -80,16 +130,282 @@ func (m *MockContactRepository) Delete(ctx context.Context, userID, contactID ui
 	return args.Error(0)
 }
 
+func (m *MockContactRepository) DeleteMany(ctx context.Context, userID uint, contactIDs []uint) ([]models.Contact, error) {
+	args := m.Called(ctx, userID, contactIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) SetFavorite(ctx context.Context, userID uint, contactIDs []uint, favorite bool) ([]models.Contact, error) {
+	args := m.Called(ctx, userID, contactIDs, favorite)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) Merge(ctx context.Context, target *models.Contact, sourceID uint) error {
+	args := m.Called(ctx, target, sourceID)
+	return args.Error(0)
+}
+
+func (m *MockContactRepository) Restore(ctx context.Context, userID, contactID uint) error {
+	args := m.Called(ctx, userID, contactID)
+	return args.Error(0)
+}
+
 func (m *MockContactRepository) List(ctx context.Context, userID uint, req *models.ListContactsRequest) ([]models.Contact, int64, error) {
 	args := m.Called(ctx, userID, req)
 	return args.Get(0).([]models.Contact), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockContactRepository) ListAll(ctx context.Context, userID uint) ([]models.Contact, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) ListInBatches(ctx context.Context, userID uint, batchSize int, fn func([]models.Contact) error) error {
+	args := m.Called(ctx, userID, batchSize)
+	if batches, ok := args.Get(0).([][]models.Contact); ok {
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockContactRepository) ListRecent(ctx context.Context, userID uint, limit int) ([]models.Contact, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) ListWithBirthday(ctx context.Context, userID uint) ([]models.Contact, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Contact), args.Error(1)
+}
+
 func (m *MockContactRepository) CheckPhoneExists(ctx context.Context, userID uint, phone string, excludeContactID uint) (bool, error) {
 	args := m.Called(ctx, userID, phone, excludeContactID)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockContactRepository) Count(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockContactRepository) Stats(ctx context.Context, userID uint, since time.Time) (*models.ContactStats, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ContactStats), args.Error(1)
+}
+
+func (m *MockContactRepository) GetByPhone(ctx context.Context, userID uint, phones []string) (*models.Contact, error) {
+	args := m.Called(ctx, userID, phones)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) GetTags(ctx context.Context, contactIDs []uint) (map[uint][]string, error) {
+	args := m.Called(ctx, contactIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint][]string), args.Error(1)
+}
+
+func (m *MockContactRepository) ListRevisions(ctx context.Context, userID, contactID uint) ([]models.ContactRevision, error) {
+	args := m.Called(ctx, userID, contactID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ContactRevision), args.Error(1)
+}
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByJTI(ctx context.Context, jti string) (*models.RefreshToken, error) {
+	args := m.Called(ctx, jti)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Revoke(ctx context.Context, jti string) error {
+	args := m.Called(ctx, jti)
+	return args.Error(0)
+}
+
+// MockSessionRepository is a mock implementation of SessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByJTI(ctx context.Context, jti string) (*models.Session, error) {
+	args := m.Called(ctx, jti)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListActive(ctx context.Context, userID uint) ([]models.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Touch(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(ctx context.Context, userID uint, jti string) error {
+	args := m.Called(ctx, userID, jti)
+	return args.Error(0)
+}
+
+// MockRevocationStore is a mock implementation of TokenRevocationStore
+type MockRevocationStore struct {
+	mock.Mock
+}
+
+func (m *MockRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	args := m.Called(ctx, jti, ttl)
+	return args.Error(0)
+}
+
+func (m *MockRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockLoginAttemptCounter is a mock implementation of LoginAttemptCounter
+type MockLoginAttemptCounter struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptCounter) Count(ctx context.Context, key string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, key, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, key, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptCounter) Reset(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// MockEmailSender is a mock implementation of EmailSender
+type MockEmailSender struct {
+	mock.Mock
+}
+
+func (m *MockEmailSender) SendVerificationEmail(ctx context.Context, to, token string) error {
+	args := m.Called(ctx, to, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailSender) SendPasswordResetEmail(ctx context.Context, to, token string) error {
+	args := m.Called(ctx, to, token)
+	return args.Error(0)
+}
+
+// MockAuditLogRepository is a mock implementation of AuditLogRepository
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepository) ListByUser(ctx context.Context, userID uint, page, limit int) ([]models.AuditLog, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]models.AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+// MockFileStorage is a mock implementation of FileStorage
+type MockFileStorage struct {
+	mock.Mock
+}
+
+func (m *MockFileStorage) Save(ctx context.Context, name string, data []byte) (string, error) {
+	args := m.Called(ctx, name, data)
+	return args.String(0), args.Error(1)
+}
+
+// MockProfileCache is a mock implementation of ProfileCache
+type MockProfileCache struct {
+	mock.Mock
+}
+
+func (m *MockProfileCache) Get(ctx context.Context, userID uint) ([]byte, bool, error) {
+	args := m.Called(ctx, userID)
+	data, _ := args.Get(0).([]byte)
+	return data, args.Bool(1), args.Error(2)
+}
+
+func (m *MockProfileCache) Set(ctx context.Context, userID uint, profile []byte, ttl time.Duration) error {
+	args := m.Called(ctx, userID, profile, ttl)
+	return args.Error(0)
+}
+
+func (m *MockProfileCache) Delete(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockEventPublisher is a mock implementation of EventPublisher
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) Publish(event webhook.Event) {
+	m.Called(event)
+}
+
 // ============================================================================
 // USER SERVICE TESTS
 // ============================================================================
@@ -104,7 +420,7 @@ func TestService_Register(t *testing.T) {
 		req := &models.RegisterRequest{
 			FullName: "John Doe",
 			Email:    "john@example.com",
-			Phone:    "081234567890",
+			Phone:    strPtr("081234567890"),
 			Password: "password123",
 		}
 
@@ -125,7 +441,7 @@ func TestService_Register(t *testing.T) {
 		req := &models.RegisterRequest{
 			FullName: "Jane Doe",
 			Email:    "existing@example.com",
-			Phone:    "081234567890",
+			Phone:    strPtr("081234567890"),
 			Password: "password123",
 		}
 
@@ -144,7 +460,7 @@ func TestService_Register(t *testing.T) {
 		req := &models.RegisterRequest{
 			FullName: "John Doe",
 			Email:    "invalid-email",
-			Phone:    "081234567890",
+			Phone:    strPtr("081234567890"),
 			Password: "password123",
 		}
 
@@ -160,7 +476,7 @@ func TestService_Register(t *testing.T) {
 		req := &models.RegisterRequest{
 			FullName: "John Doe",
 			Email:    "john@example.com",
-			Phone:    "123", // Too short
+			Phone:    strPtr("123"), // Too short
 			Password: "password123",
 		}
 
@@ -176,7 +492,7 @@ func TestService_Register(t *testing.T) {
 		req := &models.RegisterRequest{
 			FullName: "John Doe",
 			Email:    "john@example.com",
-			Phone:    "081234567890",
+			Phone:    strPtr("081234567890"),
 			Password: "123", // Too short
 		}
 
@@ -188,6 +504,81 @@ func TestService_Register(t *testing.T) {
 	})
 }
 
+func TestService_StrictPasswordPolicy(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithStrictPasswordPolicy(true))
+	oldHash, _ := service.hashPassword("oldpassword")
+
+	tests := []struct {
+		name     string
+		password string
+		message  string
+	}{
+		{"too short", "Ab1", "password must be at least 8 characters"},
+		{"too long", "Ab1" + strings.Repeat("x", 126), "password must not exceed 128 characters"},
+		{"missing uppercase", "password123", "password must contain at least one uppercase letter"},
+		{"missing lowercase", "PASSWORD123", "password must contain at least one lowercase letter"},
+		{"missing digit", "Passwordabc", "password must contain at least one digit"},
+	}
+
+	for _, tt := range tests {
+		t.Run("register "+tt.name, func(t *testing.T) {
+			req := &models.RegisterRequest{
+				FullName: "John Doe",
+				Email:    "john@example.com",
+				Password: tt.password,
+			}
+
+			resp, err := service.Register(context.Background(), req)
+
+			assert.Nil(t, resp)
+			assert.ErrorIs(t, err, ErrWeakPassword)
+			var policyErr *PasswordPolicyError
+			if assert.ErrorAs(t, err, &policyErr) {
+				assert.Equal(t, []string{tt.message}, policyErr.Messages)
+			}
+		})
+
+		t.Run("change password "+tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			// Existing accounts may still have a password that predates the strict policy
+			mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, Password: oldHash}, nil).Once()
+
+			err := service.ChangePassword(ctx, 1, "oldpassword", tt.password)
+
+			assert.ErrorIs(t, err, ErrWeakPassword)
+			var policyErr *PasswordPolicyError
+			if assert.ErrorAs(t, err, &policyErr) {
+				assert.Contains(t, policyErr.Messages, tt.message)
+			}
+		})
+	}
+
+	t.Run("multiple violations", func(t *testing.T) {
+		err := service.validatePassword("abc")
+
+		var policyErr *PasswordPolicyError
+		if assert.ErrorAs(t, err, &policyErr) {
+			assert.Equal(t, []string{
+				"password must be at least 8 characters",
+				"password must contain at least one uppercase letter",
+				"password must contain at least one digit",
+			}, policyErr.Messages)
+		}
+	})
+
+	t.Run("strong password", func(t *testing.T) {
+		assert.NoError(t, service.validatePassword("Password123"))
+	})
+
+	t.Run("lenient policy only checks length", func(t *testing.T) {
+		lenient := NewService(mockUserRepo, mockContactRepo, "test-secret")
+		assert.NoError(t, lenient.validatePassword("password"))
+		assert.ErrorIs(t, lenient.validatePassword("short"), ErrWeakPassword)
+	})
+}
+
 func TestService_Login(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockContactRepo := new(MockContactRepository)
@@ -262,132 +653,3175 @@ func TestService_Login(t *testing.T) {
 	})
 }
 
-func TestService_GetProfile(t *testing.T) {
-	mockUserRepo := new(MockUserRepository)
-	mockContactRepo := new(MockContactRepository)
-	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
-
-	t.Run("successful get profile", func(t *testing.T) {
+func TestService_PasswordRehash(t *testing.T) {
+	t.Run("login upgrades a lower cost hash", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		service := NewService(mockUserRepo, nil, "test-secret", WithBcryptCost(bcrypt.MinCost+1))
 		ctx := context.Background()
-		user := &models.User{
-			ID:       1,
-			FullName: "John Doe",
-			Email:    "john@example.com",
-			Phone:    "081234567890",
-		}
 
-		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		oldHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		user := &models.User{ID: 1, Email: "john@example.com", Password: string(oldHash)}
 
-		profile, err := service.GetProfile(ctx, 1)
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			cost, err := bcrypt.Cost([]byte(u.Password))
+			return err == nil && cost == bcrypt.MinCost+1 &&
+				bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("password123")) == nil
+		})).Return(nil).Once()
+
+		_, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123"})
 
 		assert.NoError(t, err)
-		assert.NotNil(t, profile)
-		assert.Equal(t, "john@example.com", profile.Email)
 		mockUserRepo.AssertExpectations(t)
 	})
 
-	t.Run("user not found", func(t *testing.T) {
+	t.Run("current cost hash is kept", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		service := NewService(mockUserRepo, nil, "test-secret", WithBcryptCost(bcrypt.MinCost))
 		ctx := context.Background()
 
-		mockUserRepo.On("GetByID", ctx, uint(999)).Return(nil, repository.ErrNotFound).Once()
+		hash, _ := service.hashPassword("password123")
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hash}
 
-		profile, err := service.GetProfile(ctx, 999)
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
 
-		assert.Error(t, err)
-		assert.Nil(t, profile)
-		assert.ErrorIs(t, err, ErrUserNotFound)
-		mockUserRepo.AssertExpectations(t)
+		_, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123"})
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid cost falls back to default", func(t *testing.T) {
+		for _, cost := range []int{0, bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+			service := NewService(nil, nil, "test-secret", WithBcryptCost(cost))
+			assert.Equal(t, bcrypt.DefaultCost, service.bcryptCost, "cost=%d", cost)
+		}
+	})
+
+	t.Run("needsRehash", func(t *testing.T) {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+		assert.True(t, needsRehash(string(hash), bcrypt.MinCost+1))
+		assert.False(t, needsRehash(string(hash), bcrypt.MinCost))
+		assert.False(t, needsRehash("not-a-hash", bcrypt.MaxCost))
 	})
 }
 
-func TestService_ValidateToken(t *testing.T) {
+func TestService_AccountDeactivation(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockContactRepo := new(MockContactRepository)
 	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
 
-	t.Run("valid token", func(t *testing.T) {
-		user := &models.User{
-			ID:       1,
-			FullName: "John Doe",
-			Email:    "john@example.com",
-		}
+	hashedPassword, _ := service.hashPassword("password123")
+	deactivatedAt := time.Now()
+	deactivatedUser := &models.User{
+		ID:            1,
+		FullName:      "John Doe",
+		Email:         "john@example.com",
+		Password:      hashedPassword,
+		DeactivatedAt: &deactivatedAt,
+	}
 
-		token, err := service.generateToken(user)
-		assert.NoError(t, err)
+	t.Run("deactivate account", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("SetActive", ctx, uint(1), false).Return(nil).Once()
+
+		err := service.DeactivateAccount(ctx, 1)
 
-		userID, err := service.ValidateToken(token)
 		assert.NoError(t, err)
-		assert.Equal(t, uint(1), userID)
+		mockUserRepo.AssertExpectations(t)
 	})
 
-	t.Run("invalid token", func(t *testing.T) {
-		userID, err := service.ValidateToken("invalid-token")
-		assert.Error(t, err)
-		assert.Equal(t, uint(0), userID)
-		assert.ErrorIs(t, err, ErrInvalidToken)
-	})
-}
+	t.Run("deactivate unknown account", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("SetActive", ctx, uint(99), false).Return(repository.ErrNotFound).Once()
 
-// ============================================================================
+		err := service.DeactivateAccount(ctx, 99)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("login while deactivated", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.LoginRequest{Email: "john@example.com", Password: "password123"}
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(deactivatedUser, nil).Once()
+
+		resp, err := service.Login(ctx, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrAccountDeactivated)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong password while deactivated reports invalid credentials", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.LoginRequest{Email: "john@example.com", Password: "wrongpassword"}
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(deactivatedUser, nil).Once()
+
+		_, err := service.Login(ctx, req)
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("reactivate restores login", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("SetActive", ctx, uint(1), true).Return(nil).Once()
+
+		assert.NoError(t, service.ReactivateAccount(ctx, 1))
+
+		reactivated := *deactivatedUser
+		reactivated.DeactivatedAt = nil
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(&reactivated, nil).Once()
+
+		resp, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("tokens of deactivated accounts", func(t *testing.T) {
+		strict := NewService(mockUserRepo, mockContactRepo, "test-secret", WithRejectDeactivatedTokens(true))
+		token, err := strict.generateToken(deactivatedUser, "")
+		assert.NoError(t, err)
+
+		// Without the flag the token stays valid until it expires
+		userID, err := service.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+
+		mockUserRepo.On("GetByID", mock.Anything, uint(1)).Return(deactivatedUser, nil).Once()
+
+		userID, err = strict.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrAccountDeactivated)
+		assert.Equal(t, uint(0), userID)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_SetRole(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("promote to admin", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("SetRole", ctx, uint(1), models.RoleAdmin).Return(nil).Once()
+
+		assert.NoError(t, service.SetRole(ctx, 1, models.RoleAdmin))
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		assert.ErrorIs(t, service.SetRole(context.Background(), 1, "root"), ErrInvalidRole)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("SetRole", ctx, uint(99), models.RoleUser).Return(repository.ErrNotFound).Once()
+
+		assert.ErrorIs(t, service.SetRole(ctx, 99, models.RoleUser), ErrUserNotFound)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("access token carries the role", func(t *testing.T) {
+		ctx := context.Background()
+		hashedPassword, _ := service.hashPassword("password123")
+		admin := &models.User{ID: 2, FullName: "Ada Admin", Email: "ada@example.com", Password: hashedPassword, Role: models.RoleAdmin}
+		mockUserRepo.On("GetByEmail", ctx, "ada@example.com").Return(admin, nil).Once()
+
+		resp, err := service.Login(ctx, &models.LoginRequest{Email: "ada@example.com", Password: "password123"})
+		assert.NoError(t, err)
+
+		claims, err := service.ValidateTokenClaims(context.Background(), resp.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), claims.UserID)
+		assert.Equal(t, models.RoleAdmin, claims.Role)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_AuditLog(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockAuditRepo := new(MockAuditLogRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithAuditLogRepository(mockAuditRepo))
+
+	hashedPassword, _ := service.hashPassword("password123")
+	newUser := func() *models.User {
+		return &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com", Password: hashedPassword}
+	}
+
+	t.Run("successful login writes exactly one audit row", func(t *testing.T) {
+		ctx := WithClientIP(context.Background(), "10.0.0.1")
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(newUser(), nil).Once()
+		mockAuditRepo.On("Create", ctx, mock.MatchedBy(func(e *models.AuditLog) bool {
+			return e.UserID == 1 && e.Action == models.AuditActionLogin && e.Target == "user:1" && e.IPAddress == "10.0.0.1"
+		})).Return(nil).Once()
+
+		_, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123"})
+
+		assert.NoError(t, err)
+		mockAuditRepo.AssertNumberOfCalls(t, "Create", 1)
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed login writes no audit row", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(newUser(), nil).Once()
+
+		_, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "wrongpassword"})
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		// Still the single row from the previous subtest
+		mockAuditRepo.AssertNumberOfCalls(t, "Create", 1)
+	})
+
+	t.Run("audit write failure does not fail the action", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(5)).Return(&models.Contact{ID: 5, UserID: 1}, nil).Once()
+		mockContactRepo.On("Delete", ctx, uint(1), uint(5)).Return(nil).Once()
+		mockAuditRepo.On("Create", ctx, mock.MatchedBy(func(e *models.AuditLog) bool {
+			return e.Action == models.AuditActionContactDelete && e.Target == "contact:5"
+		})).Return(errors.New("db down")).Once()
+
+		err := service.DeleteContact(ctx, 1, 5)
+
+		assert.NoError(t, err)
+		mockContactRepo.AssertExpectations(t)
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("merge records the source as deleted", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(6)).Return(&models.Contact{ID: 6, UserID: 1, FullName: "Jane"}, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(&models.Contact{ID: 7, UserID: 1, FullName: "Jane"}, nil).Once()
+		mockContactRepo.On("Merge", ctx, mock.AnythingOfType("*models.Contact"), uint(7)).Return(nil).Once()
+		mockAuditRepo.On("Create", ctx, mock.MatchedBy(func(e *models.AuditLog) bool {
+			return e.UserID == 1 && e.Action == models.AuditActionContactDelete && e.Target == "contact:7"
+		})).Return(nil).Once()
+
+		_, err := service.MergeContacts(ctx, 1, 6, 7)
+
+		assert.NoError(t, err)
+		mockContactRepo.AssertExpectations(t)
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("list applies pagination defaults", func(t *testing.T) {
+		ctx := context.Background()
+		entries := []models.AuditLog{{ID: 1, UserID: 1, Action: models.AuditActionLogin}}
+		mockAuditRepo.On("ListByUser", ctx, uint(1), 1, 10).Return(entries, int64(1), nil).Once()
+
+		resp, err := service.ListAuditLogs(ctx, 1, &models.ListAuditLogsRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entries, resp.Data)
+		assert.Equal(t, int64(1), resp.Pagination.Total)
+		assert.Equal(t, 10, resp.Pagination.Limit)
+		mockAuditRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_LoginRateLimit(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockCounter := new(MockLoginAttemptCounter)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret",
+		WithLoginAttemptLimiter(mockCounter, 5, 15*time.Minute),
+	)
+
+	hashedPassword, _ := service.hashPassword("password123")
+	key := "john@example.com|10.0.0.1"
+
+	t.Run("failed login is recorded", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+
+		mockCounter.On("Count", ctx, key, 15*time.Minute).Return(int64(4), nil).Once()
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
+		mockCounter.On("Increment", ctx, key, 15*time.Minute).Return(int64(5), nil).Once()
+
+		resp, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "wrongpassword", ClientIP: "10.0.0.1"})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockUserRepo.AssertExpectations(t)
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("locked out after max failures", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockCounter.On("Count", ctx, key, 15*time.Minute).Return(int64(5), nil).Once()
+
+		resp, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123", ClientIP: "10.0.0.1"})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		// No GetByEmail expectation is set: the user lookup must not run while locked out
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("unknown email counts as failure", func(t *testing.T) {
+		ctx := context.Background()
+		unknownKey := "nobody@example.com|10.0.0.1"
+
+		mockCounter.On("Count", ctx, unknownKey, 15*time.Minute).Return(int64(0), nil).Once()
+		mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, repository.ErrNotFound).Once()
+		mockCounter.On("Increment", ctx, unknownKey, 15*time.Minute).Return(int64(1), nil).Once()
+
+		_, err := service.Login(ctx, &models.LoginRequest{Email: "nobody@example.com", Password: "password123", ClientIP: "10.0.0.1"})
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("successful login resets counter", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+
+		mockCounter.On("Count", ctx, key, 15*time.Minute).Return(int64(3), nil).Once()
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
+		mockCounter.On("Reset", ctx, key).Return(nil).Once()
+
+		resp, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123", ClientIP: "10.0.0.1"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockUserRepo.AssertExpectations(t)
+		mockCounter.AssertExpectations(t)
+	})
+
+	t.Run("counter error fails login", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockCounter.On("Count", ctx, key, 15*time.Minute).Return(int64(0), errors.New("redis down")).Once()
+
+		_, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123", ClientIP: "10.0.0.1"})
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInvalidCredentials)
+		mockCounter.AssertExpectations(t)
+	})
+}
+
+func TestService_EmailVerification(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockSender := new(MockEmailSender)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret",
+		WithEmailSender(mockSender),
+		WithRequireEmailVerification(true),
+	)
+
+	t.Run("register creates unverified user and sends token", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.RegisterRequest{
+			FullName: "John Doe",
+			Email:    "john@example.com",
+			Password: "password123",
+		}
+
+		var sentToken string
+		mockUserRepo.On("CheckEmailExists", ctx, "john@example.com", uint(0)).Return(false, nil).Once()
+		mockUserRepo.On("Create", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return !u.EmailVerified
+		})).Return(nil).Once()
+		mockSender.On("SendVerificationEmail", ctx, "john@example.com", mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { sentToken = args.String(2) }).
+			Return(nil).Once()
+
+		resp, err := service.Register(ctx, req)
+
+		assert.NoError(t, err)
+		assert.False(t, resp.User.EmailVerified)
+		assert.NotEmpty(t, sentToken)
+
+		// The verification token must not be usable as an access token
+		_, err = service.ValidateToken(context.Background(), sentToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		mockUserRepo.AssertExpectations(t)
+		mockSender.AssertExpectations(t)
+	})
+
+	t.Run("login before verify", func(t *testing.T) {
+		ctx := context.Background()
+		hashedPassword, _ := service.hashPassword("password123")
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword, EmailVerified: false}
+
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
+
+		resp, err := service.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123"})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("login before verify allowed when not enforced", func(t *testing.T) {
+		ctx := context.Background()
+		svc := NewService(mockUserRepo, mockContactRepo, "test-secret")
+		hashedPassword, _ := svc.hashPassword("password123")
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword, EmailVerified: false}
+
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
+
+		resp, err := svc.Login(ctx, &models.LoginRequest{Email: "john@example.com", Password: "password123"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("verify with valid token", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, Email: "john@example.com"}
+		token, err := service.generatePurposeToken(user, TokenTypeEmailVerification, time.Hour)
+		assert.NoError(t, err)
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, Email: "john@example.com"}, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.EmailVerified
+		})).Return(nil).Once()
+
+		err = service.VerifyEmail(ctx, token)
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("verify with bad token", func(t *testing.T) {
+		err := service.VerifyEmail(context.Background(), "bad-token")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("verify with access token", func(t *testing.T) {
+		token, err := service.generateToken(&models.User{ID: 1, Email: "john@example.com"}, "")
+		assert.NoError(t, err)
+
+		err = service.VerifyEmail(context.Background(), token)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("verify with token for previous email", func(t *testing.T) {
+		ctx := context.Background()
+		token, err := service.generatePurposeToken(&models.User{ID: 1, Email: "old@example.com"}, TokenTypeEmailVerification, time.Hour)
+		assert.NoError(t, err)
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, Email: "new@example.com"}, nil).Once()
+
+		err = service.VerifyEmail(ctx, token)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_PasswordReset(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	emailSender := new(MockEmailSender)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithEmailSender(emailSender))
+
+	hashedPassword, _ := service.hashPassword("password123")
+
+	t.Run("request for unknown email", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, repository.ErrNotFound).Once()
+
+		err := service.RequestPasswordReset(ctx, "nobody@example.com")
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		mockUserRepo.AssertExpectations(t)
+		emailSender.AssertNotCalled(t, "SendPasswordResetEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("without an email sender", func(t *testing.T) {
+		unsent := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+		err := unsent.RequestPasswordReset(context.Background(), "john@example.com")
+		assert.ErrorIs(t, err, ErrEmailUnavailable)
+	})
+
+	t.Run("reset is single use", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+		mockUserRepo.On("GetByEmail", ctx, "john@example.com").Return(user, nil).Once()
+
+		// The email is sent in the background, outliving the request context
+		sent := make(chan string, 1)
+		emailSender.On("SendPasswordResetEmail", mock.Anything, "john@example.com", mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { sent <- args.String(2) }).
+			Return(nil).Once()
+
+		err := service.RequestPasswordReset(ctx, "john@example.com")
+		assert.NoError(t, err)
+
+		var token string
+		select {
+		case token = <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("password reset email was not sent")
+		}
+		assert.NotEmpty(t, token)
+		emailSender.AssertExpectations(t)
+
+		// Reset tokens must not authenticate API requests
+		_, err = service.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		var newHash string
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.AnythingOfType("*models.User")).
+			Run(func(args mock.Arguments) { newHash = args.Get(1).(*models.User).Password }).
+			Return(nil).Once()
+
+		err = service.ResetPassword(ctx, token, "newpassword123")
+		assert.NoError(t, err)
+		assert.NoError(t, service.verifyPassword(newHash, "newpassword123"))
+
+		// Replaying the token after the password changed must fail
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, Email: "john@example.com", Password: newHash}, nil).Once()
+
+		err = service.ResetPassword(ctx, token, "anotherpassword123")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("weak new password", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+		token, err := service.generatePasswordResetToken(user)
+		assert.NoError(t, err)
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+
+		err = service.ResetPassword(ctx, token, "short")
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+		token, err := service.generatePurposeToken(user, TokenTypePasswordReset, -time.Minute)
+		assert.NoError(t, err)
+
+		err = service.ResetPassword(context.Background(), token, "newpassword123")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("verification token used for reset", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+		token, err := service.generatePurposeToken(user, TokenTypeEmailVerification, time.Hour)
+		assert.NoError(t, err)
+
+		err = service.ResetPassword(context.Background(), token, "newpassword123")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestService_UpdateProfile(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	newUser := func() *models.User {
+		return &models.User{
+			ID:        1,
+			FullName:  "John Doe",
+			Email:     "john@example.com",
+			Phone:     strPtr("081234567890"),
+			AvatarURL: strPtr("/uploads/avatars/1.png"),
+		}
+	}
+
+	t.Run("omitted fields are left unchanged", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.FullName == "Jane Doe" && u.Phone != nil && *u.Phone == "081234567890" &&
+				u.AvatarURL != nil && *u.AvatarURL == "/uploads/avatars/1.png"
+		})).Return(nil).Once()
+
+		resp, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{FullName: strPtr("Jane Doe")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane Doe", resp.FullName)
+		assert.Equal(t, "081234567890", *resp.Phone)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("explicit empty string clears phone and avatar", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.FullName == "John Doe" && u.Phone == nil && u.AvatarURL == nil
+		})).Return(nil).Once()
+
+		resp, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{
+			Phone:     strPtr(""),
+			AvatarURL: strPtr(""),
+		})
+
+		assert.NoError(t, err)
+		assert.Nil(t, resp.Phone)
+		assert.Nil(t, resp.AvatarURL)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("new phone is validated", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+
+		_, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Phone: strPtr("abc")})
+
+		assert.ErrorIs(t, err, ErrInvalidPhone)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("empty full name is rejected", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+
+		_, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{FullName: strPtr("")})
+
+		assert.ErrorIs(t, err, ErrInvalidFullName)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("new email is unverified and re-verified", func(t *testing.T) {
+		ctx := context.Background()
+		userRepo := new(MockUserRepository)
+		emailSender := new(MockEmailSender)
+		service := NewService(userRepo, new(MockContactRepository), "test-secret",
+			WithEmailSender(emailSender), WithRequireEmailVerification(true))
+
+		user := newUser()
+		user.EmailVerified = true
+		userRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		userRepo.On("CheckEmailExists", ctx, "jane@example.com", uint(1)).Return(false, nil).Once()
+		userRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == "jane@example.com" && !u.EmailVerified
+		})).Return(nil).Once()
+		emailSender.On("SendVerificationEmail", ctx, "jane@example.com", mock.AnythingOfType("string")).Return(nil).Once()
+
+		resp, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Email: strPtr("jane@example.com")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "jane@example.com", resp.Email)
+		assert.False(t, resp.EmailVerified)
+		userRepo.AssertExpectations(t)
+		emailSender.AssertExpectations(t)
+	})
+
+	t.Run("unchanged email keeps verification", func(t *testing.T) {
+		ctx := context.Background()
+		userRepo := new(MockUserRepository)
+		service := NewService(userRepo, new(MockContactRepository), "test-secret", WithRequireEmailVerification(true))
+
+		user := newUser()
+		user.EmailVerified = true
+		userRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		userRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == "john@example.com" && u.EmailVerified
+		})).Return(nil).Once()
+
+		resp, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Email: strPtr("john@example.com")})
+
+		assert.NoError(t, err)
+		assert.True(t, resp.EmailVerified)
+		userRepo.AssertNotCalled(t, "CheckEmailExists", mock.Anything, mock.Anything, mock.Anything)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("taken email is rejected", func(t *testing.T) {
+		ctx := context.Background()
+		userRepo := new(MockUserRepository)
+		service := NewService(userRepo, new(MockContactRepository), "test-secret")
+
+		userRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+		userRepo.On("CheckEmailExists", ctx, "taken@example.com", uint(1)).Return(true, nil).Once()
+
+		resp, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Email: strPtr("taken@example.com")})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid email is rejected", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+
+		_, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Email: strPtr("not-an-email")})
+
+		assert.ErrorIs(t, err, ErrInvalidEmail)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("null and omitted decode to unchanged, empty string to clear", func(t *testing.T) {
+		var omitted, null, empty models.UpdateProfileRequest
+		assert.NoError(t, json.Unmarshal([]byte(`{}`), &omitted))
+		assert.NoError(t, json.Unmarshal([]byte(`{"phone":null}`), &null))
+		assert.NoError(t, json.Unmarshal([]byte(`{"phone":""}`), &empty))
+
+		assert.Nil(t, omitted.Phone)
+		assert.Nil(t, null.Phone)
+		if assert.NotNil(t, empty.Phone) {
+			assert.Equal(t, "", *empty.Phone)
+		}
+	})
+}
+
+func TestService_GetProfile(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("successful get profile", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{
+			ID:       1,
+			FullName: "John Doe",
+			Email:    "john@example.com",
+			Phone:    strPtr("081234567890"),
+		}
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+
+		profile, err := service.GetProfile(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, profile)
+		assert.Equal(t, "john@example.com", profile.Email)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockUserRepo.On("GetByID", ctx, uint(999)).Return(nil, repository.ErrNotFound).Once()
+
+		profile, err := service.GetProfile(ctx, 999)
+
+		assert.Error(t, err)
+		assert.Nil(t, profile)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ProfileCache(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}
+
+	t.Run("hit skips the database", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		cache := new(MockProfileCache)
+		service := NewService(mockUserRepo, new(MockContactRepository), "test-secret", WithProfileCache(cache, 0))
+
+		cache.On("Get", ctx, uint(1)).Return([]byte(`{"id":1,"full_name":"John Doe","email":"john@example.com"}`), true, nil).Once()
+
+		profile, err := service.GetProfile(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "John Doe", profile.FullName)
+		mockUserRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("miss reads the database and fills the cache", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		cache := new(MockProfileCache)
+		service := NewService(mockUserRepo, new(MockContactRepository), "test-secret", WithProfileCache(cache, 30*time.Second))
+
+		cache.On("Get", ctx, uint(1)).Return(nil, false, nil).Once()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		cache.On("Set", ctx, uint(1), mock.MatchedBy(func(data []byte) bool {
+			var cached models.UserResponse
+			return json.Unmarshal(data, &cached) == nil && cached.Email == "john@example.com"
+		}), 30*time.Second).Return(nil).Once()
+
+		profile, err := service.GetProfile(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "john@example.com", profile.Email)
+		mockUserRepo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("cache errors fall back to the database", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		cache := new(MockProfileCache)
+		service := NewService(mockUserRepo, new(MockContactRepository), "test-secret", WithProfileCache(cache, 0))
+
+		cache.On("Get", ctx, uint(1)).Return(nil, false, errors.New("connection refused")).Once()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		cache.On("Set", ctx, uint(1), mock.Anything, time.Minute).Return(errors.New("connection refused")).Once()
+
+		profile, err := service.GetProfile(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), profile.ID)
+	})
+
+	t.Run("update invalidates", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepository)
+		cache := new(MockProfileCache)
+		service := NewService(mockUserRepo, new(MockContactRepository), "test-secret", WithProfileCache(cache, 0))
+
+		name := "Johnny Doe"
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, FullName: "John Doe"}, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.AnythingOfType("*models.User")).Return(nil).Once()
+		cache.On("Delete", ctx, uint(1)).Return(nil).Once()
+
+		_, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{FullName: &name})
+
+		assert.NoError(t, err)
+		cache.AssertExpectations(t)
+	})
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	newUser := func() *models.User {
+		hashedPassword, _ := service.hashPassword("password123")
+		return &models.User{ID: 1, Email: "john@example.com", Password: hashedPassword}
+	}
+
+	t.Run("successful change password", func(t *testing.T) {
+		ctx := context.Background()
+		user := newUser()
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return service.verifyPassword(u.Password, "newpassword456") == nil
+		})).Return(nil).Once()
+
+		err := service.ChangePassword(ctx, 1, "password123", "newpassword456")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong old password", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+
+		err := service.ChangePassword(ctx, 1, "wrongpassword", "newpassword456")
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("weak new password", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+
+		err := service.ChangePassword(ctx, 1, "password123", "short")
+
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("new password same as old", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(newUser(), nil).Once()
+
+		err := service.ChangePassword(ctx, 1, "password123", "password123")
+
+		assert.ErrorIs(t, err, ErrPasswordUnchanged)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockUserRepo.On("GetByID", ctx, uint(999)).Return(nil, repository.ErrNotFound).Once()
+
+		err := service.ChangePassword(ctx, 999, "password123", "newpassword456")
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_UpdateAvatar(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockStorage := new(MockFileStorage)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithAvatarStorage(mockStorage))
+
+	pngData := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	jpegData := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, make([]byte, 32)...)
+
+	t.Run("successful png upload", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockStorage.On("Save", ctx, mock.MatchedBy(func(name string) bool {
+			return strings.HasPrefix(name, "1-") && strings.HasSuffix(name, ".png")
+		}), pngData).Return("/uploads/avatars/1-abc.png", nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.AvatarURL != nil && *u.AvatarURL == "/uploads/avatars/1-abc.png"
+		})).Return(nil).Once()
+
+		url, err := service.UpdateAvatar(ctx, 1, pngData, "image/png")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/uploads/avatars/1-abc.png", url)
+		mockUserRepo.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("jpeg without declared content type", func(t *testing.T) {
+		ctx := context.Background()
+		user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockStorage.On("Save", ctx, mock.MatchedBy(func(name string) bool {
+			return strings.HasSuffix(name, ".jpg")
+		}), jpegData).Return("/uploads/avatars/1-abc.jpg", nil).Once()
+		mockUserRepo.On("Update", ctx, mock.Anything).Return(nil).Once()
+
+		url, err := service.UpdateAvatar(ctx, 1, jpegData, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/uploads/avatars/1-abc.jpg", url)
+		mockUserRepo.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("oversized file", func(t *testing.T) {
+		data := append(append([]byte{}, pngData...), make([]byte, maxAvatarSize)...)
+
+		url, err := service.UpdateAvatar(context.Background(), 1, data, "image/png")
+
+		assert.ErrorIs(t, err, ErrAvatarTooLarge)
+		assert.Empty(t, url)
+	})
+
+	t.Run("non-image file", func(t *testing.T) {
+		url, err := service.UpdateAvatar(context.Background(), 1, []byte("just some text"), "image/png")
+
+		assert.ErrorIs(t, err, ErrInvalidAvatarType)
+		assert.Empty(t, url)
+	})
+
+	t.Run("unsupported image type", func(t *testing.T) {
+		gifData := append([]byte("GIF89a"), make([]byte, 32)...)
+
+		_, err := service.UpdateAvatar(context.Background(), 1, gifData, "image/gif")
+
+		assert.ErrorIs(t, err, ErrInvalidAvatarType)
+	})
+
+	t.Run("declared type does not match content", func(t *testing.T) {
+		_, err := service.UpdateAvatar(context.Background(), 1, pngData, "image/jpeg")
+
+		assert.ErrorIs(t, err, ErrInvalidAvatarType)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(99)).Return(nil, repository.ErrNotFound).Once()
+
+		_, err := service.UpdateAvatar(ctx, 99, pngData, "image/png")
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_RS256Signing(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "jwt.key")
+	publicPath := filepath.Join(dir, "jwt.pub")
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	assert.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}), 0o600))
+	assert.NoError(t, os.WriteFile(publicPath, publicPEM, 0o644))
+
+	loadedPrivate, loadedPublic, err := LoadRSAKeys(privatePath, publicPath)
+	assert.NoError(t, err)
+
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithRSAKeys(loadedPrivate, loadedPublic))
+	user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}
+
+	t.Run("sign and verify round trip", func(t *testing.T) {
+		token, err := service.generateToken(user, "")
+		assert.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+		assert.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+
+		userID, err := service.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	t.Run("HS256 token rejected", func(t *testing.T) {
+		hmacService := NewService(mockUserRepo, mockContactRepo, "test-secret")
+		token, err := hmacService.generateToken(user, "")
+		assert.NoError(t, err)
+
+		userID, err := service.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.Equal(t, uint(0), userID)
+	})
+
+	t.Run("HS256 token signed with the public key rejected", func(t *testing.T) {
+		claims := &JWTClaims{
+			UserID:    1,
+			TokenType: TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				Issuer:    tokenIssuer,
+				Audience:  jwt.ClaimStrings{defaultTokenAudience},
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(publicPEM)
+		assert.NoError(t, err)
+
+		_, err = service.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("missing key file", func(t *testing.T) {
+		_, _, err := LoadRSAKeys(filepath.Join(dir, "missing.key"), publicPath)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_JWTKeyRotation(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}
+
+	before := NewService(mockUserRepo, mockContactRepo, "test-secret",
+		WithJWTKeys(map[string]string{"2025-01": "old-secret"}, "2025-01"))
+	oldToken, err := before.generateToken(user, "")
+	assert.NoError(t, err)
+	legacyToken, err := NewService(mockUserRepo, mockContactRepo, "test-secret").generateToken(user, "")
+	assert.NoError(t, err)
+
+	// The new key is added and made active; the old one stays listed until its tokens expire
+	after := NewService(mockUserRepo, mockContactRepo, "test-secret",
+		WithJWTKeys(map[string]string{"2025-01": "old-secret", "2025-06": "new-secret"}, "2025-06"))
+
+	t.Run("new tokens use the active kid", func(t *testing.T) {
+		token, err := after.generateToken(user, "")
+		assert.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+		assert.NoError(t, err)
+		assert.Equal(t, "2025-06", parsed.Header["kid"])
+
+		userID, err := after.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+
+		// The service that does not know the new key rejects it
+		_, err = before.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("tokens signed with an old kid still validate", func(t *testing.T) {
+		userID, err := after.ValidateToken(context.Background(), oldToken)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	t.Run("tokens without a kid use the secret", func(t *testing.T) {
+		userID, err := after.ValidateToken(context.Background(), legacyToken)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	t.Run("tokens without a kid are rejected once the secret is dropped", func(t *testing.T) {
+		keysOnly := NewService(mockUserRepo, mockContactRepo, "",
+			WithJWTKeys(map[string]string{"2025-06": "new-secret"}, "2025-06"))
+
+		_, err := keysOnly.ValidateToken(context.Background(), legacyToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		// A token signed with the empty secret must not pass either
+		forged, err := NewService(mockUserRepo, mockContactRepo, "").generateToken(user, "")
+		assert.NoError(t, err)
+		_, err = keysOnly.ValidateToken(context.Background(), forged)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		token, err := keysOnly.generateToken(user, "")
+		assert.NoError(t, err)
+		userID, err := keysOnly.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	t.Run("removed keys no longer validate", func(t *testing.T) {
+		rotated := NewService(mockUserRepo, mockContactRepo, "test-secret",
+			WithJWTKeys(map[string]string{"2025-06": "new-secret"}, "2025-06"))
+
+		_, err := rotated.ValidateToken(context.Background(), oldToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestService_ValidateToken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("valid token", func(t *testing.T) {
+		user := &models.User{
+			ID:       1,
+			FullName: "John Doe",
+			Email:    "john@example.com",
+		}
+
+		token, err := service.generateToken(user, "")
+		assert.NoError(t, err)
+
+		userID, err := service.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		userID, err := service.ValidateToken(context.Background(), "invalid-token")
+		assert.Error(t, err)
+		assert.Equal(t, uint(0), userID)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := &JWTClaims{
+			UserID:    1,
+			TokenType: TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				Issuer:    "billing-service",
+				Audience:  jwt.ClaimStrings{defaultTokenAudience},
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		assert.NoError(t, err)
+
+		userID, err := service.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.Equal(t, uint(0), userID)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		other := NewService(mockUserRepo, mockContactRepo, "test-secret", WithJWTAudience("admin-api"))
+		token, err := other.generateToken(&models.User{ID: 1}, "")
+		assert.NoError(t, err)
+
+		userID, err := service.ValidateToken(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.Equal(t, uint(0), userID)
+
+		userID, err = other.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	t.Run("expiry defaults to 24 hours", func(t *testing.T) {
+		token, err := service.generateToken(&models.User{ID: 1}, "")
+		assert.NoError(t, err)
+
+		claims, err := service.parseAccessToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+	})
+
+	t.Run("expiry matches configured value", func(t *testing.T) {
+		svc := NewService(mockUserRepo, mockContactRepo, "test-secret", WithAccessTokenTTL(15*time.Minute))
+
+		token, err := svc.generateToken(&models.User{ID: 1}, "")
+		assert.NoError(t, err)
+
+		claims, err := svc.parseAccessToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+	})
+
+	t.Run("zero expiry falls back to default", func(t *testing.T) {
+		svc := NewService(mockUserRepo, mockContactRepo, "test-secret", WithAccessTokenTTL(0))
+
+		token, err := svc.generateToken(&models.User{ID: 1}, "")
+		assert.NoError(t, err)
+
+		claims, err := svc.parseAccessToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+	})
+
+	t.Run("refresh token rejected as access token", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: "john@example.com"}
+
+		refreshToken, _, _, err := service.generateRefreshToken(user, "")
+		assert.NoError(t, err)
+
+		userID, err := service.ValidateToken(context.Background(), refreshToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.Equal(t, uint(0), userID)
+	})
+}
+
+func TestService_IntrospectToken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	signAccessToken := func(secret string, expiresAt time.Time) string {
+		claims := &JWTClaims{
+			UserID:    1,
+			Email:     "john@example.com",
+			TokenType: TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    tokenIssuer,
+				Audience:  jwt.ClaimStrings{defaultTokenAudience},
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+				IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-24 * time.Hour)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return token
+	}
+
+	t.Run("active token", func(t *testing.T) {
+		token, err := service.generateToken(&models.User{ID: 1, Email: "john@example.com"}, "")
+		assert.NoError(t, err)
+
+		claims, active, err := service.IntrospectToken(context.Background(), token)
+
+		assert.NoError(t, err)
+		assert.True(t, active)
+		assert.Equal(t, uint(1), claims.UserID)
+		assert.Equal(t, "john@example.com", claims.Email)
+		assert.NotNil(t, claims.ExpiresAt)
+		assert.NotNil(t, claims.IssuedAt)
+	})
+
+	t.Run("expired token returns its claims as inactive", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		claims, active, err := service.IntrospectToken(context.Background(), signAccessToken("test-secret", expiresAt))
+
+		assert.NoError(t, err)
+		assert.False(t, active)
+		assert.Equal(t, uint(1), claims.UserID)
+		assert.Equal(t, expiresAt.Unix(), claims.ExpiresAt.Unix())
+	})
+
+	t.Run("expired token with a bad signature is invalid", func(t *testing.T) {
+		claims, active, err := service.IntrospectToken(context.Background(), signAccessToken("other-secret", time.Now().Add(-time.Hour)))
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.False(t, active)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("revoked token is inactive", func(t *testing.T) {
+		store := new(MockRevocationStore)
+		svc := NewService(mockUserRepo, mockContactRepo, "test-secret", WithTokenRevocationStore(store))
+		token, err := svc.generateToken(&models.User{ID: 1}, "")
+		assert.NoError(t, err)
+		store.On("IsRevoked", mock.Anything, mock.Anything).Return(true, nil)
+
+		claims, active, err := svc.IntrospectToken(context.Background(), token)
+
+		assert.NoError(t, err)
+		assert.False(t, active)
+		assert.Equal(t, uint(1), claims.UserID)
+	})
+
+	t.Run("malformed token is invalid", func(t *testing.T) {
+		_, active, err := service.IntrospectToken(context.Background(), "not-a-token")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.False(t, active)
+	})
+}
+
+func TestService_RefreshToken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithRefreshTokenRepository(mockTokenRepo))
+
+	user := &models.User{
+		ID:       1,
+		FullName: "John Doe",
+		Email:    "john@example.com",
+	}
+
+	t.Run("successful refresh rotates token", func(t *testing.T) {
+		ctx := context.Background()
+		refreshToken, jti, expiresAt, err := service.generateRefreshToken(user, "")
+		assert.NoError(t, err)
+
+		mockTokenRepo.On("GetByJTI", ctx, jti).Return(&models.RefreshToken{UserID: 1, JTI: jti, ExpiresAt: expiresAt}, nil).Once()
+		mockTokenRepo.On("Revoke", ctx, jti).Return(nil).Once()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockTokenRepo.On("Create", ctx, mock.MatchedBy(func(rt *models.RefreshToken) bool {
+			return rt.UserID == 1 && rt.JTI != "" && rt.JTI != jti
+		})).Return(nil).Once()
+
+		resp, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.NotEmpty(t, resp.Token)
+		assert.NotEmpty(t, resp.RefreshToken)
+		assert.NotEqual(t, refreshToken, resp.RefreshToken)
+		mockTokenRepo.AssertExpectations(t)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("expired refresh token", func(t *testing.T) {
+		claims := &RefreshClaims{
+			UserID:    1,
+			TokenType: TokenTypeRefresh,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "expired-jti",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(time.Now().Add(-8 * 24 * time.Hour)),
+			},
+		}
+		expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		assert.NoError(t, err)
+
+		resp, err := service.RefreshToken(context.Background(), expired)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("invalid refresh token", func(t *testing.T) {
+		resp, err := service.RefreshToken(context.Background(), "invalid-token")
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("refresh token signed with another secret", func(t *testing.T) {
+		other := NewService(mockUserRepo, mockContactRepo, "other-secret")
+		refreshToken, _, _, err := other.generateRefreshToken(user, "")
+		assert.NoError(t, err)
+
+		resp, err := service.RefreshToken(context.Background(), refreshToken)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("access token used as refresh token", func(t *testing.T) {
+		accessToken, err := service.generateToken(user, "")
+		assert.NoError(t, err)
+
+		resp, err := service.RefreshToken(context.Background(), accessToken)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("revoked refresh token", func(t *testing.T) {
+		ctx := context.Background()
+		refreshToken, jti, expiresAt, err := service.generateRefreshToken(user, "")
+		assert.NoError(t, err)
+
+		revokedAt := time.Now()
+		mockTokenRepo.On("GetByJTI", ctx, jti).Return(&models.RefreshToken{UserID: 1, JTI: jti, ExpiresAt: expiresAt, RevokedAt: &revokedAt}, nil).Once()
+
+		resp, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown refresh token jti", func(t *testing.T) {
+		ctx := context.Background()
+		refreshToken, jti, _, err := service.generateRefreshToken(user, "")
+		assert.NoError(t, err)
+
+		mockTokenRepo.On("GetByJTI", ctx, jti).Return(nil, repository.ErrNotFound).Once()
+
+		resp, err := service.RefreshToken(ctx, refreshToken)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		mockTokenRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_Sessions(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockSessionRepo := new(MockSessionRepository)
+	mockStore := new(MockRevocationStore)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret",
+		WithRefreshTokenRepository(mockTokenRepo),
+		WithSessionRepository(mockSessionRepo),
+		WithTokenRevocationStore(mockStore),
+	)
+
+	user := &models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}
+
+	// Start two sessions from different devices
+	var started []*models.Session
+	mockTokenRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockSessionRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Session")).
+		Run(func(args mock.Arguments) { started = append(started, args.Get(1).(*models.Session)) }).
+		Return(nil).Twice()
+
+	phone, err := service.issueTokens(WithUserAgent(context.Background(), "Phone/1.0"), user, "")
+	assert.NoError(t, err)
+	laptop, err := service.issueTokens(WithUserAgent(context.Background(), "Laptop/1.0"), user, "")
+	assert.NoError(t, err)
+	if !assert.Len(t, started, 2) {
+		return
+	}
+	phoneSession, laptopSession := started[0], started[1]
+	assert.Equal(t, "Phone/1.0", phoneSession.UserAgent)
+	assert.NotEqual(t, phoneSession.JTI, laptopSession.JTI)
+
+	t.Run("revoking one session leaves others working", func(t *testing.T) {
+		ctx := context.Background()
+		mockSessionRepo.On("Revoke", ctx, uint(1), phoneSession.JTI).Return(nil).Once()
+		mockStore.On("Revoke", ctx, phoneSession.JTI, defaultAccessTokenTTL).Return(nil).Once()
+
+		assert.NoError(t, service.RevokeSession(ctx, 1, phoneSession.JTI))
+
+		// Access tokens of the revoked session are rejected, others still validate
+		mockStore.On("IsRevoked", mock.Anything, phoneSession.JTI).Return(true, nil)
+		mockStore.On("IsRevoked", mock.Anything, mock.Anything).Return(false, nil)
+
+		_, err := service.ValidateToken(context.Background(), phone.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		userID, err := service.ValidateToken(context.Background(), laptop.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+
+		// The revoked session's refresh token no longer works
+		revokedAt := time.Now()
+		phoneRefresh, err := service.parseRefreshToken(phone.RefreshToken)
+		assert.NoError(t, err)
+		mockTokenRepo.On("GetByJTI", ctx, phoneRefresh.ID).Return(&models.RefreshToken{UserID: 1, JTI: phoneRefresh.ID}, nil).Once()
+		mockTokenRepo.On("Revoke", ctx, phoneRefresh.ID).Return(nil).Once()
+		mockSessionRepo.On("GetByJTI", ctx, phoneSession.JTI).Return(&models.Session{UserID: 1, JTI: phoneSession.JTI, RevokedAt: &revokedAt}, nil).Once()
+
+		resp, err := service.RefreshToken(ctx, phone.RefreshToken)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		// The other session refreshes and keeps its identity
+		laptopRefresh, err := service.parseRefreshToken(laptop.RefreshToken)
+		assert.NoError(t, err)
+		mockTokenRepo.On("GetByJTI", ctx, laptopRefresh.ID).Return(&models.RefreshToken{UserID: 1, JTI: laptopRefresh.ID}, nil).Once()
+		mockTokenRepo.On("Revoke", ctx, laptopRefresh.ID).Return(nil).Once()
+		mockSessionRepo.On("GetByJTI", ctx, laptopSession.JTI).Return(laptopSession, nil).Once()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockSessionRepo.On("Touch", ctx, laptopSession.JTI, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		resp, err = service.RefreshToken(ctx, laptop.RefreshToken)
+		assert.NoError(t, err)
+		refreshed, err := service.parseAccessToken(resp.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, laptopSession.JTI, refreshed.SessionID)
+
+		mockSessionRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("revoke unknown session", func(t *testing.T) {
+		ctx := context.Background()
+		mockSessionRepo.On("Revoke", ctx, uint(1), "unknown").Return(repository.ErrNotFound).Once()
+
+		err := service.RevokeSession(ctx, 1, "unknown")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("list active sessions", func(t *testing.T) {
+		ctx := context.Background()
+		mockSessionRepo.On("ListActive", ctx, uint(1)).Return([]models.Session{*laptopSession}, nil).Once()
+
+		sessions, err := service.ListSessions(ctx, 1)
+		assert.NoError(t, err)
+		if assert.Len(t, sessions, 1) {
+			assert.Equal(t, laptopSession.JTI, sessions[0].JTI)
+			assert.Equal(t, "Laptop/1.0", sessions[0].UserAgent)
+		}
+	})
+}
+
+func TestService_Logout(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	mockStore := new(MockRevocationStore)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithTokenRevocationStore(mockStore))
+
+	user := &models.User{ID: 1, Email: "john@example.com"}
+
+	t.Run("logout revokes jti for remaining lifetime", func(t *testing.T) {
+		ctx := context.Background()
+		token, err := service.generateToken(user, "")
+		assert.NoError(t, err)
+
+		claims, err := service.parseAccessToken(token)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, claims.ID)
+
+		mockStore.On("Revoke", ctx, claims.ID, mock.MatchedBy(func(ttl time.Duration) bool {
+			return ttl > 23*time.Hour && ttl <= 24*time.Hour
+		})).Return(nil).Once()
+
+		err = service.Logout(ctx, token)
+
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("revoked token is rejected", func(t *testing.T) {
+		token, err := service.generateToken(user, "")
+		assert.NoError(t, err)
+
+		claims, err := service.parseAccessToken(token)
+		assert.NoError(t, err)
+
+		// The check runs with the caller's context, e.g. the request's
+		type ctxKey struct{}
+		reqCtx := context.WithValue(context.Background(), ctxKey{}, "request")
+		mockStore.On("IsRevoked", reqCtx, claims.ID).Return(true, nil).Once()
+
+		userID, err := service.ValidateToken(reqCtx, token)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.Equal(t, uint(0), userID)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("non-revoked token is accepted", func(t *testing.T) {
+		token, err := service.generateToken(user, "")
+		assert.NoError(t, err)
+
+		mockStore.On("IsRevoked", mock.Anything, mock.AnythingOfType("string")).Return(false, nil).Once()
+
+		userID, err := service.ValidateToken(context.Background(), token)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("logout with invalid token", func(t *testing.T) {
+		err := service.Logout(context.Background(), "invalid-token")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+// ============================================================================
 // CONTACT SERVICE TESTS
 // ============================================================================
 
-func TestService_CreateContact(t *testing.T) {
+func TestService_CreateContact(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("successful create contact", func(t *testing.T) {
+		ctx := context.Background()
+		email := "contact@example.com"
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081234567890",
+			Email:    &email,
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, "Jane Doe", resp.FullName)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("phone already exists", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081234567890",
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(true, nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("concurrent create with same phone", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081234567890",
+		}
+
+		// The pre-check passes but the unique index rejects the insert
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 0).Return(repository.ErrDuplicatePhone).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid email format", func(t *testing.T) {
+		ctx := context.Background()
+		invalidEmail := "invalid-email"
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081234567890",
+			Email:    &invalidEmail,
+		}
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidEmail)
+	})
+
+	t.Run("reports every invalid field", func(t *testing.T) {
+		invalidEmail := "invalid-email"
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "not-a-phone",
+			Email:    &invalidEmail,
+		}
+
+		resp, err := service.CreateContact(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		var verr *ValidationError
+		if assert.ErrorAs(t, err, &verr) {
+			assert.Equal(t, map[string][]string{
+				"phone": {"invalid format"},
+				"email": {"invalid format"},
+			}, verr.Fields)
+		}
+		assert.ErrorIs(t, err, ErrInvalidPhone)
+		assert.ErrorIs(t, err, ErrInvalidEmail)
+	})
+}
+
+func TestService_PhoneNormalization(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithPhoneNormalization(true))
+
+	t.Run("duplicate across formats", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+6281234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == "+6281234567890"
+		}), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890"})
+		assert.NoError(t, err)
+		assert.Equal(t, "+6281234567890", resp.Phone)
+
+		// The same number in international format is now a duplicate
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+6281234567890", uint(0)).Return(true, nil).Once()
+
+		resp, err = service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane D", Phone: "+6281234567890"})
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("non-Indonesian number unchanged", func(t *testing.T) {
+		ctx := context.Background()
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+14155552671", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "John Doe", Phone: "+14155552671"})
+		assert.NoError(t, err)
+		assert.Equal(t, "+14155552671", resp.Phone)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update normalizes before duplicate check", func(t *testing.T) {
+		ctx := context.Background()
+		existing := &models.Contact{ID: 3, UserID: 1, FullName: "Jane Doe", Phone: "+6281111111111"}
+		phone := "6281234567890"
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(3)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+6281234567890", uint(3)).Return(true, nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 3, &models.UpdateContactRequest{Phone: &phone})
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestDetectCountry(t *testing.T) {
+	tests := []struct {
+		phone   string
+		country string
+	}{
+		{"+6281234567890", "ID"},
+		// Only E.164 numbers carry a country code
+		{"6281234567890", ""},
+		{"0812-3456-7890", ""},
+		{"8123456789", ""},
+		{"+1 (415) 555-2671", "US"},
+		{"+6591234567", "SG"},
+		{"+447911123456", "GB"},
+		{"+9991234567890", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.country, detectCountry(tt.phone), tt.phone)
+	}
+}
+
+func TestDetectCountryBackfillInSync(t *testing.T) {
+	// The migration backfilling phone_country must detect the same calling codes
+	backfill, err := os.ReadFile(filepath.Join("..", "migrations", "sql", "024_backfill_contact_phone_country.up.sql"))
+	if err != nil {
+		t.Fatalf("failed to read backfill migration: %v", err)
+	}
+	for code, country := range countryCallingCodes {
+		assert.Contains(t, string(backfill), fmt.Sprintf("WHEN phone LIKE '+%s%%' THEN '%s'", code, country))
+	}
+	assert.Equal(t, len(countryCallingCodes), strings.Count(string(backfill), "WHEN phone LIKE"))
+}
+
+func TestService_ContactPhoneCountry(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+	ctx := context.Background()
+
+	t.Run("create stores the detected country", func(t *testing.T) {
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+14155552671", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.PhoneCountry != nil && *c.PhoneCountry == "US"
+		}), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "John Doe", Phone: "+14155552671"})
+		assert.NoError(t, err)
+		if assert.NotNil(t, resp.PhoneCountry) {
+			assert.Equal(t, "US", *resp.PhoneCountry)
+		}
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown prefix stores no country", func(t *testing.T) {
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+9991234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.PhoneCountry == nil
+		}), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Roe", Phone: "+9991234567890"})
+		assert.NoError(t, err)
+		assert.Nil(t, resp.PhoneCountry)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update redetects the country", func(t *testing.T) {
+		us := "US"
+		existing := &models.Contact{ID: 3, UserID: 1, FullName: "John Doe", Phone: "+14155552671", PhoneCountry: &us}
+		phone := "+6281234567890"
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(3)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(3)).Return(false, nil).Once()
+		mockContactRepo.On("Update", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.PhoneCountry != nil && *c.PhoneCountry == "ID"
+		}), mock.Anything).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 3, &models.UpdateContactRequest{Phone: &phone})
+		assert.NoError(t, err)
+		if assert.NotNil(t, resp.PhoneCountry) {
+			assert.Equal(t, "ID", *resp.PhoneCountry)
+		}
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_DuplicateContactPhones(t *testing.T) {
+	ctx := context.Background()
+	const phone = "081234567890"
+
+	t.Run("rejected by default", func(t *testing.T) {
+		mockContactRepo := new(MockContactRepository)
+		service := NewService(new(MockUserRepository), mockContactRepo, "test-secret")
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(0)).Return(true, nil).Once()
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Front Desk", Phone: phone})
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+
+		existing := &models.Contact{ID: 3, UserID: 1, FullName: "Reception", Phone: "081111111111"}
+		newPhone := phone
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(3)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(3)).Return(true, nil).Once()
+		resp, err = service.UpdateContact(ctx, 1, 3, &models.UpdateContactRequest{Phone: &newPhone})
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("allowed with a warning", func(t *testing.T) {
+		mockContactRepo := new(MockContactRepository)
+		service := NewService(new(MockUserRepository), mockContactRepo, "test-secret", WithDuplicateContactPhones(true))
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(0)).Return(true, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == phone && c.PhoneShared
+		}), 0).Return(nil).Once()
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Front Desk", Phone: phone})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"phone 081234567890 is already used by another contact"}, resp.Warnings)
+
+		existing := &models.Contact{ID: 3, UserID: 1, FullName: "Reception", Phone: "081111111111"}
+		newPhone := phone
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(3)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(3)).Return(true, nil).Once()
+		mockContactRepo.On("Update", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == phone && c.PhoneShared
+		}), mock.Anything).Return(nil).Once()
+		resp, err = service.UpdateContact(ctx, 1, 3, &models.UpdateContactRequest{Phone: &newPhone})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Warnings, 1)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("no warning for a unique phone", func(t *testing.T) {
+		mockContactRepo := new(MockContactRepository)
+		service := NewService(new(MockUserRepository), mockContactRepo, "test-secret", WithDuplicateContactPhones(true))
+
+		// A unique number stays covered by the unique index
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return !c.PhoneShared
+		}), 0).Return(nil).Once()
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Front Desk", Phone: phone})
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Warnings)
+
+		existing := &models.Contact{ID: 3, UserID: 1, FullName: "Reception", Phone: "081111111111", PhoneShared: true}
+		newPhone := phone
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(3)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), phone, uint(3)).Return(false, nil).Once()
+		mockContactRepo.On("Update", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == phone && !c.PhoneShared
+		}), mock.Anything).Return(nil).Once()
+		resp, err = service.UpdateContact(ctx, 1, 3, &models.UpdateContactRequest{Phone: &newPhone})
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Warnings)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_PhoneFormats(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	// The same rule backs utils.ValidatePhone, contact creation and profile updates
+	const spaced = "+62 812 3456 7890"
+	assert.True(t, utils.ValidatePhone(spaced))
+
+	t.Run("contact creation", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "+6281234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == "+6281234567890"
+		}), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Doe", Phone: spaced})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "+6281234567890", resp.Phone)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("profile update", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(u *models.User) bool {
+			return u.Phone != nil && *u.Phone == "+6281234567890"
+		})).Return(nil).Once()
+
+		// Separators are stripped when the request is bound
+		resp, err := service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Phone: strPtr(utils.CleanPhone(spaced))})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "+6281234567890", *resp.Phone)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejected consistently", func(t *testing.T) {
+		ctx := context.Background()
+		const invalid = "+62 0812 3456 7890"
+		assert.False(t, utils.ValidatePhone(invalid))
+
+		_, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Doe", Phone: invalid})
+		assert.ErrorIs(t, err, ErrInvalidPhone)
+
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, FullName: "John Doe", Email: "john@example.com"}, nil).Once()
+		_, err = service.UpdateProfile(ctx, 1, &models.UpdateProfileRequest{Phone: strPtr(invalid)})
+		assert.ErrorIs(t, err, ErrInvalidPhone)
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ContactTags(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("create with tags", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081234567890",
+			Tags:     []string{" Work ", "family", "work", ""},
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return assert.ObjectsAreEqual([]string{"work", "family"}, c.Tags)
+		}), 0).
+			Run(func(args mock.Arguments) { args.Get(1).(*models.Contact).ID = 7 }).
+			Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"work", "family"}, resp.Tags)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("too many tags", func(t *testing.T) {
+		tags := make([]string, 11)
+		for i := range tags {
+			tags[i] = fmt.Sprintf("tag%d", i)
+		}
+		req := &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890", Tags: tags}
+
+		resp, err := service.CreateContact(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidTags)
+	})
+
+	t.Run("tag too long", func(t *testing.T) {
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081234567890",
+			Tags:     []string{strings.Repeat("a", 31)},
+		}
+
+		resp, err := service.CreateContact(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidTags)
+	})
+
+	t.Run("update with empty tags clears them", func(t *testing.T) {
+		ctx := context.Background()
+		existing := &models.Contact{ID: 7, UserID: 1, FullName: "Jane Doe", Phone: "081234567890", Tags: []string{"work"}}
+		empty := []string{}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existing, nil).Once()
+		mockContactRepo.On("Update", ctx, existing, repository.ContactChildren{Tags: []string{}}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Tags: &empty})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Tags)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update without tags keeps them", func(t *testing.T) {
+		ctx := context.Background()
+		existing := &models.Contact{ID: 8, UserID: 1, FullName: "John Doe", Phone: "081234567891", Tags: []string{"family"}}
+		favorite := true
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(8)).Return(existing, nil).Once()
+		mockContactRepo.On("Update", ctx, existing, repository.ContactChildren{}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 8, &models.UpdateContactRequest{Favorite: &favorite})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"family"}, resp.Tags)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ContactNotes(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("create with notes at the limit", func(t *testing.T) {
+		ctx := context.Background()
+		notes := strings.Repeat("é", 2000)
+		req := &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890", Notes: &notes}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, notes, *resp.Notes)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("create with notes over the limit", func(t *testing.T) {
+		notes := strings.Repeat("a", 2001)
+		req := &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890", Notes: &notes}
+
+		resp, err := service.CreateContact(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrNotesTooLong)
+	})
+
+	t.Run("update with notes over the limit", func(t *testing.T) {
+		ctx := context.Background()
+		existing := &models.Contact{ID: 7, UserID: 1, FullName: "Jane Doe", Phone: "081234567890"}
+		notes := strings.Repeat("a", 2001)
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existing, nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Notes: &notes})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrNotesTooLong)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update with empty notes clears them", func(t *testing.T) {
+		ctx := context.Background()
+		old := "met at the conference"
+		existing := &models.Contact{ID: 8, UserID: 1, FullName: "John Doe", Phone: "081234567891", Notes: &old}
+		empty := ""
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(8)).Return(existing, nil).Once()
+		mockContactRepo.On("Update", ctx, mock.MatchedBy(func(c *models.Contact) bool { return c.Notes == nil }), repository.ContactChildren{}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 8, &models.UpdateContactRequest{Notes: &empty})
+
+		assert.NoError(t, err)
+		assert.Nil(t, resp.Notes)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ContactPhones(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	existingContact := func() *models.Contact {
+		return &models.Contact{
+			ID: 7, UserID: 1, FullName: "Jane Doe", Phone: "081111111111",
+			Phones: []models.ContactPhone{{ID: 1, ContactID: 7, Phone: "081111111111", IsPrimary: true}},
+		}
+	}
+
+	t.Run("create with a second number", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081111111111",
+			Phones:   []models.ContactPhoneRequest{{Phone: "082222222222", Label: "work"}},
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081111111111", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "082222222222", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == "081111111111" && len(c.Phones) == 2 && c.Phones[0].IsPrimary && !c.Phones[1].IsPrimary
+		}), 0).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "081111111111", resp.Phone)
+		assert.Equal(t, "work", resp.Phones[1].Label)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("second number already used by another contact", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.CreateContactRequest{
+			FullName: "Jane Doe",
+			Phone:    "081111111111",
+			Phones:   []models.ContactPhoneRequest{{Phone: "083333333333"}},
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081111111111", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "083333333333", uint(0)).Return(true, nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update adds a second number", func(t *testing.T) {
+		ctx := context.Background()
+		existing := existingContact()
+		phones := []models.ContactPhoneRequest{{Phone: "081111111111", IsPrimary: true}, {Phone: "082222222222", Label: "work"}}
+		expected := []models.ContactPhone{{Phone: "081111111111", IsPrimary: true}, {Phone: "082222222222", Label: "work"}}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "082222222222", uint(7)).Return(false, nil).Once()
+		mockContactRepo.On("Update", ctx, existing, repository.ContactChildren{Phones: expected}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Phones: &phones})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "081111111111", resp.Phone)
+		assert.Len(t, resp.Phones, 2)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update promotes the second number to primary", func(t *testing.T) {
+		ctx := context.Background()
+		existing := existingContact()
+		existing.Phones = append(existing.Phones, models.ContactPhone{ID: 2, ContactID: 7, Phone: "082222222222", Label: "work"})
+		phones := []models.ContactPhoneRequest{{Phone: "081111111111"}, {Phone: "082222222222", Label: "work", IsPrimary: true}}
+		expected := []models.ContactPhone{{Phone: "081111111111"}, {Phone: "082222222222", Label: "work", IsPrimary: true}}
+
+		// Both numbers already belong to the contact, so no duplicate check is needed
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existing, nil).Once()
+		mockContactRepo.On("Update", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.Phone == "082222222222"
+		}), repository.ContactChildren{Phones: expected}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Phones: &phones})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "082222222222", resp.Phone)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update phone replaces only the primary number", func(t *testing.T) {
+		ctx := context.Background()
+		existing := existingContact()
+		existing.Phones = append(existing.Phones, models.ContactPhone{ID: 2, ContactID: 7, Phone: "082222222222", Label: "work"})
+		phone := "084444444444"
+		expected := []models.ContactPhone{{Phone: "084444444444", IsPrimary: true}, {Phone: "082222222222", Label: "work"}}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existing, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "084444444444", uint(7)).Return(false, nil).Once()
+		mockContactRepo.On("Update", ctx, existing, repository.ContactChildren{Phones: expected}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Phone: &phone})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "084444444444", resp.Phone)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("more than one primary", func(t *testing.T) {
+		ctx := context.Background()
+		phones := []models.ContactPhoneRequest{{Phone: "081111111111", IsPrimary: true}, {Phone: "082222222222", IsPrimary: true}}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existingContact(), nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Phones: &phones})
+
+		assert.Nil(t, resp)
+		var verr *ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.Contains(t, verr.Fields, "phones")
+		assert.ErrorIs(t, err, ErrInvalidContactData)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("update email keeps the other addresses", func(t *testing.T) {
+		ctx := context.Background()
+		old := "jane@example.com"
+		existing := existingContact()
+		existing.Email = &old
+		existing.Emails = []models.ContactEmail{
+			{ID: 1, ContactID: 7, Email: old, IsPrimary: true},
+			{ID: 2, ContactID: 7, Email: "jane@work.example.com", Label: "work"},
+		}
+		email := "jane.doe@example.com"
+		expected := []models.ContactEmail{{Email: "jane.doe@example.com", IsPrimary: true}, {Email: "jane@work.example.com", Label: "work"}}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(existing, nil).Once()
+		mockContactRepo.On("Update", ctx, existing, repository.ContactChildren{Emails: expected}).Return(nil).Once()
+
+		resp, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{Email: &email})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", *resp.Email)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ImportContacts(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("imports valid rows and reports invalid ones", func(t *testing.T) {
+		ctx := context.Background()
+		badEmail := "not-an-email"
+		rows := []models.CreateContactRequest{
+			{FullName: "Jane Doe", Phone: "081234567890"},
+			{FullName: "", Phone: "081234567891"},
+			{FullName: "Bad Phone", Phone: "abc"},
+			{FullName: "Bad Email", Phone: "081234567892", Email: &badEmail},
+			{FullName: "Duplicate", Phone: "081234567893"},
+			{FullName: "John Doe", Phone: "081234567894", Favorite: true},
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567893", uint(0)).Return(true, nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567894", uint(0)).Return(false, nil).Once()
+		// The favorite column is kept, not only parsed
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.FullName == "Jane Doe" && !c.Favorite
+		}), 0).Return(nil).Once()
+		mockContactRepo.On("Create", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.FullName == "John Doe" && c.Favorite
+		}), 0).Return(nil).Once()
+
+		result, err := service.ImportContacts(ctx, 1, rows)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Imported)
+		assert.Equal(t, 4, result.Failed)
+		assert.Len(t, result.Errors, 4)
+		assert.Equal(t, []int{2, 3, 4, 5}, []int{result.Errors[0].Row, result.Errors[1].Row, result.Errors[2].Row, result.Errors[3].Row})
+		assert.Equal(t, ErrPhoneAlreadyExists.Error(), result.Errors[3].Message)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("repository failure aborts import", func(t *testing.T) {
+		ctx := context.Background()
+		rows := []models.CreateContactRequest{{FullName: "Jane Doe", Phone: "081234567895"}}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567895", uint(0)).Return(false, errors.New("db down")).Once()
+
+		result, err := service.ImportContacts(ctx, 1, rows)
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ContactLimit(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithMaxContactsPerUser(3))
+
+	t.Run("below the limit", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 3).Return(nil).Once()
+
+		resp, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("at the limit", func(t *testing.T) {
+		ctx := context.Background()
+		// The repository checks the limit in the insert transaction
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(2), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 3).Return(repository.ErrLimitReached).Once()
+
+		resp, err := service.CreateContact(ctx, 2, &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890"})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrContactLimitReached)
+		assert.Contains(t, err.Error(), "at most 3 contacts")
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("import stops at the limit", func(t *testing.T) {
+		ctx := context.Background()
+		rows := []models.CreateContactRequest{
+			{FullName: "Jane Doe", Phone: "081234567891"},
+			{FullName: "John Doe", Phone: "081234567892"},
+			{FullName: "Jim Doe", Phone: "081234567893"},
+		}
+
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(4), "081234567891", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 3).Return(nil).Once()
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(4), "081234567892", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 3).Return(repository.ErrLimitReached).Once()
+
+		result, err := service.ImportContacts(ctx, 4, rows)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Imported)
+		assert.Equal(t, 2, result.Failed)
+		assert.Equal(t, []int{2, 3}, []int{result.Errors[0].Row, result.Errors[1].Row})
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("zero means unlimited", func(t *testing.T) {
+		ctx := context.Background()
+		unlimitedRepo := new(MockContactRepository)
+		unlimited := NewService(mockUserRepo, unlimitedRepo, "test-secret", WithMaxContactsPerUser(0))
+
+		unlimitedRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		unlimitedRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 0).Return(nil).Once()
+
+		_, err := unlimited.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890"})
+
+		assert.NoError(t, err)
+		unlimitedRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ExportContacts(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("returns all contacts", func(t *testing.T) {
+		ctx := context.Background()
+		contacts := []models.Contact{
+			{ID: 1, UserID: 1, FullName: "Contact 1", Phone: "081111111111"},
+			{ID: 2, UserID: 1, FullName: "Contact 2", Phone: "082222222222"},
+		}
+		mockContactRepo.On("ListAll", ctx, uint(1)).Return(contacts, nil).Once()
+
+		result, err := service.ExportContacts(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, contacts, result)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("ListAll", ctx, uint(2)).Return(nil, errors.New("db down")).Once()
+
+		result, err := service.ExportContacts(ctx, 2)
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_MergeContacts(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("only source has email", func(t *testing.T) {
+		ctx := context.Background()
+		sourceEmail := "jane@example.com"
+		target := &models.Contact{ID: 1, UserID: 1, FullName: "Jane", Phone: "081234567890", Tags: []string{"work"}}
+		source := &models.Contact{ID: 2, UserID: 1, FullName: "Jane Doe", Phone: "081234567891", Email: &sourceEmail, Favorite: true, Tags: []string{"family", "work"}}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(1)).Return(target, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(2)).Return(source, nil).Once()
+		mockContactRepo.On("Merge", ctx, mock.MatchedBy(func(c *models.Contact) bool {
+			return c.ID == 1 && c.Email != nil && *c.Email == sourceEmail
+		}), uint(2)).Return(nil).Once()
+
+		resp, err := service.MergeContacts(ctx, 1, 1, 2)
+
+		assert.NoError(t, err)
+		// Target wins for populated fields
+		assert.Equal(t, "Jane", resp.FullName)
+		assert.Equal(t, "081234567890", resp.Phone)
+		// Empty fields are filled from the source
+		assert.Equal(t, sourceEmail, *resp.Email)
+		assert.True(t, resp.Favorite)
+		assert.Equal(t, []string{"work", "family"}, resp.Tags)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("target email is kept", func(t *testing.T) {
+		ctx := context.Background()
+		targetEmail := "target@example.com"
+		sourceEmail := "source@example.com"
+		target := &models.Contact{ID: 3, UserID: 1, FullName: "John", Phone: "081234567892", Email: &targetEmail}
+		source := &models.Contact{ID: 4, UserID: 1, FullName: "John", Phone: "081234567893", Email: &sourceEmail}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(3)).Return(target, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(4)).Return(source, nil).Once()
+		mockContactRepo.On("Merge", ctx, target, uint(4)).Return(nil).Once()
+
+		resp, err := service.MergeContacts(ctx, 1, 3, 4)
+
+		assert.NoError(t, err)
+		assert.Equal(t, targetEmail, *resp.Email)
+		assert.False(t, resp.Favorite)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("phones and emails are combined", func(t *testing.T) {
+		ctx := context.Background()
+		targetEmail := "jane@example.com"
+		sourceEmail := "jane@work.example.com"
+		target := &models.Contact{ID: 6, UserID: 1, FullName: "Jane", Phone: "081234567890", Email: &targetEmail,
+			Phones: []models.ContactPhone{{Phone: "081234567890", IsPrimary: true}, {Phone: "082222222222", Label: "home"}},
+			Emails: []models.ContactEmail{{Email: targetEmail, IsPrimary: true}},
+		}
+		source := &models.Contact{ID: 7, UserID: 1, FullName: "Jane", Phone: "083333333333", Email: &sourceEmail,
+			Phones: []models.ContactPhone{{Phone: "083333333333", IsPrimary: true}, {Phone: "082222222222"}},
+			Emails: []models.ContactEmail{{Email: sourceEmail, Label: "work", IsPrimary: true}},
+		}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(6)).Return(target, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(source, nil).Once()
+		mockContactRepo.On("Merge", ctx, target, uint(7)).Return(nil).Once()
+
+		resp, err := service.MergeContacts(ctx, 1, 6, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "081234567890", resp.Phone)
+		assert.Equal(t, []models.ContactPhone{
+			{Phone: "081234567890", IsPrimary: true},
+			{Phone: "082222222222", Label: "home"},
+			{Phone: "083333333333"},
+		}, target.Phones)
+		assert.Equal(t, targetEmail, *resp.Email)
+		assert.Equal(t, []models.ContactEmail{
+			{Email: targetEmail, IsPrimary: true},
+			{Email: sourceEmail, Label: "work"},
+		}, target.Emails)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("source belongs to another user", func(t *testing.T) {
+		ctx := context.Background()
+		target := &models.Contact{ID: 5, UserID: 1, FullName: "Jane", Phone: "081234567894"}
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(5)).Return(target, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(99)).Return(nil, repository.ErrNotFound).Once()
+
+		resp, err := service.MergeContacts(ctx, 1, 5, 99)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrContactNotFound)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("merge into itself", func(t *testing.T) {
+		resp, err := service.MergeContacts(context.Background(), 1, 5, 5)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidContactData)
+	})
+}
+
+func TestService_DeleteContacts(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("some IDs belong to another user", func(t *testing.T) {
+		ctx := context.Background()
+		// Contact 3 belongs to another user, so the repository only deletes two rows
+		mockContactRepo.On("DeleteMany", ctx, uint(1), []uint{1, 2, 3}).
+			Return([]models.Contact{{ID: 1, UserID: 1}, {ID: 2, UserID: 1}}, nil).Once()
+
+		deleted, err := service.DeleteContacts(ctx, 1, []uint{1, 2, 2, 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("no valid IDs", func(t *testing.T) {
+		deleted, err := service.DeleteContacts(context.Background(), 1, []uint{0})
+
+		assert.Equal(t, 0, deleted)
+		assert.ErrorIs(t, err, ErrInvalidContactData)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("DeleteMany", ctx, uint(1), []uint{4}).Return(nil, errors.New("db down")).Once()
+
+		_, err := service.DeleteContacts(ctx, 1, []uint{4})
+
+		assert.Error(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_SetFavorites(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("some IDs belong to another user", func(t *testing.T) {
+		ctx := context.Background()
+		// Contact 3 belongs to another user, so the repository only updates two rows
+		mockContactRepo.On("SetFavorite", ctx, uint(1), []uint{1, 2, 3}, true).
+			Return([]models.Contact{{ID: 1, UserID: 1, Favorite: true}, {ID: 2, UserID: 1, Favorite: true}}, nil).Once()
+
+		updated, err := service.SetFavorites(ctx, 1, []uint{1, 2, 2, 3}, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, updated)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("unfavorite", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("SetFavorite", ctx, uint(1), []uint{4}, false).Return([]models.Contact{{ID: 4, UserID: 1}}, nil).Once()
+
+		updated, err := service.SetFavorites(ctx, 1, []uint{4}, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, updated)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("no valid IDs", func(t *testing.T) {
+		updated, err := service.SetFavorites(context.Background(), 1, []uint{0, 0}, true)
+
+		assert.Equal(t, 0, updated)
+		assert.ErrorIs(t, err, ErrInvalidContactData)
+	})
+}
+
+func TestUpcomingBirthdays(t *testing.T) {
+	birthday := func(year int, month time.Month, day int) *time.Time {
+		b := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &b
+	}
+	contacts := []models.Contact{
+		{ID: 1, FullName: "New Year", Birthday: birthday(1990, time.January, 1)},
+		{ID: 2, FullName: "Christmas", Birthday: birthday(1985, time.December, 25)},
+		{ID: 3, FullName: "Too Late", Birthday: birthday(1992, time.January, 25)},
+		{ID: 4, FullName: "Already Passed", Birthday: birthday(1980, time.December, 10)},
+		{ID: 5, FullName: "Today", Birthday: birthday(2000, time.December, 20)},
+		{ID: 6, FullName: "No Birthday"},
+	}
+
+	t.Run("window crosses the year end", func(t *testing.T) {
+		today := time.Date(2025, time.December, 20, 15, 30, 0, 0, time.UTC)
+
+		upcoming := upcomingBirthdays(contacts, today, 30)
+
+		names := make([]string, len(upcoming))
+		for i, contact := range upcoming {
+			names[i] = contact.FullName
+		}
+		assert.Equal(t, []string{"Today", "Christmas", "New Year"}, names)
+		assert.Equal(t, "1990-01-01", *upcoming[2].Birthday)
+	})
+
+	t.Run("window starting in January skips December", func(t *testing.T) {
+		today := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		upcoming := upcomingBirthdays(contacts, today, 30)
+
+		assert.Len(t, upcoming, 2)
+		assert.Equal(t, "New Year", upcoming[0].FullName)
+		assert.Equal(t, "Too Late", upcoming[1].FullName)
+	})
+
+	t.Run("leap day falls on March 1 in common years", func(t *testing.T) {
+		leap := []models.Contact{{ID: 7, FullName: "Leap", Birthday: birthday(2000, time.February, 29)}}
+
+		assert.Len(t, upcomingBirthdays(leap, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), 1), 1)
+		assert.Empty(t, upcomingBirthdays(leap, time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC), 30))
+	})
+}
+
+func TestService_UpcomingBirthdays(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("days out of range", func(t *testing.T) {
+		_, err := service.UpcomingBirthdays(context.Background(), 1, 0)
+		assert.ErrorIs(t, err, ErrInvalidContactData)
+
+		_, err = service.UpcomingBirthdays(context.Background(), 1, 367)
+		assert.ErrorIs(t, err, ErrInvalidContactData)
+	})
+
+	t.Run("today's birthday is included", func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Now()
+		today := time.Date(now.Year()-30, now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		mockContactRepo.On("ListWithBirthday", ctx, uint(1)).
+			Return([]models.Contact{{ID: 1, UserID: 1, FullName: "Jane", Birthday: &today}}, nil).Once()
+
+		upcoming, err := service.UpcomingBirthdays(ctx, 1, 30)
+
+		assert.NoError(t, err)
+		assert.Len(t, upcoming, 1)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_RecentContacts(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("newest first", func(t *testing.T) {
+		mockContactRepo.On("ListRecent", ctx, uint(1), 5).
+			Return([]models.Contact{
+				{ID: 3, UserID: 1, FullName: "Newest", CreatedAt: now},
+				{ID: 2, UserID: 1, FullName: "Older", CreatedAt: now.Add(-time.Hour)},
+			}, nil).Once()
+
+		recent, err := service.RecentContacts(ctx, 1, 5)
+		assert.NoError(t, err)
+		if assert.Len(t, recent, 2) {
+			assert.Equal(t, uint(3), recent[0].ID)
+			assert.Equal(t, uint(2), recent[1].ID)
+		}
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("limit is clamped", func(t *testing.T) {
+		mockContactRepo.On("ListRecent", ctx, uint(1), maxRecentContacts).Return([]models.Contact{}, nil).Once()
+		mockContactRepo.On("ListRecent", ctx, uint(1), defaultRecentContacts).Return([]models.Contact{}, nil).Once()
+
+		_, err := service.RecentContacts(ctx, 1, 500)
+		assert.NoError(t, err)
+		_, err = service.RecentContacts(ctx, 1, 0)
+		assert.NoError(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestParseBirthday(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+
+	birthday, err := parseBirthday("1990-12-31", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(1990, time.December, 31, 0, 0, 0, 0, time.UTC), *birthday)
+
+	for _, value := range []string{"31-12-1990", "1990-02-30", "1990/12/31", "2026-10-16"} {
+		_, err := parseBirthday(value, now)
+		assert.ErrorIs(t, err, ErrInvalidBirthday, value)
+	}
+}
+
+func TestService_RestoreContact(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("successful restore", func(t *testing.T) {
+		ctx := context.Background()
+		contact := &models.Contact{ID: 1, UserID: 1, FullName: "Jane Doe", Phone: "081234567890"}
+
+		mockContactRepo.On("Restore", ctx, uint(1), uint(1)).Return(nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(1)).Return(contact, nil).Once()
+
+		resp, err := service.RestoreContact(ctx, 1, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane Doe", resp.FullName)
+		assert.Nil(t, resp.DeletedAt)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("restore nonexistent contact", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("Restore", ctx, uint(1), uint(999)).Return(repository.ErrNotFound).Once()
+
+		resp, err := service.RestoreContact(ctx, 1, 999)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrContactNotFound)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_RestoreAccount(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service := NewService(mockUserRepo, nil, "test-secret")
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		repoErr  error
+		expected error
+	}{
+		{"successful restore", nil, nil},
+		{"user not deleted", repository.ErrNotFound, ErrUserNotFound},
+		{"email registered again", repository.ErrDuplicateEmail, ErrEmailAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo.On("Restore", ctx, uint(1)).Return(tt.repoErr).Once()
+
+			err := service.RestoreAccount(ctx, 1)
+
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_CountContacts(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("user without contacts", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("Count", ctx, uint(1)).Return(int64(0), nil).Once()
+
+		count, err := service.CountContacts(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		ctx := context.Background()
+		mockContactRepo.On("Count", ctx, uint(2)).Return(int64(0), errors.New("db down")).Once()
+
+		_, err := service.CountContacts(ctx, 2)
+
+		assert.Error(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ContactStats(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	ctx := context.Background()
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	stats := &models.ContactStats{Total: 4, Favorites: 1, WithEmail: 2, NewThisWeek: 1}
+	mockContactRepo.On("Stats", ctx, uint(1), mock.MatchedBy(func(since time.Time) bool {
+		return since.Sub(weekAgo).Abs() < time.Minute
+	})).Return(stats, nil).Once()
+
+	resp, err := service.ContactStats(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stats, resp)
+	mockContactRepo.AssertExpectations(t)
+}
+
+func TestService_DeleteAccount(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithBcryptCost(bcrypt.MinCost))
+
+	ctx := context.Background()
+	hashedPassword, _ := service.hashPassword("password123")
+	user := &models.User{ID: 1, Email: "jane@example.com", Password: hashedPassword}
+
+	t.Run("wrong password keeps the account", func(t *testing.T) {
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+
+		err := service.DeleteAccount(ctx, 1, "wrongpassword")
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockUserRepo.AssertNotCalled(t, "Purge", ctx, uint(1))
+	})
+
+	t.Run("confirmed deletion purges the account", func(t *testing.T) {
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(user, nil).Once()
+		mockUserRepo.On("Purge", ctx, uint(1)).Return(nil).Once()
+
+		err := service.DeleteAccount(ctx, 1, "password123")
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockUserRepo.On("GetByID", ctx, uint(2)).Return(nil, repository.ErrNotFound).Once()
+
+		err := service.DeleteAccount(ctx, 2, "password123")
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+func TestService_ExportAccount(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockContactRepo := new(MockContactRepository)
-	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+	mockAuditRepo := new(MockAuditLogRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithAuditLogRepository(mockAuditRepo))
 
-	t.Run("successful create contact", func(t *testing.T) {
-		ctx := context.Background()
-		email := "contact@example.com"
-		req := &models.CreateContactRequest{
-			FullName: "Jane Doe",
-			Phone:    "081234567890",
-			Email:    &email,
+	ctx := context.Background()
+	mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1, FullName: "Jane Doe", Email: "jane@example.com"}, nil).Once()
+	firstPage := make([]models.AuditLog, exportAuditPageSize)
+	mockAuditRepo.On("ListByUser", ctx, uint(1), 1, exportAuditPageSize).Return(firstPage, int64(exportAuditPageSize+1), nil).Once()
+	mockAuditRepo.On("ListByUser", ctx, uint(1), 2, exportAuditPageSize).Return([]models.AuditLog{{Action: models.AuditActionLogin}}, int64(exportAuditPageSize+1), nil).Once()
+	mockContactRepo.On("ListInBatches", ctx, uint(1), exportContactBatchSize).Return([][]models.Contact{
+		{{ID: 1, FullName: "John Doe", Phone: "081234567890"}},
+		{{ID: 2, FullName: "Mary Major", Phone: "081234567891", Tags: []string{"work"}}},
+	}, nil).Once()
+
+	export, err := service.ExportAccount(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@example.com", export.Profile.Email)
+	assert.Len(t, export.AuditLogs, exportAuditPageSize+1)
+
+	var names []string
+	err = export.EachContactBatch(ctx, func(contacts []*models.ContactResponse) error {
+		for _, contact := range contacts {
+			names = append(names, contact.FullName)
 		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"John Doe", "Mary Major"}, names)
+	mockUserRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+	mockContactRepo.AssertExpectations(t)
 
-		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
-		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact")).Return(nil).Once()
+	t.Run("unknown user", func(t *testing.T) {
+		mockUserRepo.On("GetByID", ctx, uint(2)).Return(nil, repository.ErrNotFound).Once()
 
-		resp, err := service.CreateContact(ctx, 1, req)
+		_, err := service.ExportAccount(ctx, 2)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+func TestService_GetContactByPhone(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+
+	t.Run("local format matches stored +62 number", func(t *testing.T) {
+		ctx := context.Background()
+		contact := &models.Contact{ID: 3, UserID: 1, FullName: "Jane", Phone: "+6281234567890"}
+		mockContactRepo.On("GetByPhone", ctx, uint(1), []string{"081234567890", "+6281234567890", "6281234567890"}).
+			Return(contact, nil).Once()
+
+		resp, err := service.GetContactByPhone(ctx, 1, " 081234567890 ")
 
 		assert.NoError(t, err)
-		assert.NotNil(t, resp)
-		assert.Equal(t, "Jane Doe", resp.FullName)
+		assert.Equal(t, uint(3), resp.ID)
+		assert.Equal(t, "+6281234567890", resp.Phone)
 		mockContactRepo.AssertExpectations(t)
 	})
 
-	t.Run("phone already exists", func(t *testing.T) {
+	t.Run("foreign number is matched as given", func(t *testing.T) {
 		ctx := context.Background()
-		req := &models.CreateContactRequest{
-			FullName: "Jane Doe",
-			Phone:    "081234567890",
-		}
-
-		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(true, nil).Once()
+		mockContactRepo.On("GetByPhone", ctx, uint(1), []string{"+14155550123"}).
+			Return(nil, repository.ErrNotFound).Once()
 
-		resp, err := service.CreateContact(ctx, 1, req)
+		resp, err := service.GetContactByPhone(ctx, 1, "+14155550123")
 
-		assert.Error(t, err)
 		assert.Nil(t, resp)
-		assert.ErrorIs(t, err, ErrPhoneAlreadyExists)
+		assert.ErrorIs(t, err, ErrContactNotFound)
 		mockContactRepo.AssertExpectations(t)
 	})
 
-	t.Run("invalid email format", func(t *testing.T) {
-		ctx := context.Background()
-		invalidEmail := "invalid-email"
-		req := &models.CreateContactRequest{
-			FullName: "Jane Doe",
-			Phone:    "081234567890",
-			Email:    &invalidEmail,
-		}
+	t.Run("invalid phone", func(t *testing.T) {
+		resp, err := service.GetContactByPhone(context.Background(), 1, "abc")
 
-		resp, err := service.CreateContact(ctx, 1, req)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidPhone)
+	})
+}
 
+func TestService_ContactEvents(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	publisher := new(MockEventPublisher)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithEventPublisher(publisher))
+	ctx := context.Background()
+
+	eventFor := func(eventType string, contactID uint) interface{} {
+		return mock.MatchedBy(func(event webhook.Event) bool {
+			return event.Type == eventType && event.UserID == 1 && event.Contact.ID == contactID && !event.Timestamp.IsZero()
+		})
+	}
+
+	t.Run("create", func(t *testing.T) {
+		mockContactRepo.On("CheckPhoneExists", ctx, uint(1), "081234567890", uint(0)).Return(false, nil).Once()
+		mockContactRepo.On("Create", ctx, mock.AnythingOfType("*models.Contact"), 0).
+			Run(func(args mock.Arguments) { args.Get(1).(*models.Contact).ID = 7 }).
+			Return(nil).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactCreated, 7)).Once()
+
+		_, err := service.CreateContact(ctx, 1, &models.CreateContactRequest{FullName: "Jane Doe", Phone: "081234567890"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		name := "Jane Smith"
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(&models.Contact{ID: 7, UserID: 1, FullName: "Jane Doe"}, nil).Once()
+		mockContactRepo.On("Update", ctx, mock.AnythingOfType("*models.Contact"), repository.ContactChildren{}).Return(nil).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactUpdated, 7)).Once()
+
+		_, err := service.UpdateContact(ctx, 1, 7, &models.UpdateContactRequest{FullName: &name})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(&models.Contact{ID: 7, UserID: 1, FullName: "Jane Smith"}, nil).Once()
+		mockContactRepo.On("Delete", ctx, uint(1), uint(7)).Return(nil).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactDeleted, 7)).Once()
+
+		assert.NoError(t, service.DeleteContact(ctx, 1, 7))
+	})
+
+	t.Run("set favorites", func(t *testing.T) {
+		mockContactRepo.On("SetFavorite", ctx, uint(1), []uint{7, 9}, true).
+			Return([]models.Contact{{ID: 7, UserID: 1, Favorite: true}, {ID: 9, UserID: 1, Favorite: true}}, nil).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactUpdated, 7)).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactUpdated, 9)).Once()
+
+		updated, err := service.SetFavorites(ctx, 1, []uint{7, 9}, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, updated)
+	})
+
+	t.Run("delete many", func(t *testing.T) {
+		// Contact 9 belongs to another user, so only contact 7 is deleted and announced
+		mockContactRepo.On("DeleteMany", ctx, uint(1), []uint{7, 9}).
+			Return([]models.Contact{{ID: 7, UserID: 1}}, nil).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactDeleted, 7)).Once()
+
+		deleted, err := service.DeleteContacts(ctx, 1, []uint{7, 9})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(&models.Contact{ID: 7, UserID: 1, FullName: "Jane Smith"}, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(9)).Return(&models.Contact{ID: 9, UserID: 1, FullName: "Jane"}, nil).Once()
+		mockContactRepo.On("Merge", ctx, mock.AnythingOfType("*models.Contact"), uint(9)).Return(nil).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactUpdated, 7)).Once()
+		publisher.On("Publish", eventFor(webhook.EventContactDeleted, 9)).Once()
+
+		_, err := service.MergeContacts(ctx, 1, 7, 9)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("failed writes publish nothing", func(t *testing.T) {
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(8)).Return(nil, repository.ErrNotFound).Once()
+
+		assert.ErrorIs(t, service.DeleteContact(ctx, 1, 8), ErrContactNotFound)
+
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(7)).Return(&models.Contact{ID: 7, UserID: 1}, nil).Once()
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(9)).Return(&models.Contact{ID: 9, UserID: 1}, nil).Once()
+		mockContactRepo.On("Merge", ctx, mock.AnythingOfType("*models.Contact"), uint(9)).Return(errors.New("db down")).Once()
+
+		_, err := service.MergeContacts(ctx, 1, 7, 9)
 		assert.Error(t, err)
+	})
+
+	publisher.AssertExpectations(t)
+	mockContactRepo.AssertExpectations(t)
+}
+
+// stubResolver resolves hosts from a fixed table
+type stubResolver map[string][]string
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestService_SetWebhook(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	resolver := stubResolver{
+		"hooks.example.com":    {"93.184.216.34"},
+		"internal.example.com": {"93.184.216.34", "10.0.0.5"},
+	}
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret", WithWebhookResolver(resolver))
+	ctx := context.Background()
+
+	t.Run("generates a secret", func(t *testing.T) {
+		mockUserRepo.On("GetByID", ctx, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		mockUserRepo.On("Update", ctx, mock.MatchedBy(func(user *models.User) bool {
+			return user.WebhookURL != nil && *user.WebhookURL == "https://hooks.example.com/contacts" &&
+				user.WebhookSecret != nil && len(*user.WebhookSecret) == 64
+		})).Return(nil).Once()
+
+		resp, err := service.SetWebhook(ctx, 1, "https://hooks.example.com/contacts")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://hooks.example.com/contacts", resp.URL)
+		assert.Len(t, resp.Secret, 64)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	for _, url := range []string{
+		"hooks.example.com", "ftp://hooks.example.com", "https://",
+		"http://127.0.0.1:8080/hook", "http://10.1.2.3/hook", "http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook", "http://0.0.0.0/hook", "https://internal.example.com/hook", "https://unknown.example.com/hook",
+	} {
+		t.Run("rejects "+url, func(t *testing.T) {
+			_, err := service.SetWebhook(ctx, 1, url)
+
+			assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+		})
+	}
+}
+
+func TestService_ContactOwnedByAnotherUser(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockContactRepo := new(MockContactRepository)
+	service := NewService(mockUserRepo, mockContactRepo, "test-secret")
+	ctx := context.Background()
+
+	// Contact 5 belongs to user 1. Lookups are scoped by user, so for user 2 it does not
+	// exist and every operation reports not found rather than forbidden.
+	mockContactRepo.On("GetByID", ctx, uint(2), uint(5)).Return(nil, repository.ErrNotFound)
+
+	t.Run("get", func(t *testing.T) {
+		resp, err := service.GetContact(ctx, 2, 5)
+
 		assert.Nil(t, resp)
-		assert.ErrorIs(t, err, ErrInvalidEmail)
+		assert.ErrorIs(t, err, ErrContactNotFound)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		name := "Hijacked"
+		resp, err := service.UpdateContact(ctx, 2, 5, &models.UpdateContactRequest{FullName: &name})
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrContactNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := service.DeleteContact(ctx, 2, 5)
+
+		assert.ErrorIs(t, err, ErrContactNotFound)
+	})
+
+	mockContactRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	mockContactRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_GetContactHistory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns revisions of a soft-deleted contact", func(t *testing.T) {
+		mockContactRepo := new(MockContactRepository)
+		service := NewService(new(MockUserRepository), mockContactRepo, "test-secret")
+
+		revisions := []models.ContactRevision{
+			{ID: 2, ContactID: 5, UserID: 1, Action: models.RevisionActionDelete, Snapshot: []byte(`{"id":5}`)},
+			{ID: 1, ContactID: 5, UserID: 1, Action: models.RevisionActionUpdate, Snapshot: []byte(`{"id":5}`)},
+		}
+		mockContactRepo.On("ListRevisions", ctx, uint(1), uint(5)).Return(revisions, nil)
+
+		history, err := service.GetContactHistory(ctx, 1, 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, revisions, history)
+		// The contact itself is not looked up, so its deletion does not hide the history
+		mockContactRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unchanged contact has empty history", func(t *testing.T) {
+		mockContactRepo := new(MockContactRepository)
+		service := NewService(new(MockUserRepository), mockContactRepo, "test-secret")
+
+		mockContactRepo.On("ListRevisions", ctx, uint(1), uint(5)).Return([]models.ContactRevision{}, nil)
+		mockContactRepo.On("GetByID", ctx, uint(1), uint(5)).Return(&models.Contact{ID: 5, UserID: 1}, nil)
+
+		history, err := service.GetContactHistory(ctx, 1, 5)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, history)
+		assert.Empty(t, history)
+	})
+
+	t.Run("contact of another user is not found", func(t *testing.T) {
+		mockContactRepo := new(MockContactRepository)
+		service := NewService(new(MockUserRepository), mockContactRepo, "test-secret")
+
+		mockContactRepo.On("ListRevisions", ctx, uint(2), uint(5)).Return([]models.ContactRevision{}, nil)
+		mockContactRepo.On("GetByID", ctx, uint(2), uint(5)).Return(nil, repository.ErrNotFound)
+
+		history, err := service.GetContactHistory(ctx, 2, 5)
+
+		assert.Nil(t, history)
+		assert.ErrorIs(t, err, ErrContactNotFound)
 	})
 }
 
@@ -420,6 +3854,180 @@ func TestService_ListContacts(t *testing.T) {
 		mockContactRepo.AssertExpectations(t)
 	})
 
+	t.Run("without count reports whether a next page exists", func(t *testing.T) {
+		ctx := context.Background()
+		withCount := false
+		contacts := []models.Contact{
+			{ID: 3, UserID: 1, FullName: "Contact 3", Phone: "083333333333"},
+			{ID: 2, UserID: 1, FullName: "Contact 2", Phone: "082222222222"},
+			{ID: 1, UserID: 1, FullName: "Contact 1", Phone: "081111111111"},
+		}
+
+		// The repository returns one row beyond the page when another page follows
+		req := &models.ListContactsRequest{Page: 1, Limit: 2, Sort: "full_name", WithCount: &withCount}
+		mockContactRepo.On("List", ctx, uint(1), req).Return(contacts, int64(-1), nil).Once()
+
+		resp, err := service.ListContacts(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Data, 2)
+		assert.Equal(t, int64(-1), resp.Pagination.Total)
+		assert.Equal(t, -1, resp.Pagination.TotalPages)
+		assert.True(t, resp.Pagination.HasNextPage)
+
+		lastPage := &models.ListContactsRequest{Page: 2, Limit: 2, Sort: "full_name", WithCount: &withCount}
+		mockContactRepo.On("List", ctx, uint(1), lastPage).Return(contacts[2:], int64(-1), nil).Once()
+
+		resp, err = service.ListContacts(ctx, 1, lastPage)
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Data, 1)
+		assert.False(t, resp.Pagination.HasNextPage)
+		assert.True(t, resp.Pagination.HasPrevPage)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("updated since returns tombstones", func(t *testing.T) {
+		ctx := context.Background()
+		since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, UpdatedSince: &since}
+
+		deletedAt := since.Add(time.Nanosecond)
+		contacts := []models.Contact{
+			{ID: 1, UserID: 1, FullName: "Contact 1", Phone: "081111111111", UpdatedAt: since.Add(time.Second)},
+			{ID: 2, UserID: 1, FullName: "Contact 2", Phone: "082222222222", UpdatedAt: since,
+				DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+		}
+		mockContactRepo.On("List", ctx, uint(1), mock.MatchedBy(func(r *models.ListContactsRequest) bool {
+			return r.UpdatedSince != nil && r.UpdatedSince.Equal(since)
+		})).Return(contacts, int64(2), nil).Once()
+
+		resp, err := service.ListContacts(ctx, 1, req)
+
+		assert.NoError(t, err)
+		data := resp.Data.([]*models.ContactResponse)
+		if assert.Len(t, data, 2) {
+			assert.Nil(t, data[0].DeletedAt)
+			if assert.NotNil(t, data[1].DeletedAt) {
+				assert.True(t, deletedAt.Equal(*data[1].DeletedAt))
+			}
+		}
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("sort by allowed field", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Sort: " Full_Name ", Order: "ASC"}
+
+		mockContactRepo.On("List", ctx, uint(1), mock.MatchedBy(func(r *models.ListContactsRequest) bool {
+			return r.Sort == "full_name" && r.Order == "asc"
+		})).Return([]models.Contact{}, int64(0), nil).Once()
+
+		_, err := service.ListContacts(ctx, 1, req)
+
+		assert.NoError(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown sort field", func(t *testing.T) {
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Sort: "password"}
+
+		resp, err := service.ListContacts(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidSortField)
+	})
+
+	t.Run("unknown sort order", func(t *testing.T) {
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Sort: "phone", Order: "sideways"}
+
+		resp, err := service.ListContacts(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidSortOrder)
+	})
+
+	t.Run("search fields are normalized", func(t *testing.T) {
+		ctx := context.Background()
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Search: "john@", SearchFields: []string{" Email ", "PHONE"}}
+
+		mockContactRepo.On("List", ctx, uint(1), mock.MatchedBy(func(r *models.ListContactsRequest) bool {
+			return len(r.SearchFields) == 2 && r.SearchFields[0] == "email" && r.SearchFields[1] == "phone"
+		})).Return([]models.Contact{}, int64(0), nil).Once()
+
+		_, err := service.ListContacts(ctx, 1, req)
+
+		assert.NoError(t, err)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown search field", func(t *testing.T) {
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Search: "john", SearchFields: []string{"password"}}
+
+		resp, err := service.ListContacts(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidSearchField)
+	})
+
+	t.Run("full page returns next cursor", func(t *testing.T) {
+		ctx := context.Background()
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		req := &models.ListContactsRequest{Page: 1, Limit: 2}
+
+		contacts := []models.Contact{
+			{ID: 9, UserID: 1, FullName: "Contact 9", Phone: "081111111111", CreatedAt: createdAt.Add(time.Minute)},
+			{ID: 8, UserID: 1, FullName: "Contact 8", Phone: "082222222222", CreatedAt: createdAt},
+		}
+		mockContactRepo.On("List", ctx, uint(1), req).Return(contacts, int64(5), nil).Once()
+
+		resp, err := service.ListContacts(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.Pagination.NextCursor)
+		cursorAt, cursorID, err := repository.DecodeContactCursor(resp.Pagination.NextCursor)
+		assert.NoError(t, err)
+		assert.True(t, createdAt.Equal(cursorAt))
+		assert.Equal(t, uint(8), cursorID)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("last cursor page has no next cursor", func(t *testing.T) {
+		ctx := context.Background()
+		cursor := repository.EncodeContactCursor(&models.Contact{ID: 8, CreatedAt: time.Now()})
+		req := &models.ListContactsRequest{Page: 1, Limit: 2, Cursor: cursor}
+
+		contacts := []models.Contact{{ID: 7, UserID: 1, FullName: "Contact 7", Phone: "083333333333"}}
+		mockContactRepo.On("List", ctx, uint(1), req).Return(contacts, int64(5), nil).Once()
+
+		resp, err := service.ListContacts(ctx, 1, req)
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Pagination.NextCursor)
+		assert.False(t, resp.Pagination.HasNextPage)
+		assert.True(t, resp.Pagination.HasPrevPage)
+		mockContactRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Cursor: "not-a-cursor"}
+
+		resp, err := service.ListContacts(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("cursor with non-default sort", func(t *testing.T) {
+		cursor := repository.EncodeContactCursor(&models.Contact{ID: 8, CreatedAt: time.Now()})
+		req := &models.ListContactsRequest{Page: 1, Limit: 10, Sort: "full_name", Cursor: cursor}
+
+		resp, err := service.ListContacts(context.Background(), 1, req)
+
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
 	t.Run("pagination defaults", func(t *testing.T) {
 		ctx := context.Background()
 		req := &models.ListContactsRequest{
@@ -484,3 +4092,7 @@ func TestService_Validation(t *testing.T) {
 		assert.Error(t, service.validatePassword("1234567")) // 7 chars
 	})
 }
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/repository"
+)
+
+// maxUserAgentLength matches the sessions.user_agent column size
+const maxUserAgentLength = 255
+
+// userAgentKey is the context key carrying the client user agent recorded on sessions
+type userAgentKey struct{}
+
+// WithUserAgent returns a copy of ctx carrying the user agent recorded when a session starts
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// userAgentFromContext returns the user agent stored by WithUserAgent, if any
+func userAgentFromContext(ctx context.Context) string {
+	userAgent, _ := ctx.Value(userAgentKey{}).(string)
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return userAgent
+}
+
+// saveSession records a new session or, for a refreshed one, its latest use and expiry
+func (s *Service) saveSession(ctx context.Context, userID uint, sessionID string, newSession bool, expiresAt time.Time) error {
+	if s.sessionRepo == nil {
+		return nil
+	}
+
+	if !newSession {
+		if err := s.sessionRepo.Touch(ctx, sessionID, expiresAt); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		return nil
+	}
+
+	session := &models.Session{
+		UserID:     userID,
+		JTI:        sessionID,
+		UserAgent:  userAgentFromContext(ctx),
+		IPAddress:  clientIPFromContext(ctx),
+		ExpiresAt:  expiresAt,
+		LastUsedAt: time.Now(),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *Service) ListSessions(ctx context.Context, userID uint) ([]*models.SessionResponse, error) {
+	if s.sessionRepo == nil {
+		return []*models.SessionResponse{}, nil
+	}
+
+	sessions, err := s.sessionRepo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	responses := make([]*models.SessionResponse, len(sessions))
+	for i := range sessions {
+		responses[i] = sessions[i].ToResponse()
+	}
+	return responses, nil
+}
+
+// RevokeSession ends one of the user's sessions. Its refresh token stops working at once;
+// access tokens issued for it are rejected when a revocation store is configured.
+func (s *Service) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	if s.sessionRepo == nil {
+		return ErrSessionNotFound
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	// Access tokens live at most accessTokenTTL past the revocation
+	if s.revocationStore != nil {
+		if err := s.revocationStore.Revoke(ctx, sessionID, s.accessTokenTTL); err != nil {
+			return fmt.Errorf("failed to revoke session tokens: %w", err)
+		}
+	}
+
+	return nil
+}
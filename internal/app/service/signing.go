@@ -0,0 +1,89 @@
+package service
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// LoadRSAKeys reads a PEM encoded RSA private key and public key from disk
+func LoadRSAKeys(privateKeyPath, publicKeyPath string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	privatePEM, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read JWT private key: %w", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JWT private key: %w", err)
+	}
+
+	publicPEM, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read JWT public key: %w", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// signToken signs claims with RS256 when RSA keys are configured and HS256 otherwise.
+// HS256 tokens use the active rotation key, named in the kid header, when there is one.
+func (s *Service) signToken(claims jwt.Claims) (string, error) {
+	if s.rsaPrivateKey != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(s.rsaPrivateKey)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	secret := s.jwtSecret
+	if key, ok := s.jwtKeys[s.jwtActiveKID]; ok {
+		token.Header["kid"] = s.jwtActiveKID
+		secret = key
+	}
+	return token.SignedString([]byte(secret))
+}
+
+// verificationKey returns the key a token is verified with. The token's alg must match
+// the configured mode so an HMAC token signed with the public key is never accepted.
+func (s *Service) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.rsaPublicKey != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.rsaPublicKey, nil
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	// A kid names the rotation key the token was signed with; removed keys no longer verify
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		key, ok := s.jwtKeys[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %v", kid)
+		}
+		return []byte(key), nil
+	}
+	// Tokens without a kid predate rotation. Once rotation keys are in use they only verify
+	// while the old secret is still configured.
+	if len(s.jwtKeys) > 0 && s.jwtSecret == "" {
+		return nil, errors.New("token has no signing key ID")
+	}
+	return []byte(s.jwtSecret), nil
+}
+
+// fingerprintKey returns the key password fingerprints are derived with: the service
+// secret, or the active rotation key when only rotation keys are configured
+func (s *Service) fingerprintKey() []byte {
+	if s.jwtSecret == "" {
+		return []byte(s.jwtKeys[s.jwtActiveKID])
+	}
+	return []byte(s.jwtSecret)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/repository"
+	"user-service/internal/app/webhook"
+)
+
+// ErrInvalidWebhookURL is returned for webhook URLs that are not absolute http(s) URLs
+// or whose host is not a public address
+var ErrInvalidWebhookURL = errors.New("invalid webhook URL")
+
+// maxWebhookURLLength matches the users.webhook_url column size
+const maxWebhookURLLength = 2048
+
+// EventPublisher delivers contact change events, e.g. to the users' webhooks
+type EventPublisher interface {
+	// Publish hands an event over for delivery without blocking
+	Publish(event webhook.Event)
+}
+
+// SetWebhook points the user's contact events at webhookURL and returns it together with a
+// newly generated signing secret. The secret is only shown here; setting the webhook
+// again rotates it.
+func (s *Service) SetWebhook(ctx context.Context, userID uint, webhookURL string) (*models.WebhookResponse, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(webhookURL) > maxWebhookURLLength {
+		return nil, ErrInvalidWebhookURL
+	}
+	// Deliveries are checked again when they connect, in case the host is rebound
+	if err := webhook.CheckHost(ctx, s.resolver(), parsed.Hostname()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	secret := hex.EncodeToString(raw)
+
+	user.WebhookURL = &webhookURL
+	user.WebhookSecret = &secret
+	if err := s.userRepo.Update(ctx, user, "webhook_url", "webhook_secret"); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return &models.WebhookResponse{URL: webhookURL, Secret: secret}, nil
+}
+
+// DeleteWebhook stops delivering the user's contact events
+func (s *Service) DeleteWebhook(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user.WebhookURL = nil
+	user.WebhookSecret = nil
+	if err := s.userRepo.Update(ctx, user, "webhook_url", "webhook_secret"); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// WebhookEndpoint returns the URL and secret the user's events are delivered with, or an
+// empty URL when the user has no webhook. It is the webhook.EndpointFunc of the dispatcher.
+func (s *Service) WebhookEndpoint(ctx context.Context, userID uint) (string, string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.WebhookURL == nil || user.WebhookSecret == nil {
+		return "", "", nil
+	}
+	return *user.WebhookURL, *user.WebhookSecret, nil
+}
+
+// resolver returns the resolver webhook hosts are checked with
+func (s *Service) resolver() webhook.Resolver {
+	if s.webhookResolver != nil {
+		return s.webhookResolver
+	}
+	return net.DefaultResolver
+}
+
+// publishContactEvent hands a contact change to the event publisher, if one is configured
+func (s *Service) publishContactEvent(eventType string, userID uint, contact *models.ContactResponse) {
+	if s.eventPublisher == nil {
+		return
+	}
+	s.eventPublisher.Publish(webhook.Event{
+		Type:      eventType,
+		Contact:   contact,
+		UserID:    userID,
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"user-service/pkg/coreclient"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700::1111"} {
+		assert.True(t, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{
+		"127.0.0.1", "10.0.0.1", "172.16.5.4", "192.168.1.1", "169.254.169.254", "0.0.0.0",
+		"100.64.0.1", "224.0.0.1", "::1", "::", "fe80::1", "fd00::1", "::ffff:127.0.0.1",
+	} {
+		assert.False(t, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+type fixedResolver []net.IPAddr
+
+func (r fixedResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return r, nil
+}
+
+func TestCheckHost(t *testing.T) {
+	ctx := context.Background()
+	public := fixedResolver{{IP: net.ParseIP("93.184.216.34")}}
+	mixed := fixedResolver{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("127.0.0.1")}}
+
+	assert.NoError(t, CheckHost(ctx, public, "hooks.example.com"))
+	assert.NoError(t, CheckHost(ctx, mixed, "93.184.216.34"))
+	assert.ErrorIs(t, CheckHost(ctx, public, "169.254.169.254"), ErrForbiddenAddress)
+	assert.ErrorIs(t, CheckHost(ctx, mixed, "hooks.example.com"), ErrForbiddenAddress)
+	assert.Error(t, CheckHost(ctx, fixedResolver{}, "hooks.example.com"))
+}
+
+func TestNewTransport_RefusesInternalAddresses(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client := coreclient.New("", coreclient.WithTransport(NewTransport()))
+	_, err := client.Post(server.URL, []byte(`{}`))
+
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.False(t, called)
+}
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/logger"
+	"user-service/pkg/coreclient"
+)
+
+// Contact event types
+const (
+	EventContactCreated = "contact.created"
+	EventContactUpdated = "contact.updated"
+	EventContactDeleted = "contact.deleted"
+)
+
+// Headers sent with every delivery
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the body,
+	// keyed with the receiving user's webhook secret
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Webhook-Event"
+)
+
+// Delivery defaults
+const (
+	defaultQueueSize   = 1000
+	defaultMaxAttempts = 5
+	defaultWorkers     = 4
+)
+
+// RetryPolicy is the policy of clients delivering webhooks: attempts tries in total, the
+// first retry after a second and doubling from there up to a minute, unless the webhook
+// asks for another wait with Retry-After. Non-positive attempts keep the default of 5.
+func RetryPolicy(attempts int) coreclient.RetryPolicy {
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	return coreclient.RetryPolicy{
+		MaxAttempts: attempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		Jitter:      0.2,
+	}
+}
+
+// Event describes a change to one of a user's contacts
+type Event struct {
+	Type      string                  `json:"type"`
+	Contact   *models.ContactResponse `json:"contact"`
+	UserID    uint                    `json:"user_id"`
+	Timestamp time.Time               `json:"timestamp"`
+}
+
+// EndpointFunc returns the URL and secret a user's events are delivered with, or an
+// empty URL when the user has no webhook
+type EndpointFunc func(ctx context.Context, userID uint) (url, secret string, err error)
+
+// Sign returns the signature header value for a body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Option configures a Dispatcher
+type Option func(*Dispatcher)
+
+// WithQueueSize sets how many events may wait for delivery before new ones are dropped.
+// Non-positive values keep the default of 1000.
+func WithQueueSize(size int) Option {
+	return func(d *Dispatcher) {
+		if size > 0 {
+			d.queueSize = size
+		}
+	}
+}
+
+// WithWorkers sets how many events are delivered concurrently, so one slow or failing
+// webhook does not hold up everyone else's. Non-positive values keep the default of 4.
+func WithWorkers(workers int) Option {
+	return func(d *Dispatcher) {
+		if workers > 0 {
+			d.workers = workers
+		}
+	}
+}
+
+// Dispatcher delivers events to the users' webhooks from background workers. Delivery
+// is best effort: events are dropped when the queue is full, after the last failed
+// attempt, and when the dispatcher stops.
+type Dispatcher struct {
+	client    *coreclient.Client
+	endpoint  EndpointFunc
+	queueSize int
+	workers   int
+
+	queue chan Event
+	// ctx is cancelled by Stop, aborting deliveries and their retries
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher posting through client to the endpoints returned by
+// endpoint. Failed deliveries are retried according to the client's retry policy, so build
+// it with coreclient.WithRetry(RetryPolicy(attempts)). Call Start to begin delivering.
+func NewDispatcher(client *coreclient.Client, endpoint EndpointFunc, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		client:    client,
+		endpoint:  endpoint,
+		queueSize: defaultQueueSize,
+		workers:   defaultWorkers,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.queue = make(chan Event, d.queueSize)
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	return d
+}
+
+// Start launches the delivery workers
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case event := <-d.queue:
+					d.deliver(event)
+				case <-d.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Stop stops the workers, abandoning the deliveries in progress. Queued events are dropped.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Publish queues an event for delivery without blocking
+func (d *Dispatcher) Publish(event Event) {
+	select {
+	case d.queue <- event:
+	default:
+		logger.Warn("Webhook queue full, dropping event", "type", event.Type, "user_id", event.UserID)
+	}
+}
+
+// deliver posts an event to its user's webhook
+func (d *Dispatcher) deliver(event Event) {
+	url, secret, err := d.endpoint(d.ctx, event.UserID)
+	if err != nil {
+		logger.Warn("Failed to look up webhook", "user_id", event.UserID, "error", err)
+		return
+	}
+	if url == "" {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Warn("Failed to encode webhook event", "type", event.Type, "error", err)
+		return
+	}
+
+	if err := d.send(url, secret, event.Type, body); err != nil && d.ctx.Err() == nil {
+		logger.Warn("Webhook delivery failed", "type", event.Type, "user_id", event.UserID, "error", err)
+	}
+}
+
+// send posts the event body, with the client retrying network errors, 429 and 5xx
+// responses. It fails when the final response is not 2xx.
+func (d *Dispatcher) send(url, secret, eventType string, body []byte) error {
+	resp, err := d.client.PostContext(d.ctx, url, body,
+		coreclient.WithHeader(SignatureHeader, Sign(secret, body)),
+		coreclient.WithHeader(EventHeader, eventType),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/pkg/coreclient"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '{"type":"contact.created"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=64c30daa64ee1ec356a68fc1c891c698cb0418530435250a192403dbedf4056b",
+		Sign("secret", []byte(`{"type":"contact.created"}`)),
+	)
+	assert.NotEqual(t, Sign("secret", []byte("a")), Sign("other", []byte("a")))
+}
+
+func TestDispatcher_RetriesServerErrors(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	endpoint := func(ctx context.Context, userID uint) (string, string, error) {
+		return server.URL, "secret", nil
+	}
+	client := coreclient.New("", coreclient.WithRetry(coreclient.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}))
+	dispatcher := NewDispatcher(client, endpoint)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	dispatcher.Publish(Event{
+		Type:      EventContactCreated,
+		Contact:   &models.ContactResponse{ID: 5, FullName: "Jane Doe"},
+		UserID:    1,
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+
+	select {
+	case req := <-received:
+		body := <-bodies
+		assert.Equal(t, int32(3), attempts.Load())
+		assert.Equal(t, Sign("secret", body), req.Header.Get(SignatureHeader))
+		assert.Equal(t, EventContactCreated, req.Header.Get(EventHeader))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		var event Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, uint(1), event.UserID)
+		assert.Equal(t, uint(5), event.Contact.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+}
+
+func TestDispatcher_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	endpoint := func(ctx context.Context, userID uint) (string, string, error) {
+		return server.URL, "secret", nil
+	}
+	client := coreclient.New("", coreclient.WithRetry(coreclient.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}))
+	dispatcher := NewDispatcher(client, endpoint)
+
+	dispatcher.deliver(Event{Type: EventContactDeleted, UserID: 1})
+
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestDispatcher_HonoursRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	endpoint := func(ctx context.Context, userID uint) (string, string, error) {
+		return server.URL, "secret", nil
+	}
+	client := coreclient.New("", coreclient.WithRetry(coreclient.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}))
+	dispatcher := NewDispatcher(client, endpoint)
+	dispatcher.Start()
+
+	dispatcher.Publish(Event{Type: EventContactUpdated, UserID: 1})
+	deadline := time.Now().Add(5 * time.Second)
+	for attempts.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The webhook asked for a minute, so no retry is made before the dispatcher stops
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	dispatcher.Stop()
+
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestDispatcher_WorkersDeliverConcurrently(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	delivered := make(chan struct{}, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer fast.Close()
+
+	endpoint := func(ctx context.Context, userID uint) (string, string, error) {
+		if userID == 1 {
+			return slow.URL, "secret", nil
+		}
+		return fast.URL, "secret", nil
+	}
+	dispatcher := NewDispatcher(coreclient.New(""), endpoint, WithWorkers(2))
+	dispatcher.Start()
+
+	dispatcher.Publish(Event{Type: EventContactCreated, UserID: 1})
+	dispatcher.Publish(Event{Type: EventContactCreated, UserID: 2})
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a slow webhook held up the other delivery")
+	}
+	release <- struct{}{}
+	dispatcher.Stop()
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggerKey is the gin context key holding the request-scoped logger
+const LoggerKey = "logger"
+
+// loggerContextKey is the context.Context key holding the request-scoped logger
+type loggerContextKey struct{}
+
+// discardLogger is returned by FromContext before the logger is initialized, matching
+// the package-level helpers that log nothing in that case
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored in ctx by LoggingMiddleware, so
+// entries carry the request's correlation ID. It accepts both a request context and a
+// *gin.Context and falls back to the global logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+			return l
+		}
+		if c, ok := ctx.(*gin.Context); ok {
+			if l, ok := c.Value(LoggerKey).(*slog.Logger); ok {
+				return l
+			}
+		}
+	}
+	if DefaultLogger != nil {
+		return DefaultLogger.Logger
+	}
+	return discardLogger
+}
+
+// LogSlowQuery logs a database query that exceeded the slow query threshold, with the
+// correlation ID of the request that ran it
+func LogSlowQuery(ctx context.Context, sql string, elapsed time.Duration) {
+	FromContext(ctx).Warn("Slow database query", "sql", sql, "duration_ms", elapsed.Milliseconds())
+}
@@ -2,10 +2,12 @@ package logger
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,35 +16,51 @@ import (
 // Logger wraps slog.Logger with additional functionality
 type Logger struct {
 	*slog.Logger
-	logFile *os.File
+	logFile *rotatingFile
 }
 
 // Config holds logger configuration
 type Config struct {
 	Level      string // debug, info, warn, error
 	OutputPath string // path to log file
-	MaxSize    int64  // max size in MB before rotation
+	MaxSize    int64  // max size in MB before rotation; 0 disables rotation
+	MaxBackups int    // number of rotated files to keep; 0 keeps all
+	// SensitiveKeys are JSON keys redacted from logged request bodies (case-insensitive).
+	// Defaults to DefaultSensitiveKeys when empty.
+	SensitiveKeys []string
 }
 
 var (
 	// DefaultLogger is the global logger instance
 	DefaultLogger *Logger
+
+	// level is the minimum level of DefaultLogger; SetLevel changes it at runtime
+	level = new(slog.LevelVar)
 )
 
+// ErrInvalidLevel is returned by SetLevel for an unknown level name
+var ErrInvalidLevel = errors.New("invalid log level: must be debug, info, warn or error")
+
 // Init initializes the global logger
 func Init(config Config) error {
 	if config.OutputPath == "" {
 		config.OutputPath = "logs/app.log"
 	}
 
+	if len(config.SensitiveKeys) > 0 {
+		sensitiveKeys = toKeySet(config.SensitiveKeys)
+	} else {
+		sensitiveKeys = toKeySet(DefaultSensitiveKeys)
+	}
+
 	// Create logs directory if not exists
 	logDir := filepath.Dir(config.OutputPath)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return err
 	}
 
-	// Open log file
-	logFile, err := os.OpenFile(config.OutputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	// Open log file, rotated once it exceeds MaxSize MB
+	logFile, err := openRotatingFile(config.OutputPath, config.MaxSize*1024*1024, config.MaxBackups)
 	if err != nil {
 		return err
 	}
@@ -50,20 +68,12 @@ func Init(config Config) error {
 	// Create multi-writer (file + stdout)
 	multiWriter := io.MultiWriter(logFile, os.Stdout)
 
-	// Parse log level
-	var level slog.Level
-	switch config.Level {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
+	// Parse log level, defaulting to info
+	parsed, ok := parseLevel(config.Level)
+	if !ok {
+		parsed = slog.LevelInfo
 	}
+	level.Set(parsed)
 
 	// Create JSON handler for structured logging
 	handler := slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{
@@ -87,6 +97,37 @@ func Init(config Config) error {
 	return nil
 }
 
+// parseLevel maps a level name to its slog level
+func parseLevel(name string) (slog.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
+
+// SetLevel changes the minimum level of the global logger without a restart
+func SetLevel(name string) error {
+	parsed, ok := parseLevel(name)
+	if !ok {
+		return ErrInvalidLevel
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// Level returns the current minimum level of the global logger, e.g. "info"
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
 // Close closes the log file
 func Close() error {
 	if DefaultLogger != nil && DefaultLogger.logFile != nil {
@@ -1,9 +1,9 @@
 package logger
-package logger
 
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -222,6 +222,41 @@ func TestLoggerHelpers(t *testing.T) {
 	}
 }
 
+func TestSetLevel(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	if err := Init(Config{Level: "info", OutputPath: logPath}); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer Close()
+
+	Debug("hidden debug line")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	Debug("visible debug line")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Contains(string(content), "hidden debug line") {
+		t.Error("Expected debug line to be dropped at info level")
+	}
+	if !strings.Contains(string(content), "visible debug line") {
+		t.Error("Expected debug line to be emitted after switching to debug")
+	}
+	if got := Level(); got != "debug" {
+		t.Errorf("Level() = %q, want %q", got, "debug")
+	}
+
+	if err := SetLevel("verbose"); err != ErrInvalidLevel {
+		t.Errorf("SetLevel(verbose) error = %v, want %v", err, ErrInvalidLevel)
+	}
+	if got := Level(); got != "debug" {
+		t.Errorf("Level() after invalid level = %q, want %q", got, "debug")
+	}
+}
+
 func TestWithFields(t *testing.T) {
 	// Initialize logger
 	tempDir := t.TempDir()
@@ -280,10 +315,89 @@ func TestSanitizeRequestBody(t *testing.T) {
 			if result == "" {
 				t.Error("sanitizeRequestBody() returned empty string")
 			}
+			if !strings.Contains(result, tt.contains) {
+				t.Errorf("sanitizeRequestBody() = %q, want it to contain %q", result, tt.contains)
+			}
+		})
+	}
+}
+
+func TestSanitizeRequestBodySensitiveKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		hidden []string
+		kept   []string
+	}{
+		{
+			name:   "token keys",
+			body:   `{"token":"t1","access_token":"t2","refresh_token":"t3","email":"a@b.c"}`,
+			hidden: []string{"t1", "t2", "t3"},
+			kept:   []string{"a@b.c"},
+		},
+		{
+			name:   "case insensitive keys",
+			body:   `{"Password":"p1","AUTHORIZATION":"Bearer abc","Access_Token":"t1"}`,
+			hidden: []string{"p1", "Bearer abc", "t1"},
+		},
+		{
+			name:   "password change fields",
+			body:   `{"old_password":"old1","new_password":"new1"}`,
+			hidden: []string{"old1", "new1"},
+		},
+		{
+			name:   "nested one level",
+			body:   `{"credentials":{"password":"p1","username":"john"},"name":"John"}`,
+			hidden: []string{"p1"},
+			kept:   []string{"john", "John"},
+		},
+		{
+			name: "deeper nesting is left alone",
+			body: `{"a":{"b":{"password":"deep"}}}`,
+			kept: []string{"deep"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sanitizeRequestBody(tt.body)
+			for _, value := range tt.hidden {
+				if strings.Contains(result, value) {
+					t.Errorf("sanitizeRequestBody() = %q, should not contain %q", result, value)
+				}
+			}
+			for _, value := range tt.kept {
+				if !strings.Contains(result, value) {
+					t.Errorf("sanitizeRequestBody() = %q, should contain %q", result, value)
+				}
+			}
 		})
 	}
 }
 
+func TestSanitizeRequestBodyConfiguredKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	err := Init(Config{
+		OutputPath:    filepath.Join(tempDir, "test.log"),
+		SensitiveKeys: []string{"PIN"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer func() {
+		Close()
+		sensitiveKeys = toKeySet(DefaultSensitiveKeys)
+	}()
+
+	result := sanitizeRequestBody(`{"pin":"1234","password":"visible"}`)
+	if strings.Contains(result, "1234") {
+		t.Errorf("sanitizeRequestBody() = %q, should redact pin", result)
+	}
+	if !strings.Contains(result, "visible") {
+		t.Errorf("sanitizeRequestBody() = %q, should only redact configured keys", result)
+	}
+}
+
 func TestExtractErrorMessage(t *testing.T) {
 	tests := []struct {
 		name string
@@ -337,6 +451,7 @@ func TestGenerateLogMessage(t *testing.T) {
 			path:    "/api/v1/users",
 			status:  200,
 			latency: 45,
+			want:    "GET /api/v1/users - ✓ ( 45ms )",
 		},
 		{
 			name:    "error request",
@@ -344,19 +459,39 @@ func TestGenerateLogMessage(t *testing.T) {
 			path:    "/api/v1/users",
 			status:  500,
 			latency: 123,
+			want:    "POST /api/v1/users - ✗ ( 123ms )",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := generateLogMessage(tt.method, tt.path, tt.status, tt.latency)
-			if result == "" {
-				t.Error("generateLogMessage() returned empty string")
+			if result != tt.want {
+				t.Errorf("generateLogMessage() = %q, want %q", result, tt.want)
 			}
 		})
 	}
 }
 
+func TestFormatLatency(t *testing.T) {
+	tests := []struct {
+		latency int64
+		want    string
+	}{
+		{latency: 0, want: "0ms"},
+		{latency: 7, want: "7ms"},
+		{latency: 45, want: "45ms"},
+		{latency: 999, want: "999ms"},
+		{latency: 1500, want: "1.50s"},
+	}
+
+	for _, tt := range tests {
+		if got := formatLatency(tt.latency); got != tt.want {
+			t.Errorf("formatLatency(%d) = %q, want %q", tt.latency, got, tt.want)
+		}
+	}
+}
+
 func TestLimitString(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -4,12 +4,22 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CorrelationIDHeader is the header used to receive and echo the request correlation ID
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// CorrelationIDKey is the gin context key holding the request correlation ID
+const CorrelationIDKey = "correlation_id"
+
+// maxCorrelationIDLength caps client supplied correlation IDs
+const maxCorrelationIDLength = 128
+
 // ResponseWriter wraps gin.ResponseWriter to capture response body
 type ResponseWriter struct {
 	gin.ResponseWriter
@@ -22,12 +32,30 @@ func (w *ResponseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
-// LoggingMiddleware logs all HTTP requests and responses
-func LoggingMiddleware() gin.HandlerFunc {
+// replayBody serves the captured start of a request body followed by the unread rest
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// LoggingMiddleware logs all HTTP requests and responses. At most maxBodyBytes of each
+// request body are buffered for the log; non-positive values capture the whole body.
+// The client IP is taken from X-Forwarded-For only for the engine's trusted proxies.
+func LoggingMiddleware(maxBodyBytes int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Generate correlation ID
-		correlationID := GenerateCorrelationID()
-		c.Set("correlation_id", correlationID)
+		// Reuse the client's correlation ID or generate a new one
+		correlationID := c.GetHeader(CorrelationIDHeader)
+		if correlationID == "" || len(correlationID) > maxCorrelationIDLength {
+			correlationID = GenerateCorrelationID()
+		}
+		c.Set(CorrelationIDKey, correlationID)
+		c.Header(CorrelationIDHeader, correlationID)
+
+		// Expose a logger carrying the correlation ID to handlers and services
+		if requestLogger := WithFields(map[string]interface{}{CorrelationIDKey: correlationID}); requestLogger != nil {
+			c.Set(LoggerKey, requestLogger)
+			c.Request = c.Request.WithContext(NewContext(c.Request.Context(), requestLogger))
+		}
 
 		// Start timer
 		startTime := time.Now()
@@ -35,14 +63,21 @@ func LoggingMiddleware() gin.HandlerFunc {
 		// Capture request body
 		var requestBody string
 		if c.Request.Body != nil {
-			bodyBytes, err := io.ReadAll(c.Request.Body)
+			reader := io.Reader(c.Request.Body)
+			if maxBodyBytes > 0 {
+				reader = io.LimitReader(c.Request.Body, maxBodyBytes)
+			}
+			bodyBytes, err := io.ReadAll(reader)
 			if err == nil {
 				requestBody = string(bodyBytes)
-				// Restore the body for downstream handlers
-				c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+				// Restore the body for downstream handlers, including any bytes past the cap
+				c.Request.Body = replayBody{
+					Reader: io.MultiReader(bytes.NewReader(bodyBytes), c.Request.Body),
+					Closer: c.Request.Body,
+				}
 
-				// Sanitize sensitive data (passwords)
-				if strings.Contains(c.Request.URL.Path, "/auth/") {
+				// Sanitize sensitive data (passwords, tokens)
+				if requestBody != "" {
 					requestBody = sanitizeRequestBody(requestBody)
 				}
 			}
@@ -69,8 +104,8 @@ func LoggingMiddleware() gin.HandlerFunc {
 			}
 		}
 
-		// Capture response body
-		responseBody := responseWriter.body.String()
+		// Capture response body, without the tokens and secrets it may return
+		responseBody := sanitizeResponseBody(responseWriter.body.String())
 
 		// Limit response body size for logging (max 1000 chars)
 		if len(responseBody) > 1000 {
@@ -123,6 +158,46 @@ func LoggingMiddleware() gin.HandlerFunc {
 	}
 }
 
+// redactedValue replaces the values of sensitive keys in logs
+const redactedValue = "***REDACTED***"
+
+// sensitiveKeys holds the lowercased keys whose values are redacted from logged bodies
+var sensitiveKeys = toKeySet(DefaultSensitiveKeys)
+
+// DefaultSensitiveKeys are redacted when Config.SensitiveKeys is empty
+var DefaultSensitiveKeys = []string{
+	"password", "old_password", "new_password",
+	"token", "access_token", "refresh_token",
+	"authorization", "secret",
+}
+
+// toKeySet builds a case-insensitive lookup set from keys
+func toKeySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = true
+	}
+	return set
+}
+
+// isSensitiveKey reports whether the value of key must be redacted
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// redactSensitiveKeys redacts sensitive keys of data and of objects nested one level deep
+func redactSensitiveKeys(data map[string]interface{}, depth int) {
+	for key, value := range data {
+		if isSensitiveKey(key) {
+			data[key] = redactedValue
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && depth > 0 {
+			redactSensitiveKeys(nested, depth-1)
+		}
+	}
+}
+
 // sanitizeRequestBody removes sensitive data from request body
 func sanitizeRequestBody(body string) string {
 	var data map[string]interface{}
@@ -130,10 +205,8 @@ func sanitizeRequestBody(body string) string {
 		return "[unable to parse]"
 	}
 
-	// Remove password field
-	if _, exists := data["password"]; exists {
-		data["password"] = "***REDACTED***"
-	}
+	// Redact sensitive fields, including those of nested objects
+	redactSensitiveKeys(data, 1)
 
 	sanitized, err := json.Marshal(data)
 	if err != nil {
@@ -143,6 +216,23 @@ func sanitizeRequestBody(body string) string {
 	return string(sanitized)
 }
 
+// sanitizeResponseBody redacts sensitive keys of a JSON object response, including
+// those of the data envelope. Other bodies, such as CSV exports, are kept as they are.
+func sanitizeResponseBody(body string) string {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return body
+	}
+
+	redactSensitiveKeys(data, 1)
+
+	sanitized, err := json.Marshal(data)
+	if err != nil {
+		return "[unable to sanitize]"
+	}
+	return string(sanitized)
+}
+
 // extractErrorMessage extracts error message from response body
 func extractErrorMessage(body string) string {
 	if body == "" {
@@ -214,7 +304,7 @@ func limitString(s string, maxLen int) string {
 
 // formatInt formats integer
 func formatInt(n int64) string {
-	return string(rune(n + '0'))
+	return strconv.FormatInt(n, 10)
 }
 
 // formatFloat formats float with precision
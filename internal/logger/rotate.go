@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp suffix of rotated log files
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// rotatingFile is a log file that is rotated once it grows past maxSize bytes.
+// Rotated files are renamed with a timestamp suffix and only the newest
+// maxBackups are kept. It is safe for concurrent use.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64 // bytes; 0 disables rotation
+	maxBackups int   // 0 keeps all rotated files
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens path for appending, rotating it once it exceeds maxSize bytes
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the active log file and records its current size
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write writes p to the active file, rotating first if p would push it past maxSize
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A failed rotation is reported, but p still goes to the active file and the next
+	// write tries to rotate again
+	var rotateErr error
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		rotateErr = r.rotate()
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Close closes the active file
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// rotate renames the active file with a timestamp suffix, opens a fresh one
+// and removes the oldest rotated files beyond maxBackups
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	rotated := fmt.Sprintf("%s-%s%s", base, time.Now().Format(rotatedTimeFormat), ext)
+	if err := os.Rename(r.path, rotated); err != nil {
+		// Reopen the active file so logging goes on without rotation
+		return errors.Join(err, r.open())
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	return r.removeOldBackups(base, ext)
+}
+
+// removeOldBackups deletes the oldest rotated files so that at most maxBackups remain
+func (r *rotatingFile) removeOldBackups(base, ext string) error {
+	if r.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= r.maxBackups {
+		return nil
+	}
+
+	// Timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-r.maxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
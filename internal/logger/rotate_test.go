@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("rotates past the size threshold", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		file, err := openRotatingFile(path, 100, 0)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+
+		line := []byte(strings.Repeat("x", 59) + "\n")
+		for i := 0; i < 2; i++ {
+			if _, err := file.Write(line); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+
+		rotated, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		if len(rotated) != 1 {
+			t.Fatalf("Expected 1 rotated file, got %d", len(rotated))
+		}
+
+		data, _ := os.ReadFile(rotated[0])
+		if string(data) != string(line) {
+			t.Errorf("Rotated file content = %q, want %q", data, line)
+		}
+		data, _ = os.ReadFile(path)
+		if string(data) != string(line) {
+			t.Errorf("Active file content = %q, want %q", data, line)
+		}
+	})
+
+	t.Run("keeps at most max backups", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		file, err := openRotatingFile(path, 10, 2)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+
+		for i := 0; i < 5; i++ {
+			if _, err := file.Write([]byte("0123456789")); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+
+		rotated, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		if len(rotated) != 2 {
+			t.Errorf("Expected 2 rotated files, got %d", len(rotated))
+		}
+	})
+
+	t.Run("keeps logging when the rename fails", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		file, err := openRotatingFile(path, 100, 0)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+
+		line := []byte(strings.Repeat("x", 59) + "\n")
+		if _, err := file.Write(line); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		// Removing the active file makes the rename of the next rotation fail
+		if err := os.Remove(path); err != nil {
+			t.Fatalf("Failed to remove log file: %v", err)
+		}
+
+		if n, err := file.Write(line); err == nil || n != len(line) {
+			t.Errorf("Write = %d, %v; want %d and the rename error", n, err, len(line))
+		}
+		if _, err := file.Write([]byte("next\n")); err != nil {
+			t.Errorf("Write after failed rotation failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if string(data) != string(line)+"next\n" {
+			t.Errorf("Active file content = %q, want %q", data, string(line)+"next\n")
+		}
+	})
+
+	t.Run("no rotation without max size", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		file, err := openRotatingFile(path, 0, 0)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+
+		for i := 0; i < 10; i++ {
+			file.Write([]byte(strings.Repeat("x", 1000)))
+		}
+
+		rotated, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		if len(rotated) != 0 {
+			t.Errorf("Expected no rotated files, got %d", len(rotated))
+		}
+	})
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+
+		file, err := openRotatingFile(path, 500, 0)
+		if err != nil {
+			t.Fatalf("Failed to open log file: %v", err)
+		}
+		defer file.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					file.Write([]byte(strings.Repeat("x", 49) + "\n"))
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Every line must land intact in exactly one file
+		files, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+		var total int
+		for _, f := range files {
+			data, _ := os.ReadFile(f)
+			if len(data) > 500 {
+				t.Errorf("File %s exceeds max size: %d bytes", f, len(data))
+			}
+			total += strings.Count(string(data), "\n")
+		}
+		if total != 200 {
+			t.Errorf("Expected 200 lines across files, got %d", total)
+		}
+	})
+}
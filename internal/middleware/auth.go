@@ -9,72 +9,87 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT token and sets userID in context
+// AuthMiddleware validates JWT token and sets userID and role in context
 func AuthMiddleware(svc *service.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":      0,
-				"status_code": http.StatusUnauthorized,
-				"message":     "Unauthorized - missing token",
-				"data":        gin.H{},
-			})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized - missing token")
 			return
 		}
 
 		// Check Bearer prefix
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":      0,
-				"status_code": http.StatusUnauthorized,
-				"message":     "Unauthorized - invalid token format",
-				"data":        gin.H{},
-			})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized - invalid token format")
 			return
 		}
 
 		// Extract token
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":      0,
-				"status_code": http.StatusUnauthorized,
-				"message":     "Unauthorized - empty token",
-				"data":        gin.H{},
-			})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized - empty token")
 			return
 		}
 
 		// Validate token
-		userID, err := svc.ValidateToken(token)
+		claims, err := svc.ValidateTokenClaims(c.Request.Context(), token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":      0,
-				"status_code": http.StatusUnauthorized,
-				"message":     "Unauthorized - invalid or expired token",
-				"data":        gin.H{},
-			})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "Unauthorized - invalid or expired token")
 			return
 		}
 
-		// Set userID in context
-		c.Set("userID", userID)
+		// Set userID and role in context
+		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
+		svc.RecordActivity(c.Request.Context(), claims.UserID)
 		c.Next()
 	}
 }
 
-// CORSMiddleware handles CORS
-func CORSMiddleware() gin.HandlerFunc {
+// RequireRole allows only users whose token carries one of roles. It must run after
+// AuthMiddleware; other users get 403.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		if !allowed[c.GetString("role")] {
+			abortWithError(c, http.StatusForbidden, ErrCodeForbidden, "Forbidden - insufficient role")
+			return
+		}
+		c.Next()
+	}
+}
+
+// CORSMiddleware handles CORS. A request's Origin is echoed back, with credentials
+// allowed, only when it is in allowedOrigins. A "*" entry allows every origin without
+// credentials, since browsers reject credentials combined with a wildcard origin.
+func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		origin := c.GetHeader("Origin")
+		switch {
+		case allowAll:
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && allowed[origin]:
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !allowAll {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Response-Format")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"user-service/internal/app/models"
+	"user-service/internal/app/repository"
+	"user-service/internal/app/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// roleUserRepository is a UserRepository stub holding users by email
+type roleUserRepository struct {
+	repository.UserRepository
+	users          map[string]*models.User
+	lastSeenWrites int
+}
+
+func (r *roleUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if user, ok := r.users[email]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// SetLastSeen counts last-seen writes
+func (r *roleUserRepository) SetLastSeen(ctx context.Context, id uint, at time.Time) error {
+	r.lastSeenWrites++
+	return nil
+}
+
+// windowThrottle is a LastSeenThrottle allowing one write per user until the window is over
+type windowThrottle struct {
+	until map[uint]time.Time
+}
+
+func (t *windowThrottle) Allow(ctx context.Context, userID uint, interval time.Duration) (bool, error) {
+	if time.Now().Before(t.until[userID]) {
+		return false, nil
+	}
+	t.until[userID] = time.Now().Add(interval)
+	return true, nil
+}
+
+func TestAuthMiddleware_LastSeen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	repo := &roleUserRepository{users: map[string]*models.User{
+		"user@example.com": {ID: 1, Email: "user@example.com", Password: string(hash), Role: models.RoleUser},
+	}}
+	throttle := &windowThrottle{until: map[uint]time.Time{}}
+	svc := service.NewService(repo, nil, "secret",
+		service.WithBcryptCost(bcrypt.MinCost),
+		service.WithLastSeenThrottle(throttle),
+	)
+
+	router := gin.New()
+	router.GET("/me", AuthMiddleware(svc), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	resp, err := svc.Login(context.Background(), &models.LoginRequest{Email: "user@example.com", Password: "password123"})
+	assert.NoError(t, err)
+	get := func() {
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+resp.Token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	for i := 0; i < 5; i++ {
+		get()
+	}
+	assert.Equal(t, 1, repo.lastSeenWrites, "rapid requests within a minute write once")
+
+	// Once the minute is over the next request writes again
+	throttle.until[1] = time.Now().Add(-time.Second)
+	get()
+	assert.Equal(t, 2, repo.lastSeenWrites)
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	repo := &roleUserRepository{users: map[string]*models.User{
+		"user@example.com":  {ID: 1, Email: "user@example.com", Password: string(hash), Role: models.RoleUser},
+		"admin@example.com": {ID: 2, Email: "admin@example.com", Password: string(hash), Role: models.RoleAdmin},
+	}}
+	svc := service.NewService(repo, nil, "secret", service.WithBcryptCost(bcrypt.MinCost))
+
+	router := gin.New()
+	router.GET("/admin", AuthMiddleware(svc), RequireRole(models.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		if email != "" {
+			resp, err := svc.Login(context.Background(), &models.LoginRequest{Email: email, Password: "password123"})
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+resp.Token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	forbidden := get("user@example.com")
+	assert.Equal(t, http.StatusForbidden, forbidden.Code)
+	assert.JSONEq(t, `{"status":0,"status_code":403,"message":"Forbidden - insufficient role","data":{},"error_code":"FORBIDDEN"}`, forbidden.Body.String())
+	assert.Equal(t, http.StatusOK, get("admin@example.com").Code)
+	unauthorized := get("")
+	assert.Equal(t, http.StatusUnauthorized, unauthorized.Code)
+	assert.JSONEq(t, `{"status":0,"status_code":401,"message":"Unauthorized - missing token","data":{},"error_code":"UNAUTHORIZED"}`, unauthorized.Body.String())
+}
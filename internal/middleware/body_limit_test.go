@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBodyLimitRouter(maxBytes int64, handled *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimitMiddleware(maxBytes))
+	router.POST("/contacts", func(c *gin.Context) {
+		*handled = true
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	t.Run("oversized body is rejected before the handler", func(t *testing.T) {
+		handled := false
+		router := newBodyLimitRouter(16, &handled)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(strings.Repeat("a", 17))))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.JSONEq(t, `{"status":0,"status_code":413,"message":"Request body too large","data":{},"error_code":"REQUEST_BODY_TOO_LARGE"}`, w.Body.String())
+		assert.False(t, handled)
+	})
+
+	t.Run("body without length is capped", func(t *testing.T) {
+		handled := false
+		router := newBodyLimitRouter(16, &handled)
+
+		req := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(strings.Repeat("a", 17)))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("body within the limit", func(t *testing.T) {
+		handled := false
+		router := newBodyLimitRouter(16, &handled)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(strings.Repeat("a", 16))))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.True(t, handled)
+	})
+}
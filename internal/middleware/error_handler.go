@@ -20,12 +20,7 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 				// Check if headers were already written
 				if !c.Writer.Written() {
 					// Return consistent error response
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"status":      0,
-						"status_code": http.StatusInternalServerError,
-						"message":     "Internal server error",
-						"data":        gin.H{},
-					})
+					ErrorResponse(c, http.StatusInternalServerError, ErrCodeInternal, "Internal server error", gin.H{})
 				}
 
 				// Abort the request
@@ -40,23 +35,13 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 // NotFoundHandler handles 404 errors with consistent JSON response
 func NotFoundHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":      0,
-			"status_code": http.StatusNotFound,
-			"message":     "Endpoint not found",
-			"data":        gin.H{},
-		})
+		ErrorResponse(c, http.StatusNotFound, ErrCodeNotFound, "Endpoint not found", gin.H{})
 	}
 }
 
 // MethodNotAllowedHandler handles 405 errors with consistent JSON response
 func MethodNotAllowedHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.JSON(http.StatusMethodNotAllowed, gin.H{
-			"status":      0,
-			"status_code": http.StatusMethodNotAllowed,
-			"message":     "Method not allowed",
-			"data":        gin.H{},
-		})
+		ErrorResponse(c, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed", gin.H{})
 	}
 }
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"user-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client chosen key identifying a retried request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayHeader is set on responses replayed from the store
+	IdempotentReplayHeader = "Idempotent-Replayed"
+
+	idempotencyTTL          = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+	// idempotencyPendingTTL bounds how long a key stays reserved by a request that never
+	// finishes, e.g. because the instance handling it died
+	idempotencyPendingTTL = 5 * time.Minute
+)
+
+// IdempotencyStore keeps recorded responses by idempotency key
+type IdempotencyStore interface {
+	// Get returns the record saved under key and whether one exists
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Reserve stores a record under key only if none exists and reports whether it did
+	Reserve(ctx context.Context, key string, record []byte, ttl time.Duration) (bool, error)
+	// Save stores a record under key for the given TTL, replacing any existing one
+	Save(ctx context.Context, key string, record []byte, ttl time.Duration) error
+	// Delete removes the record saved under key
+	Delete(ctx context.Context, key string) error
+}
+
+// idempotencyRecord is the response recorded for an idempotency key. A pending record
+// reserves the key while the first request carrying it is being handled.
+type idempotencyRecord struct {
+	BodyHash    string `json:"body_hash"`
+	Pending     bool   `json:"pending,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// recordingWriter copies the response body so it can be stored for replays
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the stored response of a request retried with the same
+// Idempotency-Key instead of running the handler again. Keys are scoped to the
+// authenticated user and route. The key is reserved before the handler runs, so a
+// retry arriving while the first request is still in flight gets 409 rather than
+// running the handler twice; reusing a key with a different body also returns 409.
+// Requests without the header, and store failures, fall through to the handler.
+func IdempotencyMiddleware(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, http.StatusBadRequest, ErrCodeInvalidIdempotencyKey, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, ErrCodeInvalidRequestBody, "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		bodyHash := hex.EncodeToString(sum[:])
+		userID, _ := c.Get("userID")
+		storeKey := fmt.Sprintf("%v:%s:%s:%s", userID, c.Request.Method, c.FullPath(), key)
+
+		ctx := c.Request.Context()
+		pending, err := json.Marshal(idempotencyRecord{BodyHash: bodyHash, Pending: true})
+		if err != nil {
+			c.Next()
+			return
+		}
+		reserved, err := store.Reserve(ctx, storeKey, pending, idempotencyPendingTTL)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Failed to reserve idempotency key", "error", err)
+			c.Next()
+			return
+		}
+		if !reserved {
+			replayIdempotent(c, store, storeKey, bodyHash)
+			return
+		}
+
+		// Release the key unless a response was recorded, so failed requests, including
+		// ones that panic, may be retried
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := store.Delete(context.WithoutCancel(ctx), storeKey); err != nil {
+				logger.FromContext(ctx).Warn("Failed to release idempotency key", "error", err)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// Only successful results are replayed; failed requests may be retried
+		status := writer.Status()
+		if status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+
+		record, err := json.Marshal(idempotencyRecord{
+			BodyHash:    bodyHash,
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			return
+		}
+		if err := store.Save(context.WithoutCancel(ctx), storeKey, record, idempotencyTTL); err != nil {
+			logger.FromContext(ctx).Warn("Failed to save idempotency record", "error", err)
+			return
+		}
+		saved = true
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken: with the recorded
+// response when the first request finished with the same body, and 409 otherwise
+func replayIdempotent(c *gin.Context, store IdempotencyStore, storeKey, bodyHash string) {
+	ctx := c.Request.Context()
+	data, found, err := store.Get(ctx, storeKey)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to read idempotency record", "error", err)
+		c.Next()
+		return
+	}
+
+	var record idempotencyRecord
+	if found {
+		if err := json.Unmarshal(data, &record); err != nil {
+			logger.FromContext(ctx).Warn("Failed to decode idempotency record", "error", err)
+			c.Next()
+			return
+		}
+	}
+
+	switch {
+	case found && record.BodyHash != bodyHash:
+		abortWithError(c, http.StatusConflict, ErrCodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request body")
+	case !found || record.Pending:
+		// The first request is still running, or released the key a moment ago
+		abortWithError(c, http.StatusConflict, ErrCodeRequestInProgress, "A request with this Idempotency-Key is already in progress")
+	default:
+		c.Header(IdempotentReplayHeader, "true")
+		c.Data(record.Status, record.ContentType, record.Body)
+		c.Abort()
+	}
+}
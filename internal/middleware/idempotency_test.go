@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIdempotencyStore is an in-memory IdempotencyStore for tests
+type memoryIdempotencyStore map[string][]byte
+
+func (s memoryIdempotencyStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, ok := s[key]
+	return data, ok, nil
+}
+
+func (s memoryIdempotencyStore) Reserve(ctx context.Context, key string, record []byte, ttl time.Duration) (bool, error) {
+	if _, ok := s[key]; ok {
+		return false, nil
+	}
+	s[key] = record
+	return true, nil
+}
+
+func (s memoryIdempotencyStore) Save(ctx context.Context, key string, record []byte, ttl time.Duration) error {
+	s[key] = record
+	return nil
+}
+
+func (s memoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func newIdempotencyRouter(store IdempotencyStore, created *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contacts", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+	}, IdempotencyMiddleware(store), func(c *gin.Context) {
+		*created++
+		c.JSON(http.StatusCreated, gin.H{"id": *created})
+	})
+	return router
+}
+
+func postContact(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_Replay(t *testing.T) {
+	created := 0
+	router := newIdempotencyRouter(memoryIdempotencyStore{}, &created)
+
+	first := postContact(router, "key-1", `{"full_name":"Jane"}`)
+	second := postContact(router, "key-1", `{"full_name":"Jane"}`)
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayHeader))
+	assert.Empty(t, first.Header().Get(IdempotentReplayHeader))
+
+	// A new key creates again
+	third := postContact(router, "key-2", `{"full_name":"Jane"}`)
+	assert.Equal(t, http.StatusCreated, third.Code)
+	assert.Equal(t, 2, created)
+}
+
+func TestIdempotencyMiddleware_BodyMismatch(t *testing.T) {
+	created := 0
+	router := newIdempotencyRouter(memoryIdempotencyStore{}, &created)
+
+	postContact(router, "key-1", `{"full_name":"Jane"}`)
+	w := postContact(router, "key-1", `{"full_name":"John"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 1, created)
+}
+
+func TestIdempotencyMiddleware_WithoutKey(t *testing.T) {
+	created := 0
+	store := memoryIdempotencyStore{}
+	router := newIdempotencyRouter(store, &created)
+
+	postContact(router, "", `{"full_name":"Jane"}`)
+	postContact(router, "", `{"full_name":"Jane"}`)
+
+	assert.Equal(t, 2, created)
+	assert.Empty(t, store)
+}
+
+func TestIdempotencyMiddleware_InFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memoryIdempotencyStore{}
+	created := 0
+	var retry *httptest.ResponseRecorder
+	router := gin.New()
+	router.POST("/contacts", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+	}, IdempotencyMiddleware(store), func(c *gin.Context) {
+		created++
+		// The retry arrives while the first request is still being handled
+		if retry == nil {
+			retry = postContact(router, "key-1", `{"full_name":"Jane"}`)
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+
+	first := postContact(router, "key-1", `{"full_name":"Jane"}`)
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Contains(t, retry.Body.String(), "already in progress")
+	assert.Equal(t, 1, created)
+
+	// Once the first request finished, retries are replayed
+	replay := postContact(router, "key-1", `{"full_name":"Jane"}`)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayHeader))
+	assert.Equal(t, 1, created)
+}
+
+func TestIdempotencyMiddleware_FailureReleasesKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memoryIdempotencyStore{}
+	calls := 0
+	router := gin.New()
+	router.POST("/contacts", func(c *gin.Context) {
+		c.Set("userID", uint(1))
+	}, IdempotencyMiddleware(store), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "failed"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	first := postContact(router, "key-1", `{"full_name":"Jane"}`)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Empty(t, store)
+
+	second := postContact(router, "key-1", `{"full_name":"Jane"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	metrics := NewMetrics()
+	router := gin.New()
+	router.Use(metrics.Middleware())
+	router.GET("/metrics", metrics.Handler())
+	router.GET("/api/v1/contacts/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/api/v1/contacts/1", "/api/v1/contacts/2", "/missing"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, body, `http_requests_total{method="GET",path="/api/v1/contacts/:id",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",path="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",path="/api/v1/contacts/:id",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_in_flight{method="GET",path="/metrics"} 1`)
+	assert.NotContains(t, body, `path="/api/v1/contacts/1"`)
+}
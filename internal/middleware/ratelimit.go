@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"user-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitStore counts requests per key within fixed windows
+type RateLimitStore interface {
+	// Increment adds a request to key's counter, expiring it after ttl, and returns the new count
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// RateLimitMiddleware allows each client IP at most limit requests per window and route and
+// answers further requests with 429 and a Retry-After header until the window ends. Routes
+// sharing the middleware count separately, so one endpoint does not use up another's budget.
+// Store failures let the request through.
+func RateLimitMiddleware(store RateLimitStore, limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimitMiddleware(store, limit, window, time.Now)
+}
+
+// rateLimitMiddleware is RateLimitMiddleware with an injectable clock
+func rateLimitMiddleware(store RateLimitStore, limit int, window time.Duration, now func() time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := now()
+		windowStart := current.Truncate(window)
+		key := c.FullPath() + ":" + c.ClientIP() + ":" + strconv.FormatInt(windowStart.Unix(), 10)
+
+		count, err := store.Increment(c.Request.Context(), key, window)
+		if err != nil {
+			logger.FromContext(c).Warn("Failed to check rate limit", "error", err)
+			c.Next()
+			return
+		}
+
+		if count > int64(limit) {
+			retryAfter := int(math.Ceil(windowStart.Add(window).Sub(current).Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			abortWithError(c, http.StatusTooManyRequests, ErrCodeRateLimited, "Too many requests - please try again later")
+			return
+		}
+
+		c.Next()
+	}
+}
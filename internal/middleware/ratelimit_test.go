@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRateLimitStore is an in-memory RateLimitStore for tests; keys never expire
+type memoryRateLimitStore map[string]int64
+
+func (s memoryRateLimitStore) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s[key]++
+	return s[key], nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clock := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	router := gin.New()
+	rateLimit := rateLimitMiddleware(memoryRateLimitStore{}, 3, time.Minute, func() time.Time { return clock })
+	created := func(c *gin.Context) { c.Status(http.StatusCreated) }
+	router.POST("/contacts", rateLimit, created)
+	router.POST("/contacts/:id/tags", rateLimit, created)
+
+	postTo := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	post := func(ip string) *httptest.ResponseRecorder { return postTo("/contacts", ip) }
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusCreated, post("10.0.0.1").Code)
+	}
+
+	// Over the threshold 15s into the window
+	clock = clock.Add(15 * time.Second)
+	w := post("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "45", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"status":0,"status_code":429,"message":"Too many requests - please try again later","data":{},"error_code":"RATE_LIMITED"}`, w.Body.String())
+
+	// Other clients have their own budget
+	assert.Equal(t, http.StatusCreated, post("10.0.0.2").Code)
+
+	// So do other routes sharing the middleware, counted by route rather than by path
+	assert.Equal(t, http.StatusCreated, postTo("/contacts/1/tags", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusCreated, postTo("/contacts/2/tags", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusCreated, postTo("/contacts/3/tags", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, postTo("/contacts/4/tags", "10.0.0.1").Code)
+
+	// The next window starts afresh
+	clock = clock.Add(45 * time.Second)
+	assert.Equal(t, http.StatusCreated, post("10.0.0.1").Code)
+}
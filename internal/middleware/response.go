@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"strings"
+
+	"user-service/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of the responses written by middleware, reported in error_code like the
+// codes of the handlers. Clients should switch on these rather than on the message.
+const (
+	ErrCodeUnauthorized          = "UNAUTHORIZED"
+	ErrCodeInvalidToken          = "INVALID_TOKEN"
+	ErrCodeForbidden             = "FORBIDDEN"
+	ErrCodeRateLimited           = "RATE_LIMITED"
+	ErrCodeRequestBodyTooLarge   = "REQUEST_BODY_TOO_LARGE"
+	ErrCodeNotFound              = "NOT_FOUND"
+	ErrCodeMethodNotAllowed      = "METHOD_NOT_ALLOWED"
+	ErrCodeRequestTimeout        = "REQUEST_TIMEOUT"
+	ErrCodeInternal              = "INTERNAL_ERROR"
+	ErrCodeInvalidRequestBody    = "INVALID_REQUEST_BODY"
+	ErrCodeInvalidIdempotencyKey = "INVALID_IDEMPOTENCY_KEY"
+	ErrCodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeRequestInProgress     = "REQUEST_IN_PROGRESS"
+)
+
+// ResponseFormatHeader selects the response format of a request. With the value
+// ResponseFormatPlain responses carry no envelope: successful ones are the bare data
+// and errors are a PlainErrorResponse. The envelope is the default.
+const (
+	ResponseFormatHeader = "X-Response-Format"
+	ResponseFormatPlain  = "plain"
+)
+
+// ErrorEnvelope is the body of error responses in the default format
+type ErrorEnvelope struct {
+	Status        int         `json:"status"`
+	StatusCode    int         `json:"status_code"`
+	Message       string      `json:"message"`
+	Data          interface{} `json:"data"`
+	ErrorCode     string      `json:"error_code,omitempty"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+}
+
+// PlainErrorResponse is the error body of envelope-less responses
+type PlainErrorResponse struct {
+	Message       string      `json:"message"`
+	ErrorCode     string      `json:"error_code,omitempty"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+	Details       interface{} `json:"details,omitempty"` // e.g. the invalid fields of a validation error
+}
+
+// PlainResponse reports whether the client asked for envelope-less responses
+func PlainResponse(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(ResponseFormatHeader), ResponseFormatPlain)
+}
+
+// ErrorResponse writes an error response carrying code in the format the client asked for.
+// It is shared by middleware and handlers so every error looks the same.
+func ErrorResponse(c *gin.Context, statusCode int, code, message string, data interface{}) {
+	c.JSON(statusCode, errorBody(c, statusCode, code, message, data))
+}
+
+// abortWithError writes an error response without data and stops the chain
+func abortWithError(c *gin.Context, statusCode int, code, message string) {
+	ErrorResponse(c, statusCode, code, message, gin.H{})
+	c.Abort()
+}
+
+// errorBody builds the body of an error response
+func errorBody(c *gin.Context, statusCode int, code, message string, data interface{}) interface{} {
+	if PlainResponse(c) {
+		body := PlainErrorResponse{
+			Message:       message,
+			ErrorCode:     code,
+			CorrelationID: c.GetString(logger.CorrelationIDKey),
+		}
+		// Leave out the empty gin.H{} most errors pass as data
+		if empty, ok := data.(gin.H); data != nil && (!ok || len(empty) > 0) {
+			body.Details = data
+		}
+		return body
+	}
+
+	if data == nil {
+		data = gin.H{}
+	}
+	return ErrorEnvelope{
+		Status:        0,
+		StatusCode:    statusCode,
+		Message:       message,
+		Data:          data,
+		ErrorCode:     code,
+		CorrelationID: c.GetString(logger.CorrelationIDKey),
+	}
+}
@@ -1 +1,108 @@
 package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/private", BasicAuthMiddleware("operator", "s3cret"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"lang": c.GetString("lang"), "auth": c.GetString("auth")})
+	})
+
+	t.Run("valid credentials", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/private", nil)
+		req.SetBasicAuth("operator", "s3cret")
+		req.Header.Set("Accept-Language", "id-ID")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"lang":"id-ID","auth":"Basic b3BlcmF0b3I6czNjcmV0"}`, w.Body.String())
+	})
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "wrong password", header: "Basic b3BlcmF0b3I6d3Jvbmc="}, // operator:wrong
+		{name: "not basic auth", header: "Bearer b3BlcmF0b3I6czNjcmV0"},
+		{name: "malformed", header: "Basic not-base64!"},
+		{name: "missing", header: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/private", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, `Basic realm="Authorization Required"`, w.Header().Get("WWW-Authenticate"))
+			assert.JSONEq(t, `{"status":0,"status_code":401,"message":"Unauthorized - invalid credentials","data":{},"error_code":"UNAUTHORIZED"}`, w.Body.String())
+		})
+	}
+}
+
+func TestBasicAuthMiddleware_EmptyCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, creds := range [][2]string{{"", ""}, {"operator", ""}, {"", "s3cret"}} {
+		router := gin.New()
+		router.GET("/private", BasicAuthMiddleware(creds[0], creds[1]), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		// Neither an empty header nor the configured half of the credentials gets in
+		for _, send := range [][2]string{{"", ""}, creds} {
+			req := httptest.NewRequest("GET", "/private", nil)
+			req.SetBasicAuth(send[0], send[1])
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "configured %q, sent %q", creds, send)
+		}
+	}
+}
+
+func TestSecureHeadersMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(cfg SecureHeadersConfig) http.Header {
+		router := gin.New()
+		router.Use(SecureHeadersMiddleware(cfg))
+		router.GET("/ping", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+		return w.Header()
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		header := serve(DefaultSecureHeadersConfig())
+
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", header.Get("X-Frame-Options"))
+		assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", header.Get("Content-Security-Policy"))
+		assert.Equal(t, "max-age=31536000; includeSubDomains", header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("empty values are left out", func(t *testing.T) {
+		header := serve(SecureHeadersConfig{HSTSMaxAge: time.Hour})
+
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Empty(t, header.Get("X-Frame-Options"))
+		assert.Empty(t, header.Get("Content-Security-Policy"))
+		assert.Equal(t, "max-age=3600", header.Get("Strict-Transport-Security"))
+	})
+}
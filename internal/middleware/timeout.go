@@ -1,8 +1,13 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,43 +16,256 @@ import (
 // TimeoutMiddleware creates a middleware that times out requests after the specified duration
 func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Create a context with timeout
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
-		defer cancel()
+		runWithTimeout(c, timeout)
+	}
+}
 
-		// Replace the request context with the timeout context
-		c.Request = c.Request.WithContext(ctx)
+// DefaultTimeoutMiddleware creates a middleware with 30 seconds timeout
+func DefaultTimeoutMiddleware() gin.HandlerFunc {
+	return TimeoutMiddleware(30 * time.Second)
+}
 
-		// Channel to signal when the request is done
-		finished := make(chan struct{})
+// RouteTimeouts holds the timeouts of the routes of an engine. A zero duration
+// disables the timeout.
+type RouteTimeouts struct {
+	// Read applies to GET, HEAD and OPTIONS requests
+	Read time.Duration
+	// Write applies to all other methods
+	Write time.Duration
+	// Routes overrides both for the routes with these full paths, e.g. "/api/v1/contacts/import"
+	Routes map[string]time.Duration
+}
 
-		// Run the request in a goroutine
-		go func() {
+// RouteTimeoutMiddleware times out requests after the timeout of their route. Being
+// applied to the engine it must not be nested in another timeout, which would cap longer
+// route timeouts.
+func RouteTimeoutMiddleware(timeouts RouteTimeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := timeouts.Routes[c.FullPath()]
+		if !ok {
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				timeout = timeouts.Read
+			default:
+				timeout = timeouts.Write
+			}
+		}
+		if timeout <= 0 {
 			c.Next()
-			close(finished)
-		}()
-
-		// Wait for either the request to finish or the timeout
-		select {
-		case <-finished:
-			// Request completed successfully
 			return
-		case <-ctx.Done():
-			// Timeout occurred
-			if ctx.Err() == context.DeadlineExceeded {
-				c.JSON(http.StatusRequestTimeout, gin.H{
-					"status":      0,
-					"status_code": http.StatusRequestTimeout,
-					"message":     "Request timeout - operation took too long",
-					"data":        gin.H{},
-				})
-				c.Abort()
-			}
 		}
+		runWithTimeout(c, timeout)
 	}
 }
 
-// DefaultTimeoutMiddleware creates a middleware with 30 seconds timeout
-func DefaultTimeoutMiddleware() gin.HandlerFunc {
-	return TimeoutMiddleware(30 * time.Second)
+// runWithTimeout runs the rest of the chain with a request context that is cancelled
+// after timeout. The handlers write to a buffer that is only copied to the client when
+// they finish first; on timeout a 408 is sent right away and whatever they write
+// afterwards is discarded. It waits for them to return so none outlives the request.
+func runWithTimeout(c *gin.Context, timeout time.Duration) {
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	// Replace the request context with the timeout context
+	c.Request = c.Request.WithContext(ctx)
+
+	writer := newTimeoutWriter(c.Writer)
+	c.Writer = writer
+	defer func() { c.Writer = writer.w }()
+
+	// Channel to signal when the request is done
+	finished := make(chan struct{})
+
+	// Run the request in a goroutine
+	go func() {
+		c.Next()
+		close(finished)
+	}()
+
+	// Wait for either the request to finish or the timeout
+	select {
+	case <-finished:
+		// Request completed successfully
+		writer.commit()
+		return
+	case <-ctx.Done():
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		body, _ := json.Marshal(errorBody(c, http.StatusRequestTimeout, ErrCodeRequestTimeout,
+			"Request timeout - operation took too long", gin.H{}))
+		writer.timeOut(body)
+	} else {
+		// The client went away; nothing is sent but the handlers may still write
+		writer.discard()
+	}
+	// The handlers see the cancelled context; gin must not reuse c before they are done
+	<-finished
+}
+
+// timeoutWriter buffers the response of handlers running under a timeout. Only the
+// middleware writes to the client: the buffer when the handlers finish, or the timeout
+// response. Handlers that flush get their response sent right away and can no longer
+// time out; their further writes pass through until the timeout.
+type timeoutWriter struct {
+	w gin.ResponseWriter
+
+	mu        sync.Mutex
+	header    http.Header
+	status    int
+	size      int
+	body      bytes.Buffer
+	committed bool
+	timedOut  bool
+}
+
+var _ gin.ResponseWriter = (*timeoutWriter)(nil)
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		w:      w,
+		header: w.Header().Clone(),
+		status: http.StatusOK,
+		size:   -1,
+	}
+}
+
+// Header returns the headers of the buffered response; changes after it was sent have no effect
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code > 0 && w.size < 0 {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size < 0 {
+		w.size = 0
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+
+	var n int
+	var err error
+	if w.committed {
+		n, err = w.w.Write(data)
+	} else {
+		n, err = w.body.Write(data)
+	}
+	w.size += n
+	return n, err
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+func (w *timeoutWriter) Written() bool {
+	return w.Size() >= 0
+}
+
+// Flush sends what was written so far
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.commitLocked()
+	w.w.Flush()
+}
+
+// Hijack is not supported, a hijacked connection cannot be timed out
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func (w *timeoutWriter) CloseNotify() <-chan bool {
+	return w.w.CloseNotify()
+}
+
+func (w *timeoutWriter) Pusher() http.Pusher {
+	return nil
+}
+
+// commit sends the buffered response unless the request timed out
+func (w *timeoutWriter) commit() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.commitLocked()
+	}
+}
+
+func (w *timeoutWriter) commitLocked() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+
+	header := w.w.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.w.WriteHeader(w.status)
+	if w.size >= 0 {
+		w.w.WriteHeaderNow()
+	}
+	if w.body.Len() > 0 {
+		_, _ = w.w.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// timeOut sends body as the timeout response, unless the handlers already flushed
+// theirs, and stops further writes
+func (w *timeoutWriter) timeOut(body []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	if w.committed {
+		return
+	}
+
+	w.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.w.WriteHeader(http.StatusRequestTimeout)
+	_, _ = w.w.Write(body)
+	// Send it now rather than when the handlers return
+	w.w.Flush()
+}
+
+// discard stops further writes without sending anything
+func (w *timeoutWriter) discard() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
 }
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTimeoutRouter serves /slow and /import with handlers that take delay to answer
+func newTimeoutRouter(timeouts RouteTimeouts, delay time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RouteTimeoutMiddleware(timeouts))
+	slow := func(c *gin.Context) {
+		time.Sleep(delay)
+		c.JSON(http.StatusOK, gin.H{"canceled": c.Request.Context().Err() != nil})
+	}
+	router.GET("/slow", slow)
+	router.POST("/slow", slow)
+	router.POST("/import", slow)
+	return router
+}
+
+func TestRouteTimeoutMiddleware(t *testing.T) {
+	timeouts := RouteTimeouts{
+		Read:   10 * time.Millisecond,
+		Write:  time.Second,
+		Routes: map[string]time.Duration{"/import": time.Second},
+	}
+
+	t.Run("handler exceeding its route timeout gets 408", func(t *testing.T) {
+		router := newTimeoutRouter(timeouts, 50*time.Millisecond)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+		assert.Equal(t, http.StatusRequestTimeout, w.Code)
+		// The late write of the handler is discarded
+		assert.JSONEq(t, `{"status":0,"status_code":408,"message":"Request timeout - operation took too long","data":{},"error_code":"REQUEST_TIMEOUT"}`, w.Body.String())
+	})
+
+	t.Run("writes use the write timeout", func(t *testing.T) {
+		router := newTimeoutRouter(timeouts, 50*time.Millisecond)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/slow", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"canceled":false}`, w.Body.String())
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		router := newTimeoutRouter(RouteTimeouts{
+			Write:  10 * time.Millisecond,
+			Routes: map[string]time.Duration{"/import": time.Second},
+		}, 50*time.Millisecond)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/import", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("streamed response is sent as it is flushed", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(TimeoutMiddleware(10 * time.Millisecond))
+		router.GET("/export", func(c *gin.Context) {
+			c.Header("Content-Type", "text/csv")
+			c.String(http.StatusOK, "full_name,phone\n")
+			c.Writer.Flush()
+			time.Sleep(50 * time.Millisecond)
+			c.String(http.StatusOK, "Jane,081234567890\n")
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+		// Past the timeout the rest of the stream is cut off
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, "full_name,phone\n", w.Body.String())
+	})
+
+	t.Run("zero disables the timeout", func(t *testing.T) {
+		router := newTimeoutRouter(RouteTimeouts{}, 20*time.Millisecond)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+// TestTimeoutMiddleware_ConcurrentWrite has the handler write as the timeout fires; run
+// with -race it catches the handler and the middleware sharing the response. Either may
+// win, but the response must be entirely one or the other.
+func TestTimeoutMiddleware_ConcurrentWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TimeoutMiddleware(5 * time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		for i := 0; i < 100; i++ {
+			c.Header("X-Attempt", "late")
+			c.JSON(http.StatusOK, gin.H{"late": true})
+			c.Writer.Flush()
+		}
+	})
+
+	for i := 0; i < 20; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+		if w.Code == http.StatusRequestTimeout {
+			assert.Empty(t, w.Header().Get("X-Attempt"))
+			assert.JSONEq(t, `{"status":0,"status_code":408,"message":"Request timeout - operation took too long","data":{},"error_code":"REQUEST_TIMEOUT"}`, w.Body.String())
+		} else {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "late", w.Header().Get("X-Attempt"))
+			assert.True(t, strings.HasPrefix(w.Body.String(), `{"late":true}`))
+		}
+	}
+}
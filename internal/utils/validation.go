@@ -10,9 +10,11 @@ var (
 	// Email validation regex - RFC 5322 compliant
 	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
-	// Phone validation regex - supports international format
-	// Supports: +62812345678, 0812345678, +1234567890, etc.
-	phoneRegex = regexp.MustCompile(`^(\+?\d{1,3}[-.\s]?)?(\(?\d{2,4}\)?[-.\s]?)?\d{6,10}$`)
+	// International phone regex - E.164: a country code never starts with 0
+	internationalPhoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{9,14}$`)
+
+	// National phone regex - trunk 0 or a country code written without +
+	nationalPhoneRegex = regexp.MustCompile(`^[0-9]{10,13}$`)
 
 	// Indonesia phone number regex (more strict)
 	indonesiaPhoneRegex = regexp.MustCompile(`^(\+62|62|0)[0-9]{9,13}$`)
@@ -35,28 +37,36 @@ func ValidateEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
-// ValidatePhone validates phone number format
+// phoneSeparators are the characters people type between digit groups
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// CleanPhone trims phone and removes separators (spaces, dashes, dots and parentheses),
+// e.g. "+62 (812) 3456-7890" becomes "+6281234567890"
+func CleanPhone(phone string) string {
+	return phoneSeparators.Replace(strings.TrimSpace(phone))
+}
+
+// ValidatePhone validates phone number format. Separators are ignored (see CleanPhone).
+// Accepted formats:
+//   - international: + followed by 10-15 digits, not starting with 0 (e.g. +6281234567890)
+//   - national: 10-13 digits starting with the trunk 0 (e.g. 081234567890)
+//   - country code without +: 10-13 digits (e.g. 6281234567890)
+//
+// Indonesian numbers that keep the trunk 0 after the 62 country code are rejected.
 func ValidatePhone(phone string) bool {
-	if phone == "" {
+	cleanPhone := CleanPhone(phone)
+	if cleanPhone == "" {
 		return false
 	}
 
-	phone = strings.TrimSpace(phone)
-
-	// Remove common separators for validation
-	cleanPhone := strings.ReplaceAll(phone, " ", "")
-	cleanPhone = strings.ReplaceAll(cleanPhone, "-", "")
-	cleanPhone = strings.ReplaceAll(cleanPhone, ".", "")
-	cleanPhone = strings.ReplaceAll(cleanPhone, "(", "")
-	cleanPhone = strings.ReplaceAll(cleanPhone, ")", "")
-
-	// Check length (minimum 10 digits, maximum 15 digits with country code)
-	if len(cleanPhone) < 10 || len(cleanPhone) > 15 {
+	if strings.HasPrefix(strings.TrimPrefix(cleanPhone, "+"), "620") {
 		return false
 	}
 
-	// Check format using cleaned phone
-	return phoneRegex.MatchString(cleanPhone)
+	if strings.HasPrefix(cleanPhone, "+") {
+		return internationalPhoneRegex.MatchString(cleanPhone)
+	}
+	return nationalPhoneRegex.MatchString(cleanPhone)
 }
 
 // ValidateIndonesiaPhone validates Indonesian phone number format
@@ -66,14 +76,7 @@ func ValidateIndonesiaPhone(phone string) bool {
 		return false
 	}
 
-	phone = strings.TrimSpace(phone)
-
-	// Remove common separators
-	cleanPhone := strings.ReplaceAll(phone, " ", "")
-	cleanPhone = strings.ReplaceAll(cleanPhone, "-", "")
-	cleanPhone = strings.ReplaceAll(cleanPhone, ".", "")
-
-	return indonesiaPhoneRegex.MatchString(cleanPhone)
+	return indonesiaPhoneRegex.MatchString(CleanPhone(phone))
 }
 
 // ValidatePassword validates password strength
@@ -170,12 +173,7 @@ func SanitizePhone(phone string) string {
 // Input: 62812345678 -> Output: +62812345678
 // Input: +62812345678 -> Output: +62812345678
 func NormalizeIndonesiaPhone(phone string) string {
-	phone = strings.TrimSpace(phone)
-
-	// Remove separators
-	phone = strings.ReplaceAll(phone, " ", "")
-	phone = strings.ReplaceAll(phone, "-", "")
-	phone = strings.ReplaceAll(phone, ".", "")
+	phone = CleanPhone(phone)
 
 	// Convert to +62 format
 	if strings.HasPrefix(phone, "0") {
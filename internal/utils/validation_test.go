@@ -40,8 +40,13 @@ func TestValidatePhone(t *testing.T) {
 		{"valid - with spaces", "+62 812 3456 7890", true},
 		{"valid - with dashes", "+62-812-3456-7890", true},
 		{"valid - US format", "+12345678901", true},
+		{"valid - with parentheses", "(0812) 3456-7890", true},
+		{"valid - 62 without plus", "6281234567890", true},
 		{"invalid - too short", "12345", false},
 		{"invalid - too long", "12345678901234567", false},
+		{"invalid - too long without plus", "12345678901234", false},
+		{"invalid - country code starting with 0", "+0812345678901", false},
+		{"invalid - trunk 0 after +62", "+62 0812 3456 7890", false},
 		{"invalid - letters", "081234abcd", false},
 		{"invalid - empty", "", false},
 	}
@@ -55,6 +60,26 @@ func TestValidatePhone(t *testing.T) {
 	}
 }
 
+func TestCleanPhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  string
+	}{
+		{"+62 812 3456 7890", "+6281234567890"},
+		{" (0812) 3456-7890 ", "081234567890"},
+		{"0812.3456.7890", "081234567890"},
+		{"+6281234567890", "+6281234567890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			if got := CleanPhone(tt.phone); got != tt.want {
+				t.Errorf("CleanPhone(%q) = %q, want %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestValidateIndonesiaPhone(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -0,0 +1,203 @@
+package coreclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultTimeout bounds each request made by a Client
+const defaultTimeout = 10 * time.Second
+
+// maxErrorBodySize caps how much of a failed response is kept in a StatusError
+const maxErrorBodySize = 4 << 10
+
+// Client makes JSON requests to a base URL
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retry      RetryPolicy
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithTimeout bounds each request, including reading the response body.
+// Non-positive values keep the 10-second default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.httpClient.Timeout = timeout
+		}
+	}
+}
+
+// WithTransport sends requests through transport instead of http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithRetry retries failed requests according to policy. Without it every request is
+// attempted once. Only enable it for upstreams where repeating a request is safe.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New creates a client for baseURL. With an empty base URL, request paths must be absolute URLs.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RequestOption customizes a single request
+type RequestOption func(*http.Request)
+
+// WithHeader sets a header on the request
+func WithHeader(key, value string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+// StatusError is returned by DoJSON for responses outside the 2xx range
+type StatusError struct {
+	StatusCode int
+	// Body is the start of the response body
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Post sends body to path as JSON. A []byte body is sent unchanged, e.g. when it was
+// already encoded to be signed; anything else is marshaled. The caller must close the
+// response body.
+func (c *Client) Post(path string, body any, opts ...RequestOption) (*http.Response, error) {
+	return c.PostContext(context.Background(), path, body, opts...)
+}
+
+// PostContext is Post bound to ctx; cancelling it aborts the request and any retries
+func (c *Client) PostContext(ctx context.Context, path string, body any, opts ...RequestOption) (*http.Response, error) {
+	return c.do(ctx, http.MethodPost, path, body, opts...)
+}
+
+// PutJSON sends body to path with PUT, encoded like Post. The caller must close the response body.
+func (c *Client) PutJSON(path string, body any, opts ...RequestOption) (*http.Response, error) {
+	return c.PutJSONContext(context.Background(), path, body, opts...)
+}
+
+// PutJSONContext is PutJSON bound to ctx; cancelling it aborts the request and any retries
+func (c *Client) PutJSONContext(ctx context.Context, path string, body any, opts ...RequestOption) (*http.Response, error) {
+	return c.do(ctx, http.MethodPut, path, body, opts...)
+}
+
+// DeleteJSON sends a DELETE to path, with body encoded like Post unless it is nil.
+// The caller must close the response body.
+func (c *Client) DeleteJSON(path string, body any, opts ...RequestOption) (*http.Response, error) {
+	return c.DeleteJSONContext(context.Background(), path, body, opts...)
+}
+
+// DeleteJSONContext is DeleteJSON bound to ctx; cancelling it aborts the request and any retries
+func (c *Client) DeleteJSONContext(ctx context.Context, path string, body any, opts ...RequestOption) (*http.Response, error) {
+	return c.do(ctx, http.MethodDelete, path, body, opts...)
+}
+
+// DoJSON sends req to path as JSON and decodes the response into respOut unless it is
+// nil. Responses outside the 2xx range return a *StatusError.
+func (c *Client) DoJSON(method, path string, req, respOut any, opts ...RequestOption) error {
+	return c.DoJSONContext(context.Background(), method, path, req, respOut, opts...)
+}
+
+// DoJSONContext is DoJSON bound to ctx; cancelling it aborts the request and any retries
+func (c *Client) DoJSONContext(ctx context.Context, method, path string, req, respOut any, opts ...RequestOption) error {
+	resp, err := c.do(ctx, method, path, req, opts...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if respOut == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(respOut); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return nil
+}
+
+// do sends a request with a JSON body, which is omitted when body is nil. Requests
+// carry Content-Type and Accept headers for JSON unless opts override them. Failed
+// attempts are retried according to the client's retry policy.
+// The caller must close the response body.
+func (c *Client) do(ctx context.Context, method, path string, body any, opts ...RequestOption) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var ok bool
+		if payload, ok = body.([]byte); !ok {
+			var err error
+			if payload, err = json.Marshal(body); err != nil {
+				return nil, fmt.Errorf("failed to encode request body: %w", err)
+			}
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", "application/json")
+		for _, opt := range opts {
+			opt(req)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if attempt >= c.retry.MaxAttempts || !retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := c.retry.delay(attempt, resp)
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
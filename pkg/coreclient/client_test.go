@@ -0,0 +1,76 @@
+package coreclient
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type echoRequest struct {
+	Name string `json:"name"`
+}
+
+type echoResponse struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+}
+
+func TestClient_DoJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+
+		var req echoRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(echoResponse{Method: r.Method, Path: r.URL.Path, Name: req.Name})
+	}))
+	defer server.Close()
+
+	client := New(server.URL + "/")
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			var resp echoResponse
+			err := client.DoJSON(method, "/contacts/1", echoRequest{Name: "Jane"}, &resp, WithHeader("Authorization", "token"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, echoResponse{Method: method, Path: "/contacts/1", Name: "Jane"}, resp)
+		})
+	}
+}
+
+func TestClient_DoJSONStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message":"phone number already exists"}`))
+	}))
+	defer server.Close()
+
+	var resp echoResponse
+	err := New(server.URL).DoJSON(http.MethodPost, "/contacts", echoRequest{Name: "Jane"}, &resp)
+
+	var statusErr *StatusError
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, http.StatusConflict, statusErr.StatusCode)
+		assert.Contains(t, statusErr.Body, "phone number already exists")
+	}
+	assert.Empty(t, resp)
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	err := New(server.URL, WithTimeout(10*time.Millisecond)).DoJSON(http.MethodGet, "/", nil, nil)
+
+	assert.Error(t, err)
+}
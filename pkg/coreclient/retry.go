@@ -0,0 +1,66 @@
+package coreclient
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Connection errors, 429 and
+// 5xx responses are retried; a Retry-After header overrides the computed delay.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; values below 2 disable retries
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles for every further retry
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts, including Retry-After; zero means no cap
+	MaxDelay time.Duration
+	// Jitter randomly shortens each computed delay by up to this fraction (0 to 1)
+	Jitter float64
+}
+
+// retryable reports whether an attempt's outcome is worth retrying
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		// A cancelled or expired context fails every further attempt too
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// delay returns how long to wait after the given failed attempt
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	delay, ok := retryAfter(resp)
+	if !ok {
+		// Bound the shift so large attempt counts cannot overflow
+		delay = p.BaseDelay << min(attempt-1, 20)
+		if p.Jitter > 0 {
+			delay -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(delay))
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SlowQueryReporter is called for every query that took at least the slow query threshold.
+// sql keeps its placeholders: bound values, which may hold passwords or tokens, are never passed.
+type SlowQueryReporter func(ctx context.Context, sql string, elapsed time.Duration)
+
+// WithSlowQueryLog reports queries taking threshold or longer to report; zero disables it
+func WithSlowQueryLog(threshold time.Duration, report SlowQueryReporter) Option {
+	return func(o *options) {
+		o.slowQueryThreshold = threshold
+		o.slowQueryReporter = report
+	}
+}
+
+const (
+	slowQueryStartCallback  = "slow_query:start"
+	slowQueryReportCallback = "slow_query:report"
+	slowQueryStartKey       = "slow_query:start"
+)
+
+// registerSlowQueryLog times every GORM operation with callbacks around it
+func registerSlowQueryLog(database *gorm.DB, threshold time.Duration, report SlowQueryReporter) error {
+	start := func(tx *gorm.DB) {
+		tx.InstanceSet(slowQueryStartKey, time.Now())
+	}
+	finish := func(tx *gorm.DB) {
+		started, ok := tx.InstanceGet(slowQueryStartKey)
+		if !ok {
+			return
+		}
+		if elapsed := time.Since(started.(time.Time)); elapsed >= threshold {
+			report(tx.Statement.Context, tx.Statement.SQL.String(), elapsed)
+		}
+	}
+
+	callbacks := database.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register(slowQueryStartCallback, start),
+		callbacks.Create().After("gorm:create").Register(slowQueryReportCallback, finish),
+		callbacks.Query().Before("gorm:query").Register(slowQueryStartCallback, start),
+		callbacks.Query().After("gorm:query").Register(slowQueryReportCallback, finish),
+		callbacks.Update().Before("gorm:update").Register(slowQueryStartCallback, start),
+		callbacks.Update().After("gorm:update").Register(slowQueryReportCallback, finish),
+		callbacks.Delete().Before("gorm:delete").Register(slowQueryStartCallback, start),
+		callbacks.Delete().After("gorm:delete").Register(slowQueryReportCallback, finish),
+		callbacks.Row().Before("gorm:row").Register(slowQueryStartCallback, start),
+		callbacks.Row().After("gorm:row").Register(slowQueryReportCallback, finish),
+		callbacks.Raw().Before("gorm:raw").Register(slowQueryStartCallback, start),
+		callbacks.Raw().After("gorm:raw").Register(slowQueryReportCallback, finish),
+	)
+}
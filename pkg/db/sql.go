@@ -1,10 +1,79 @@
 package db
 
 import (
+	"fmt"
+	"time"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-func NewSQLConnection(dsn string) (*gorm.DB, error) {
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// PoolConfig sizes the connection pool of the underlying sql.DB. Zero values keep the
+// database/sql defaults: unlimited open connections, 2 idle ones and no lifetime limit.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Option configures a connection opened by NewSQLConnection
+type Option func(*options)
+
+type options struct {
+	pool               PoolConfig
+	prepareStmt        bool
+	slowQueryThreshold time.Duration
+	slowQueryReporter  SlowQueryReporter
+}
+
+// WithPool applies the given pool settings once the connection is open
+func WithPool(pool PoolConfig) Option {
+	return func(o *options) {
+		o.pool = pool
+	}
+}
+
+// WithPrepareStmt makes GORM cache prepared statements for the queries it runs
+func WithPrepareStmt(enabled bool) Option {
+	return func(o *options) {
+		o.prepareStmt = enabled
+	}
+}
+
+func NewSQLConnection(dsn string, opts ...Option) (*gorm.DB, error) {
+	return open(mysql.Open(dsn), opts...)
+}
+
+// open connects through dialector and configures the connection pool
+func open(dialector gorm.Dialector, opts ...Option) (*gorm.DB, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	database, err := gorm.Open(dialector, &gorm.Config{PrepareStmt: o.prepareStmt})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := database.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	if o.pool.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(o.pool.MaxOpenConns)
+	}
+	if o.pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(o.pool.MaxIdleConns)
+	}
+	if o.pool.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(o.pool.ConnMaxLifetime)
+	}
+
+	if o.slowQueryThreshold > 0 && o.slowQueryReporter != nil {
+		if err := registerSlowQueryLog(database, o.slowQueryThreshold, o.slowQueryReporter); err != nil {
+			return nil, fmt.Errorf("failed to register slow query log: %w", err)
+		}
+	}
+	return database, nil
 }
@@ -1,12 +1,87 @@
 package db
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
 )
 
 func TestNewSQLConnection(t *testing.T) {
 	_, err := NewSQLConnection("invalid-dsn")
 	assert.Error(t, err)
 }
+
+func TestOpen_AppliesPool(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	dialector := mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true})
+	database, err := open(dialector, WithPool(PoolConfig{
+		MaxOpenConns:    7,
+		MaxIdleConns:    3,
+		ConnMaxLifetime: time.Minute,
+	}), WithPrepareStmt(true))
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, sqlDB.Stats().MaxOpenConnections)
+	assert.True(t, database.Config.PrepareStmt)
+}
+
+func TestOpen_ZeroPoolKeepsDefaults(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	database, err := open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, sqlDB.Stats().MaxOpenConnections) // unlimited
+	assert.False(t, database.Config.PrepareStmt)
+}
+
+func TestOpen_SlowQueryLog(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	type report struct {
+		ctx     context.Context
+		sql     string
+		elapsed time.Duration
+	}
+	var reports []report
+	database, err := open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		WithSlowQueryLog(20*time.Millisecond, func(ctx context.Context, sql string, elapsed time.Duration) {
+			reports = append(reports, report{ctx, sql, elapsed})
+		}))
+	require.NoError(t, err)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	mock.ExpectQuery("SELECT id FROM users WHERE password = \\?").
+		WithArgs("s3cret").
+		WillDelayFor(30 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id FROM users WHERE email = \\?").
+		WithArgs("fast@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	var id int
+	require.NoError(t, database.WithContext(ctx).Raw("SELECT id FROM users WHERE password = ?", "s3cret").Scan(&id).Error)
+	require.NoError(t, database.WithContext(ctx).Raw("SELECT id FROM users WHERE email = ?", "fast@example.com").Scan(&id).Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Only the slow query is reported, with its context and without bound values
+	require.Len(t, reports, 1)
+	assert.Equal(t, "SELECT id FROM users WHERE password = ?", reports[0].sql)
+	assert.NotContains(t, reports[0].sql, "s3cret")
+	assert.GreaterOrEqual(t, reports[0].elapsed, 20*time.Millisecond)
+	assert.Equal(t, "request", reports[0].ctx.Value(ctxKey{}))
+}
@@ -0,0 +1,55 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPSender(t *testing.T) {
+	sender := NewSMTPSender("smtp.example.com", "587", "mailer", "secret", "no-reply@example.com")
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg string
+	sender.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, string(msg)
+		return nil
+	}
+
+	err := sender.SendVerificationEmail(context.Background(), "john@example.com", "verify-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "no-reply@example.com", gotFrom)
+	assert.Equal(t, []string{"john@example.com"}, gotTo)
+	assert.Contains(t, gotMsg, "To: john@example.com\r\n")
+	assert.Contains(t, gotMsg, "Subject: Verify your email address\r\n")
+	assert.True(t, strings.HasSuffix(gotMsg, "verify-token\r\n"))
+
+	t.Run("rejects header injection", func(t *testing.T) {
+		err := sender.SendPasswordResetEmail(context.Background(), "john@example.com\r\nBcc: eve@example.com", "reset-token")
+		assert.Error(t, err)
+	})
+
+	t.Run("wraps delivery errors", func(t *testing.T) {
+		sender.send = func(string, smtp.Auth, string, []string, []byte) error {
+			return errors.New("connection refused")
+		}
+		err := sender.SendPasswordResetEmail(context.Background(), "john@example.com", "reset-token")
+		assert.EqualError(t, err, "failed to send email: connection refused")
+	})
+}
+
+func TestLogSender(t *testing.T) {
+	var logged []any
+	sender := NewLogSender(func(msg string, args ...any) {
+		logged = append([]any{msg}, args...)
+	})
+
+	assert.NoError(t, sender.SendPasswordResetEmail(context.Background(), "john@example.com", "reset-token"))
+	assert.Equal(t, []any{"Password reset email", "to", "john@example.com", "token", "reset-token"}, logged)
+}
@@ -14,5 +14,10 @@ func NewRedisClient(addr, password string, db int) *redis.Client {
 }
 
 func PingRedis(client *redis.Client) error {
-	return client.Ping(context.Background()).Err()
+	return PingRedisContext(context.Background(), client)
+}
+
+// PingRedisContext pings Redis, giving up when ctx is done
+func PingRedisContext(ctx context.Context, client *redis.Client) error {
+	return client.Ping(ctx).Err()
 }
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const idempotencyKeyPrefix = "idempotency:"
+
+// IdempotencyStore keeps responses recorded for idempotency keys in Redis
+type IdempotencyStore struct {
+	client *redis.Client
+}
+
+func NewIdempotencyStore(client *redis.Client) *IdempotencyStore {
+	return &IdempotencyStore{client: client}
+}
+
+// Get returns the record saved under key and whether one exists
+func (s *IdempotencyStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := s.client.Get(ctx, idempotencyKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Reserve stores a record under key only if none exists (SETNX) and reports whether it did
+func (s *IdempotencyStore) Reserve(ctx context.Context, key string, record []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, idempotencyKeyPrefix+key, record, ttl).Result()
+}
+
+// Save stores a record under key for the given TTL, replacing any existing one
+func (s *IdempotencyStore) Save(ctx context.Context, key string, record []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, idempotencyKeyPrefix+key, record, ttl).Err()
+}
+
+// Delete removes the record saved under key
+func (s *IdempotencyStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, idempotencyKeyPrefix+key).Err()
+}
@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const lastSeenKeyPrefix = "last_seen:"
+
+// LastSeenThrottle lets one last-seen write per user through per interval using SET NX
+type LastSeenThrottle struct {
+	client *redis.Client
+}
+
+func NewLastSeenThrottle(client *redis.Client) *LastSeenThrottle {
+	return &LastSeenThrottle{client: client}
+}
+
+// Allow reports whether the user's last activity may be written now, holding off
+// further writes for interval when it may
+func (t *LastSeenThrottle) Allow(ctx context.Context, userID uint, interval time.Duration) (bool, error) {
+	key := lastSeenKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+	return t.client.SetNX(ctx, key, 1, interval).Result()
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const loginAttemptsKeyPrefix = "login_attempts:"
+
+// LoginAttemptCounter tracks failed login attempts in a Redis sorted set scored by timestamp,
+// so that only failures inside the sliding window are counted
+type LoginAttemptCounter struct {
+	client *redis.Client
+}
+
+func NewLoginAttemptCounter(client *redis.Client) *LoginAttemptCounter {
+	return &LoginAttemptCounter{client: client}
+}
+
+// Count returns the number of failures recorded for key within the window
+func (c *LoginAttemptCounter) Count(ctx context.Context, key string, window time.Duration) (int64, error) {
+	redisKey := loginAttemptsKeyPrefix + key
+	now := time.Now()
+
+	var card *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, redisKey, "-inf", strconv.FormatInt(now.Add(-window).UnixNano(), 10))
+		card = pipe.ZCard(ctx, redisKey)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return card.Val(), nil
+}
+
+// Increment records a failure for key and returns the count within the window
+func (c *LoginAttemptCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	redisKey := loginAttemptsKeyPrefix + key
+	now := time.Now()
+	score := now.UnixNano()
+
+	var card *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, redisKey, "-inf", strconv.FormatInt(now.Add(-window).UnixNano(), 10))
+		pipe.ZAdd(ctx, redisKey, redis.Z{Score: float64(score), Member: strconv.FormatInt(score, 10)})
+		card = pipe.ZCard(ctx, redisKey)
+		pipe.Expire(ctx, redisKey, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return card.Val(), nil
+}
+
+// Reset clears all recorded failures for key
+func (c *LoginAttemptCounter) Reset(ctx context.Context, key string) error {
+	return c.client.Del(ctx, loginAttemptsKeyPrefix+key).Err()
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const profileKeyPrefix = "profile:"
+
+// ProfileCache keeps encoded user profiles in Redis
+type ProfileCache struct {
+	client *redis.Client
+}
+
+func NewProfileCache(client *redis.Client) *ProfileCache {
+	return &ProfileCache{client: client}
+}
+
+// Get returns the profile cached for a user and whether one exists
+func (c *ProfileCache) Get(ctx context.Context, userID uint) ([]byte, bool, error) {
+	data, err := c.client.Get(ctx, profileKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set caches a user's profile for the given TTL
+func (c *ProfileCache) Set(ctx context.Context, userID uint, profile []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, profileKey(userID), profile, ttl).Err()
+}
+
+// Delete removes a user's cached profile
+func (c *ProfileCache) Delete(ctx context.Context, userID uint) error {
+	return c.client.Del(ctx, profileKey(userID)).Err()
+}
+
+func profileKey(userID uint) string {
+	return profileKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "rate_limit:"
+
+// RateLimitCounter counts requests per key and window with INCR and EXPIRE
+type RateLimitCounter struct {
+	client *redis.Client
+}
+
+func NewRateLimitCounter(client *redis.Client) *RateLimitCounter {
+	return &RateLimitCounter{client: client}
+}
+
+// Increment adds a request to key's counter, expiring it after ttl, and returns the new count
+func (c *RateLimitCounter) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	redisKey := rateLimitKeyPrefix + key
+
+	var incr *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, redisKey)
+		pipe.Expire(ctx, redisKey, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const revokedTokenKeyPrefix = "revoked_token:"
+
+// RevocationStore keeps revoked token IDs in Redis until the token would have expired anyway
+type RevocationStore struct {
+	client *redis.Client
+}
+
+func NewRevocationStore(client *redis.Client) *RevocationStore {
+	return &RevocationStore{client: client}
+}
+
+// Revoke adds a token ID to the revocation set for the given TTL
+func (s *RevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, revokedTokenKeyPrefix+jti, 1, ttl).Err()
+}
+
+// IsRevoked reports whether a token ID has been revoked
+func (s *RevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.Exists(ctx, revokedTokenKeyPrefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
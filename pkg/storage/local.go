@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// LocalStorage stores files in a directory on the local filesystem
+type LocalStorage struct {
+	dir       string
+	urlPrefix string
+}
+
+// NewLocalStorage stores files under dir; saved files are served under urlPrefix
+func NewLocalStorage(dir, urlPrefix string) *LocalStorage {
+	return &LocalStorage{dir: dir, urlPrefix: urlPrefix}
+}
+
+// Save writes data to a file with the given name and returns the path it is served under
+func (s *LocalStorage) Save(ctx context.Context, name string, data []byte) (string, error) {
+	name = filepath.Base(name)
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return path.Join(s.urlPrefix, name), nil
+}
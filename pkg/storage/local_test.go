@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "avatars")
+	store := NewLocalStorage(dir, "/uploads/avatars")
+
+	url, err := store.Save(context.Background(), "../1-avatar.png", []byte("data"))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if url != "/uploads/avatars/1-avatar.png" {
+		t.Errorf("unexpected url %q", url)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "1-avatar.png"))
+	if err != nil {
+		t.Fatalf("file not written: %v", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("unexpected file content %q", data)
+	}
+}
@@ -1,3 +1,83 @@
 package test
 
 // Integration tests for the app package.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"user-service/configs"
+	"user-service/internal/app/handlers"
+	"user-service/internal/app/routes"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newRouter builds the application router on top of a mocked database
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("failed to open gorm connection: %v", err)
+	}
+
+	handler, err := handlers.NewHandler(configs.Config{AvatarDir: t.TempDir()}, gormDB, nil)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupRoutes(router, handler, handler.GetService())
+	return router
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	router := newRouter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		status  int
+		message string
+		code    string
+	}{
+		{"unknown path", http.MethodGet, "/api/v1/does-not-exist", http.StatusNotFound, "Endpoint not found", "NOT_FOUND"},
+		{"unsupported method", http.MethodPatch, "/health", http.StatusMethodNotAllowed, "Method not allowed", "METHOD_NOT_ALLOWED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, float64(0), body["status"])
+			assert.Equal(t, float64(tt.status), body["status_code"])
+			assert.Equal(t, tt.message, body["message"])
+			assert.Equal(t, map[string]interface{}{}, body["data"])
+			assert.Equal(t, tt.code, body["error_code"])
+		})
+	}
+}